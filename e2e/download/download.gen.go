@@ -9,22 +9,33 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"crypto/sha1"
+	"encoding"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/url"
-	"path"
 	"reflect"
+	"regexp"
+	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 )
 
-var _ = time.Now // need this to make sure the time package is imported
+var _ = time.Now               // need this to make sure the time package is imported
+var _ = utf8.RuneCountInString // need this to make sure the utf8 package is imported
 //
 // Custom Errors
 //
@@ -53,38 +64,34 @@ var AllHttpServiceMethodPaths = []string{
 
 type httpDownloadServiceServer struct {
 	service HttpDownloadService
-	route0  httpServiceMethodHandler
+	config  httpServerConfig
+	router  *httpRouter
 }
 
 var _ http.Handler = (*httpDownloadServiceServer)(nil)
 
 func (s *httpDownloadServiceServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	var handler httpServiceMethodHandler
-
-	switch r.URL.Path {
-	case PathHttpDownloadServiceGetMethod:
-		handler = s.route0
-	default:
-		httpResponseError(w, ErrServiceMethodNotFound.WithMsg("method %q not found", r.URL.Path))
-		return
-	}
-
-	ctx := injectCommonVars(r.Context(), w, r)
-
-	handler(ctx, w, r)
+	s.router.ServeHTTP(w, r)
 }
 
-func CreateDownloadServiceServer(service HttpDownloadService) http.Handler {
+func CreateDownloadServiceServer(service HttpDownloadService, opts ...HttpServerOption) http.Handler {
 	server := httpDownloadServiceServer{
 		service: service,
+		router:  newHttpRouter(),
+	}
+
+	for _, opt := range opts {
+		opt(&server.config)
 	}
-	server.route0 = server.createGet()
+	server.router.handle("GET", PathHttpDownloadServiceGetMethod, server.createGet())
 
 	return &server
 }
 
 func (s *httpDownloadServiceServer) createGet() httpServiceMethodHandler {
+	call := s.config.createCall("DownloadService", "Get")
 	return createStreamBinaryServiceMethod(
+		call,
 		"GET",
 		false,
 		func(ctx context.Context, args *struct {
@@ -99,17 +106,25 @@ func (s *httpDownloadServiceServer) createGet() httpServiceMethodHandler {
 //
 
 type httpDownloadServiceClient struct {
-	client *http.Client
 	host   string
+	config httpClientConfig
 }
 
 var _ HttpDownloadService = (*httpDownloadServiceClient)(nil)
 
-func CreateHttpDownloadServiceClient(host string, client *http.Client) HttpDownloadService {
-	return &httpDownloadServiceClient{
-		host:   host,
-		client: client,
+func CreateHttpDownloadServiceClient(host string, client *http.Client, opts ...HttpClientOption) HttpDownloadService {
+	c := &httpDownloadServiceClient{
+		host: host,
+		config: httpClientConfig{
+			client: client,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&c.config)
 	}
+
+	return c
 }
 
 func (s *httpDownloadServiceClient) Get(ctx context.Context) (asset io.Reader, filename string, contentType string, err error) {
@@ -118,6 +133,8 @@ func (s *httpDownloadServiceClient) Get(ctx context.Context) (asset io.Reader, f
 		return
 	}
 
+	call := s.config.createCall("DownloadService", "Get")
+
 	_in_ := struct {
 	}{}
 
@@ -126,7 +143,7 @@ func (s *httpDownloadServiceClient) Get(ctx context.Context) (asset io.Reader, f
 		Filename    string    `json:"filename"`
 		ContentType string    `json:"content_type"`
 	}{}
-	_out_.Asset, _out_.Filename, _out_.ContentType, err = callHttpStreamEndpoint(ctx, s.client, url, "GET", &_in_)
+	_out_.Asset, _out_.Filename, _out_.ContentType, err = callHttpStreamEndpoint(ctx, call, url, "GET", &_in_)
 	if err != nil {
 		return
 	}
@@ -158,8 +175,83 @@ type rpcAdaptor interface {
 	Send(ctx context.Context, topic string, data []byte) ([]byte, error)
 }
 
+// RpcMetadata is sent along with each rpc call, e.g. auth token, request id
+// or trace context, please refer to CreateCtxRpcMetadata and GetCtxRpcMetadata
+type RpcMetadata map[string]string
+
+// rpcEnvelope is the data of each rpc call, the args are carried along with the
+// caller's metadata and its absolute deadline, so the server can rebuild the
+// caller's context, stream is the reply topic of the stream methods
+type rpcEnvelope struct {
+	Metadata RpcMetadata     `json:"metadata,omitempty"`
+	Deadline *time.Time      `json:"deadline,omitempty"`
+	Stream   string          `json:"stream,omitempty"`
+	Args     json.RawMessage `json:"args"`
+}
+
+// CreateCtxRpcMetadata injects the metadata into the context which is sent by
+// rpc clients, the metadata is merged with the one already in the context
+func CreateCtxRpcMetadata(ctx context.Context, md RpcMetadata) context.Context {
+	outgoing, _ := getCtxValue[RpcMetadata](ctx, ctxKeyRpcOutgoingMetadata)
+
+	merged := make(RpcMetadata, len(outgoing)+len(md))
+	for key, value := range outgoing {
+		merged[key] = value
+	}
+	for key, value := range md {
+		merged[key] = value
+	}
+
+	return context.WithValue(ctx, ctxKeyRpcOutgoingMetadata, merged)
+}
+
+// GetCtxRpcMetadata returns the metadata sent by the caller of the rpc method,
+// it is not sent automatically by the calls made inside the method, use
+// CreateCtxRpcMetadata to forward it
+func GetCtxRpcMetadata(ctx context.Context) (result RpcMetadata, ok bool) {
+	return getCtxValue[RpcMetadata](ctx, ctxKeyRpcIncomingMetadata)
+}
+
 func rpcSend[T any](ctx context.Context, adaptor rpcAdaptor, topic string, in any, out *T) (*T, error) {
-	data, err := json.Marshal(in)
+	data, err := rpcCall(ctx, adaptor, topic, "", in)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, out)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// newRpcEnvelope returns the envelope of the args along with the outgoing metadata of ctx
+func newRpcEnvelope(ctx context.Context, in any) (envelope rpcEnvelope, err error) {
+	envelope.Args, err = json.Marshal(in)
+	if err != nil {
+		return envelope, err
+	}
+
+	envelope.Metadata, _ = getCtxValue[RpcMetadata](ctx, ctxKeyRpcOutgoingMetadata)
+
+	return envelope, nil
+}
+
+// rpcCall sends the args along with the metadata and the deadline of ctx to the topic,
+// stream is the reply topic of stream methods, and returns the reply of the server
+func rpcCall(ctx context.Context, adaptor rpcAdaptor, topic string, stream string, in any) ([]byte, error) {
+	envelope, err := newRpcEnvelope(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope.Stream = stream
+	if deadline, ok := ctx.Deadline(); ok {
+		envelope.Deadline = &deadline
+	}
+
+	data, err := json.Marshal(envelope)
 	if err != nil {
 		return nil, err
 	}
@@ -171,648 +263,3179 @@ func rpcSend[T any](ctx context.Context, adaptor rpcAdaptor, topic string, in an
 
 	err, ok := decodeRpcError(data)
 	if ok {
+		if ctxErr := rpcCtxErr(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return nil, err
 	}
 
-	err = json.Unmarshal(data, out)
-	if err != nil {
-		return nil, err
+	return data, nil
+}
+
+// rpcCtxErr returns the error of ctx, the server's context shares the same deadline,
+// so it might reply before the client's context notices that the deadline has passed
+func rpcCtxErr(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	return out, nil
+	if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
+	}
+
+	return nil
 }
 
-// CONTEXT UTILITIES
-// Injecting/extracting values from context usually used for http handlers
+// rpcReceive decodes the args of the call into in and rebuilds the caller's context,
+// which carries the metadata and is cancelled once the caller's deadline passes,
+// cancel must be called once the call is done. stream is the reply topic of the
+// stream methods.
+func rpcReceive(data []byte, in any) (ctx context.Context, cancel context.CancelFunc, stream string, err error) {
+	var envelope rpcEnvelope
+	if err = json.Unmarshal(data, &envelope); err != nil {
+		return nil, nil, "", err
+	}
 
-type ctxKey string
+	if err = json.Unmarshal(envelope.Args, in); err != nil {
+		return nil, nil, "", err
+	}
 
-const (
-	ctxKeyRequest      ctxKey = "http_request"
-	ctxKeyResponse     ctxKey = "http_response"
-	ctxKeyClientMapper ctxKey = "ella_http_client_mapper"
-)
+	ctx = context.Background()
+	if envelope.Metadata != nil {
+		ctx = context.WithValue(ctx, ctxKeyRpcIncomingMetadata, envelope.Metadata)
+	}
 
-type ctxClientMapper struct {
-	HttpResponse *http.Response
+	if envelope.Deadline != nil {
+		ctx, cancel = context.WithDeadline(ctx, *envelope.Deadline)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	// the caller has already given up, so there is no point to call the method
+	if err = ctx.Err(); err != nil {
+		cancel()
+		return nil, nil, "", err
+	}
+
+	return ctx, cancel, envelope.Stream, nil
 }
 
-// CreateCtxClientMapper inject a special object into the context which
-// let's Ella's generated code to inject some values to the context
-// Please refer to GetCtxClientMapper for accessing the values
-// NOTE: use this function only in test functions, to get access to http.Response object
-// of the http client to extract cookies, headers, etc.
-func CreateCtxClientMapper(ctx context.Context) context.Context {
-	return context.WithValue(ctx, ctxKeyClientMapper, &ctxClientMapper{})
+// RPC SERVER UTILITIES
+// Each call is handled in its own goroutine, so a slow method doesn't block the other
+// calls of the topic. The number of in-flight calls can be limited per service and per
+// method, the calls which exceed the limits wait in a queue, and once the queue is full,
+// they are rejected with ErrServerBusy.
+
+// rpcServerDefaultMaxQueue is the size of the queue of each limit if WithRpcServerMaxQueue
+// is not used, so the queued calls and their goroutines are bounded
+const rpcServerDefaultMaxQueue = 100
+
+// RpcServerOption configures the rpc server started by StartRpc{Service}Server
+type RpcServerOption func(*rpcServerConfig)
+
+type rpcServerConfig struct {
+	maxConcurrency       int
+	maxMethodConcurrency map[string]int
+	maxQueue             int
 }
 
-func GetCtxClientMapper(ctx context.Context) (*ctxClientMapper, bool) {
-	return getCtxValue[*ctxClientMapper](ctx, ctxKeyClientMapper)
+// WithRpcServerMaxConcurrency limits the number of in-flight calls of the service,
+// which is shared by all its methods, zero means unlimited
+func WithRpcServerMaxConcurrency(limit int) RpcServerOption {
+	return func(c *rpcServerConfig) {
+		c.maxConcurrency = limit
+	}
 }
 
-func GetCtxHttpRequest(ctx context.Context) (result *http.Request, ok bool) {
-	return getCtxValue[*http.Request](ctx, ctxKeyRequest)
+// WithRpcMethodMaxConcurrency limits the number of in-flight calls of the method,
+// e.g. WithRpcMethodMaxConcurrency("SayHello", 10), zero means unlimited
+func WithRpcMethodMaxConcurrency(method string, limit int) RpcServerOption {
+	return func(c *rpcServerConfig) {
+		if c.maxMethodConcurrency == nil {
+			c.maxMethodConcurrency = make(map[string]int)
+		}
+		c.maxMethodConcurrency[method] = limit
+	}
 }
 
-func GetCtxHttpResponse(ctx context.Context) (result http.ResponseWriter, ok bool) {
-	return getCtxValue[http.ResponseWriter](ctx, ctxKeyResponse)
+// WithRpcServerMaxQueue limits the number of calls which wait for a free slot of each
+// limit, the calls are rejected with ErrServerBusy once the queue is full, by default,
+// the queue has 100 calls, and a negative size means the queue is unbounded
+func WithRpcServerMaxQueue(size int) RpcServerOption {
+	return func(c *rpcServerConfig) {
+		c.maxQueue = size
+	}
 }
 
-func getCtxValue[T any](ctx context.Context, key ctxKey) (result T, ok bool) {
-	value := ctx.Value(key)
-	if value == nil {
-		return result, false
+// rpcLimiter limits the in-flight calls by slots, and the in-flight and queued calls
+// by tickets, a nil limiter doesn't limit anything
+type rpcLimiter struct {
+	slots   chan struct{}
+	tickets chan struct{} // nil if the queue is unbounded
+}
+
+func newRpcLimiter(limit int, queue int) *rpcLimiter {
+	if limit <= 0 {
+		return nil
 	}
-	result, ok = value.(T)
-	return result, ok
+
+	limiter := &rpcLimiter{slots: make(chan struct{}, limit)}
+	if queue >= 0 {
+		limiter.tickets = make(chan struct{}, limit+queue)
+	}
+
+	return limiter
 }
 
-func injectCommonVars(ctx context.Context, w http.ResponseWriter, r *http.Request) context.Context {
-	ctx = context.WithValue(ctx, ctxKeyRequest, r)
-	ctx = context.WithValue(ctx, ctxKeyResponse, w)
-	return ctx
+// admit reserves a place for the call without blocking, it returns false if the queue is full
+func (l *rpcLimiter) admit() bool {
+	if l == nil || l.tickets == nil {
+		return true
+	}
+
+	select {
+	case l.tickets <- struct{}{}:
+		return true
+	default:
+		return false
+	}
 }
 
-func injectHttpClientResponse(ctx context.Context, resp *http.Response) {
-	clientMapper, ok := ctx.Value(ctxKeyClientMapper).(*ctxClientMapper)
-	if !ok {
+func (l *rpcLimiter) leave() {
+	if l == nil || l.tickets == nil {
 		return
 	}
-
-	clientMapper.HttpResponse = resp
+	<-l.tickets
 }
 
-// HTTP SERVER UTILITIES
-// Helper utilities for creating http servers
+func (l *rpcLimiter) acquire() {
+	if l == nil {
+		return
+	}
+	l.slots <- struct{}{}
+}
 
-type httpServiceMethodHandler func(context.Context, http.ResponseWriter, *http.Request)
+func (l *rpcLimiter) release() {
+	if l == nil {
+		return
+	}
+	<-l.slots
+}
 
-func createServiceMethodHandler[ReqMsg, RespMsg any](method string, hasFields bool, fn func(ctx context.Context, req *ReqMsg) (*RespMsg, error)) httpServiceMethodHandler {
-	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
-		if r.Method != method {
-			httpResponseError(w, ErrMethodNotAllowed.WithMsg("method %q not allowed", r.Method))
-			return
-		}
+type rpcServer struct {
+	service string
+	limiter *rpcLimiter
+	methods map[string]*rpcLimiter
 
-		defer func() {
-			if err := recover(); err != nil {
-				// In case of a panic, serve a 500 error and then panic.
-				w.WriteHeader(http.StatusInternalServerError)
-				panic(err)
-			}
-		}()
+	// ctx is cancelled once the server is drained, to stop the streams
+	ctx    context.Context
+	cancel context.CancelFunc
 
-		var reqMsg ReqMsg
+	mux     sync.Mutex
+	drained bool
+	calls   sync.WaitGroup
+}
 
-		if method == http.MethodGet {
-			if err := valuesToStruct(r.URL.Query(), &reqMsg); err != nil {
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-		} else if hasFields {
-			if err := checkContentType(r, "application/json"); err != nil {
-				w.WriteHeader(http.StatusUnsupportedMediaType)
-				return
-			}
+func newRpcServer(service string, opts ...RpcServerOption) *rpcServer {
+	config := rpcServerConfig{maxQueue: rpcServerDefaultMaxQueue}
+	for _, opt := range opts {
+		opt(&config)
+	}
 
-			if err := json.NewDecoder(r.Body).Decode(&reqMsg); err != nil {
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-		}
+	ctx, cancel := context.WithCancel(context.Background())
 
-		respMsg, err := fn(ctx, &reqMsg)
-		if err != nil {
-			httpResponseError(w, err)
-			return
-		}
+	server := &rpcServer{
+		service: service,
+		limiter: newRpcLimiter(config.maxConcurrency, config.maxQueue),
+		methods: make(map[string]*rpcLimiter),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
 
-		httpResponse(w, http.StatusOK, respMsg)
+	for method, limit := range config.maxMethodConcurrency {
+		server.methods[method] = newRpcLimiter(limit, config.maxQueue)
 	}
-}
 
-type streamEvent struct {
-	id    int64
-	event string
-	data  string
+	return server
 }
 
-func createStreamServiceMethod[ReqMsg any](method string, hasFields bool, fn func(ctx context.Context, req *ReqMsg) (<-chan *streamEvent, error)) httpServiceMethodHandler {
-	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
-		if r.Method != method {
-			httpResponseError(w, ErrMethodNotAllowed.WithMsg("method %q not allowed", r.Method))
-			return
-		}
+// handle returns the recvFunc of the method, which runs fn in a new goroutine once both
+// the service and the method have a free slot, if fn panics, the call is replied with
+// ErrInternal, unless it is already replied
+func (s *rpcServer) handle(method string, fn func(msg rpcMsg)) recvFunc {
+	limiter := s.methods[method]
 
-		fluser, ok := w.(http.Flusher)
-		if !ok {
-			httpResponseError(w, ErrFlusherNotSupported)
+	return func(msg rpcMsg) {
+		if !s.admit(limiter) {
+			msg.Reply(encodeRpcError(ErrServerBusy))
 			return
 		}
 
-		defer func() {
-			if err := recover(); err != nil {
-				// In case of a panic, serve a 500 error and then panic.
-				w.WriteHeader(http.StatusInternalServerError)
-				panic(err)
-			}
-		}()
+		go func() {
+			defer s.calls.Done()
+			defer s.limiter.leave()
+			defer limiter.leave()
 
-		var reqMsg ReqMsg
+			limiter.acquire()
+			defer limiter.release()
 
-		if method == http.MethodGet {
-			if err := valuesToStruct(r.URL.Query(), &reqMsg); err != nil {
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-		} else if hasFields {
-			if err := checkContentType(r, "application/json"); err != nil {
-				w.WriteHeader(http.StatusUnsupportedMediaType)
-				return
-			}
+			s.limiter.acquire()
+			defer s.limiter.release()
 
-			if err := json.NewDecoder(r.Body).Decode(&reqMsg); err != nil {
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-		}
+			msg := &rpcServerMsg{rpcMsg: msg}
 
-		ctx, cancel := context.WithCancel(ctx)
-		defer cancel()
+			defer func() {
+				if r := recover(); r != nil {
+					slog.Error("rpc method panicked", "service", s.service, "method", method, "panic", r, "stack", string(debug.Stack()))
+					if !msg.replied.Load() {
+						msg.Reply(encodeRpcError(ErrInternal))
+					}
+				}
+			}()
 
-		events, err := fn(ctx, &reqMsg)
-		if err != nil {
-			httpResponseError(w, err)
-			return
-		}
+			fn(msg)
+		}()
+	}
+}
 
-		w.Header().Set("Content-Type", "text/event-stream")
-		w.Header().Set("Cache-Control", "no-cache")
-		w.Header().Set("Connection", "keep-alive")
-		fluser.Flush()
+func (s *rpcServer) admit(limiter *rpcLimiter) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
 
-		var buffer bytes.Buffer
+	if s.drained || !limiter.admit() {
+		return false
+	}
 
-		var lastID int64
-		for item := range events {
-			buffer.Reset()
+	if !s.limiter.admit() {
+		limiter.leave()
+		return false
+	}
 
-			buffer.WriteString("id: ")
-			buffer.WriteString(strconv.FormatInt(item.id, 10))
-			buffer.WriteString("\nevent: ")
-			buffer.WriteString(item.event)
-			buffer.WriteString("\ndata: ")
-			buffer.WriteString(item.data)
-			buffer.WriteString("\n\n")
+	s.calls.Add(1)
+	return true
+}
 
-			_, err := io.Copy(w, &buffer)
-			if err != nil {
-				return
-			}
-			fluser.Flush()
-			lastID = item.id
-		}
+// cancelOnDrain calls cancel once the server is drained, the returned func stops it
+func (s *rpcServer) cancelOnDrain(cancel context.CancelFunc) (stop func() bool) {
+	return context.AfterFunc(s.ctx, cancel)
+}
 
-		lastID++
-		fmt.Fprintf(w, "id: %d\nevent: done\ndata: {}\n\n", lastID)
-	}
+// drain rejects the new calls, stops the streams and waits for the in-flight calls
+func (s *rpcServer) drain() {
+	s.mux.Lock()
+	s.drained = true
+	s.mux.Unlock()
+
+	s.cancel()
+	s.calls.Wait()
 }
 
-func createStreamBinaryServiceMethod[ReqMsg any](method string, hasFields bool, fn func(ctx context.Context, req *ReqMsg) (io.Reader, string, string, error)) httpServiceMethodHandler {
-	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
-		if r.Method != method {
-			httpResponseError(w, ErrMethodNotAllowed.WithMsg("method %q not allowed", r.Method))
-			return
-		}
+// rpcServerMsg records whether the call is replied, so the call is not replied twice
+type rpcServerMsg struct {
+	rpcMsg
+	replied atomic.Bool
+}
 
-		defer func() {
-			if err := recover(); err != nil {
-				// In case of a panic, serve a 500 error and then panic.
-				w.WriteHeader(http.StatusInternalServerError)
-				panic(err)
-			}
-		}()
+func (m *rpcServerMsg) Reply(data []byte) error {
+	m.replied.Store(true)
+	return m.rpcMsg.Reply(data)
+}
 
-		var reqMsg ReqMsg
+// RPC STREAM UTILITIES
+// The items of rpc streams are sent to a reply topic which is registered by the client
+// for each call, its name is sent as the stream of the call's envelope. Each item is
+// sent as a frame and the server waits for the client to reply to it before sending
+// the next one, so a slow consumer slows down the producer. The stream is ended by
+// a frame with either done or error, and the client replies with an error to stop
+// the server once it gives up.
 
-		if method == http.MethodGet {
-			if err := valuesToStruct(r.URL.Query(), &reqMsg); err != nil {
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-		} else if hasFields {
-			if err := checkContentType(r, "application/json"); err != nil {
-				w.WriteHeader(http.StatusUnsupportedMediaType)
-				return
-			}
+const rpcStreamEndTimeout = 5 * time.Second
 
-			if err := json.NewDecoder(r.Body).Decode(&reqMsg); err != nil {
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-		}
+// rpcStreamAck is the reply to the stream call and to each frame of the stream
+var rpcStreamAck = []byte("{}")
 
-		ctx, cancel := context.WithCancel(ctx)
+type rpcStreamFrame struct {
+	Data  json.RawMessage `json:"data,omitempty"`
+	Done  bool            `json:"done,omitempty"`
+	Error string          `json:"error,omitempty"` // encoded by encodeRpcError
+}
+
+// rpcStreamTopic returns a unique reply topic for the stream call
+func rpcStreamTopic(topic string) (string, error) {
+	var id [16]byte
+	if _, err := io.ReadFull(rand.Reader, id[:]); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s.stream.%x", topic, id), nil
+}
+
+// rpcStream calls the stream method and returns the stream of items sent by the server,
+// once ctx is done, the stream is closed with the error of ctx, and the server is
+// stopped as soon as it sends the next item
+func rpcStream[T any](ctx context.Context, adaptor rpcAdaptor, topic string, in any) (*Stream[T], error) {
+	stream, err := rpcStreamTopic(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	out := NewStream[T](0)
+
+	var drainOnce sync.Once
+	var drain func()
+	stop := func() {
+		drainOnce.Do(drain)
+	}
+
+	drain, err = adaptor.Register(stream, func(msg rpcMsg) {
+		select {
+		case <-out.done():
+			msg.Reply(encodeRpcError(context.Canceled))
+			stop()
+			return
+		default:
+		}
+
+		var frame rpcStreamFrame
+		if err := json.Unmarshal(msg.Data(), &frame); err != nil {
+			out.Close(ErrInternal.WithCause(err))
+			msg.Reply(encodeRpcError(err))
+			stop()
+			return
+		}
+
+		switch {
+		case frame.Error != "":
+			err, _ := decodeRpcError([]byte(frame.Error))
+			if ctxErr := rpcCtxErr(ctx); ctxErr != nil {
+				out.Close(ctxErr)
+			} else {
+				out.Close(err)
+			}
+			msg.Reply(rpcStreamAck)
+			stop()
+		case frame.Done:
+			out.Close(nil)
+			msg.Reply(rpcStreamAck)
+			stop()
+		default:
+			var item T
+			if err := json.Unmarshal(frame.Data, &item); err != nil {
+				out.Close(ErrInternal.WithCause(err))
+				msg.Reply(encodeRpcError(err))
+				stop()
+				return
+			}
+
+			if err := out.Send(ctx, item); err != nil {
+				// the stream is already closed if ctx is done meanwhile
+				out.Close(err)
+				msg.Reply(encodeRpcError(err))
+				stop()
+				return
+			}
+
+			msg.Reply(rpcStreamAck)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = rpcCall(ctx, adaptor, topic, stream, in)
+	if err != nil {
+		stop()
+		return nil, err
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			out.Close(ctx.Err())
+		case <-out.done():
+		}
+	}()
+
+	return out, nil
+}
+
+// rpcStreamSend sends the items to the reply topic of the call until the stream
+// is closed, the caller gives up or ctx is done, the stream is always ended with either
+// done or error frame
+func rpcStreamSend[T any](ctx context.Context, adaptor rpcAdaptor, stream string, items *Stream[T]) {
+	send := func(ctx context.Context, frame rpcStreamFrame) bool {
+		data, err := json.Marshal(frame)
+		if err != nil {
+			return false
+		}
+
+		ack, err := adaptor.Send(ctx, stream, data)
+		if err != nil {
+			return false
+		}
+
+		_, cancelled := decodeRpcError(ack)
+		return !cancelled
+	}
+
+	// the end frame is sent even if ctx is done, so the client can stop listening
+	sendEnd := func(err error) {
+		ctx, cancel := context.WithTimeout(context.Background(), rpcStreamEndTimeout)
 		defer cancel()
 
-		streamReader, filename, contentType, err := fn(ctx, &reqMsg)
 		if err != nil {
-			httpResponseError(w, err)
+			send(ctx, rpcStreamFrame{Error: string(encodeRpcError(err))})
+		} else {
+			send(ctx, rpcStreamFrame{Done: true})
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			sendEnd(ctx.Err())
 			return
+		case item, ok := <-items.Items():
+			if !ok {
+				sendEnd(items.Err())
+				return
+			}
+
+			data, err := json.Marshal(item)
+			if err != nil {
+				sendEnd(ErrInternal.WithCause(err))
+				return
+			}
+
+			if !send(ctx, rpcStreamFrame{Data: data}) {
+				// the caller might be still waiting, unless it has already given up
+				if err := ctx.Err(); err != nil {
+					sendEnd(err)
+				}
+				// the caller's context might notice the deadline sooner, so the
+				// deadline is waited for to end ctx by it rather than being cancelled
+				if rpcCtxErr(ctx) == context.DeadlineExceeded {
+					<-ctx.Done()
+				}
+				return
+			}
 		}
-		if closer, ok := streamReader.(io.Closer); ok {
-			defer closer.Close()
+	}
+}
+
+// RPC EVENT UTILITIES
+// Events are published to all the receivers registered for their topics, e.g. by
+// several services, and no reply is expected. Only the metadata of the publisher's
+// context is sent, so the deadline of the publisher doesn't limit the subscribers.
+
+// rpcEventAdaptor is used by the events, Publish delivers the data to all the receivers
+// registered for the topic and returns without waiting for them
+type rpcEventAdaptor interface {
+	rpcAdaptor
+	Publish(ctx context.Context, topic string, data []byte) error
+}
+
+func rpcPublish(ctx context.Context, adaptor rpcEventAdaptor, topic string, in any) error {
+	envelope, err := newRpcEnvelope(ctx, in)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	return adaptor.Publish(ctx, topic, data)
+}
+
+// rpcSubscribe registers fn for the events of the topic, the args of each event are
+// decoded into a new value of In and validated, invalid events are logged and dropped
+// as there is no one to reply to
+func rpcSubscribe[In any](adaptor rpcEventAdaptor, topic string, fn func(ctx context.Context, in *In)) (unsubscribe func(), err error) {
+	return adaptor.Register(topic, func(msg rpcMsg) {
+		var in In
+
+		ctx, cancel, _, err := rpcReceive(msg.Data(), &in)
+		if err != nil {
+			slog.Error("failed to decode rpc event", "topic", topic, "error", err)
+			return
 		}
+		defer cancel()
 
-		if contentType == "" {
-			contentType = "application/octet-stream"
+		if err := validateArgs(&in); err != nil {
+			slog.Error("invalid rpc event", "topic", topic, "error", err)
+			return
 		}
 
-		w.Header().Set("Content-Type", contentType)
-		if filename != "" {
-			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+		fn(ctx, &in)
+	})
+}
+
+// RPC TRANSPORT UTILITIES
+// RpcConnAdaptor carries the rpc calls and events over persistent connections, e.g. unix
+// domain sockets, tcp or http connections, so processes can talk without a message broker.
+// Both sides of a connection tell each other about the topics they register, so the calls
+// are routed to the peer which registered the topic, and each connection is reused by all
+// the calls. Each frame is written as:
+// - size of the rest of the frame, uint32
+// - kind of the frame, byte, e.g. register, request or reply
+// - id of the request, uint64, replies have the id of their requests
+// - size of the topic, uint16, followed by the topic
+// - data, the rest of the frame
+// Frames are written by a goroutine per connection, so the reader of the connection never
+// blocks on writes, and the messages of each topic are queued up to a limit, so a slow
+// receiver can't make the connection buffer all the messages of its peer.
+
+const (
+	rpcFrameRegister byte = iota + 1
+	rpcFrameUnregister
+	rpcFrameRequest
+	rpcFrameReply
+	rpcFramePublish
+)
+
+const (
+	rpcFrameHeaderSize = 1 + 8 + 2
+	rpcFrameMaxSize    = 64 << 20
+	rpcHttpUpgrade     = "ella-rpc"
+)
+
+type rpcFrame struct {
+	kind  byte
+	id    uint64
+	topic string
+	data  []byte
+}
+
+func encodeRpcFrame(frame rpcFrame) ([]byte, error) {
+	if len(frame.topic) > math.MaxUint16 {
+		return nil, fmt.Errorf("rpc topic is too long: %d bytes", len(frame.topic))
+	}
+
+	size := rpcFrameHeaderSize + len(frame.topic) + len(frame.data)
+	if size > rpcFrameMaxSize {
+		return nil, fmt.Errorf("rpc frame is too big: %d bytes", size)
+	}
+
+	buf := make([]byte, 0, 4+size)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(size))
+	buf = append(buf, frame.kind)
+	buf = binary.BigEndian.AppendUint64(buf, frame.id)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(frame.topic)))
+	buf = append(buf, frame.topic...)
+	buf = append(buf, frame.data...)
+
+	return buf, nil
+}
+
+func readRpcFrame(r io.Reader) (frame rpcFrame, err error) {
+	var sizeBuf [4]byte
+	if _, err = io.ReadFull(r, sizeBuf[:]); err != nil {
+		return frame, err
+	}
+
+	size := binary.BigEndian.Uint32(sizeBuf[:])
+	if size < rpcFrameHeaderSize || size > rpcFrameMaxSize {
+		return frame, fmt.Errorf("invalid rpc frame size: %d bytes", size)
+	}
+
+	buf := make([]byte, size)
+	if _, err = io.ReadFull(r, buf); err != nil {
+		return frame, err
+	}
+
+	frame.kind = buf[0]
+	frame.id = binary.BigEndian.Uint64(buf[1:9])
+	topicSize := int(binary.BigEndian.Uint16(buf[9:11]))
+	if rpcFrameHeaderSize+topicSize > len(buf) {
+		return frame, fmt.Errorf("invalid rpc frame topic size: %d bytes", topicSize)
+	}
+	frame.topic = string(buf[rpcFrameHeaderSize : rpcFrameHeaderSize+topicSize])
+	frame.data = buf[rpcFrameHeaderSize+topicSize:]
+
+	return frame, nil
+}
+
+type rpcConnMsg struct {
+	topic   string
+	data    []byte
+	replyFn func(data []byte) error
+}
+
+var _ rpcMsg = (*rpcConnMsg)(nil)
+
+func (m *rpcConnMsg) Topic() string {
+	return m.topic
+}
+
+func (m *rpcConnMsg) Data() []byte {
+	return m.data
+}
+
+func (m *rpcConnMsg) Reply(data []byte) error {
+	return m.replyFn(data)
+}
+
+const (
+	// rpcConnReceiverMaxQueue is the number of messages which can wait for a receiver,
+	// once the queue is full, calls are rejected with ErrServerBusy and events are dropped
+	rpcConnReceiverMaxQueue = 1000
+	// rpcConnMaxPendingFrames is the number of frames which can wait to be written to a
+	// connection, the senders wait once it's full, except the reader of the connection
+	rpcConnMaxPendingFrames = 1024
+)
+
+// rpcConnReceiver is registered for a topic, its messages are queued, so the connections
+// are never blocked by slow receivers, and they are received one at a time in order
+type rpcConnReceiver struct {
+	recv   recvFunc
+	mux    sync.Mutex
+	queue  []rpcMsg
+	closed bool
+	notify chan struct{}
+	done   chan struct{}
+}
+
+func newRpcConnReceiver(recv recvFunc) *rpcConnReceiver {
+	r := &rpcConnReceiver{
+		recv:   recv,
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+
+	go r.run()
+
+	return r
+}
+
+// push queues the message without blocking, it returns an error if the queue is full
+// or the receiver is drained, in which case the message is not received
+func (r *rpcConnReceiver) push(msg rpcMsg) error {
+	r.mux.Lock()
+	switch {
+	case r.closed:
+		r.mux.Unlock()
+		return ErrServiceMethodNotFound.WithMsg("receiver of %s is drained", msg.Topic())
+	case len(r.queue) >= rpcConnReceiverMaxQueue:
+		r.mux.Unlock()
+		return ErrServerBusy.WithMsg("receiver of %s is busy", msg.Topic())
+	}
+	r.queue = append(r.queue, msg)
+	r.mux.Unlock()
+
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// close stops the receiver, the messages which are not received yet are rejected, so
+// their senders don't wait for them, and the ones pushed afterwards are rejected by push
+func (r *rpcConnReceiver) close() {
+	r.mux.Lock()
+	r.closed = true
+	queue := r.queue
+	r.queue = nil
+	r.mux.Unlock()
+
+	close(r.done)
+
+	for _, msg := range queue {
+		msg.Reply(encodeRpcError(ErrServiceMethodNotFound.WithMsg("receiver of %s is drained", msg.Topic())))
+	}
+}
+
+func (r *rpcConnReceiver) run() {
+	for {
+		select {
+		case <-r.notify:
+		case <-r.done:
+			return
 		}
 
-		io.Copy(w, streamReader)
+		for {
+			r.mux.Lock()
+			if r.closed || len(r.queue) == 0 {
+				r.mux.Unlock()
+				break
+			}
+			msg := r.queue[0]
+			r.queue = r.queue[1:]
+			r.mux.Unlock()
+
+			r.recv(msg)
+		}
 	}
 }
 
-func createServiceMethodUploadHandler[ReqMsg, RespMsg any](
-	totalMaxSize int64,
-	fn func(ctx context.Context, nextFile func() (string, io.Reader, error), req *ReqMsg) (*RespMsg, error),
-) httpServiceMethodHandler {
-	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			httpResponseError(w, ErrMethodNotAllowed.WithMsg("method %q not allowed", r.Method))
+// rpcConn is a connection to a peer, topics are the ones registered by the peer
+type rpcConn struct {
+	rwc    io.ReadWriteCloser
+	frames chan []byte // written in order by writeLoop
+
+	mux     sync.Mutex
+	topics  map[string]int
+	pending map[uint64]chan []byte
+
+	closed chan struct{}
+	err    error
+}
+
+// write queues the frame, it waits until there is room in the queue or the connection
+// is closed, so the senders are slowed down to the pace of the peer
+func (c *rpcConn) write(frame rpcFrame) error {
+	buf, err := encodeRpcFrame(frame)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case c.frames <- buf:
+		return nil
+	case <-c.closed:
+		return c.err
+	}
+}
+
+// tryWrite queues the frame without waiting, it's used by the reader of the connection
+// and returns false if the queue is full
+func (c *rpcConn) tryWrite(frame rpcFrame) bool {
+	buf, err := encodeRpcFrame(frame)
+	if err != nil {
+		return true
+	}
+
+	select {
+	case c.frames <- buf:
+		return true
+	default:
+		return false
+	}
+}
+
+// writeLoop writes the queued frames until the connection is closed, once a write
+// fails, the connection is closed, which stops its reader as well
+func (c *rpcConn) writeLoop() {
+	for {
+		select {
+		case buf := <-c.frames:
+			if _, err := c.rwc.Write(buf); err != nil {
+				c.rwc.Close()
+				return
+			}
+		case <-c.closed:
 			return
 		}
+	}
+}
 
-		r.Body = http.MaxBytesReader(w, r.Body, totalMaxSize)
+func (c *rpcConn) hasTopic(topic string) bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
 
-		reader, err := r.MultipartReader()
+	return c.topics[topic] > 0
+}
+
+// request sends the data to the topic and waits for the peer's reply
+func (c *rpcConn) request(ctx context.Context, id uint64, topic string, data []byte) ([]byte, error) {
+	reply := make(chan []byte, 1)
+
+	c.mux.Lock()
+	c.pending[id] = reply
+	c.mux.Unlock()
+
+	defer func() {
+		c.mux.Lock()
+		delete(c.pending, id)
+		c.mux.Unlock()
+	}()
+
+	if err := c.write(rpcFrame{kind: rpcFrameRequest, id: id, topic: topic, data: data}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case data := <-reply:
+		return data, nil
+	case <-c.closed:
+		return nil, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// RpcConnAdaptor is the rpc adaptor which routes the calls and events through the
+// connections to other processes. The topics registered on it are served to all its
+// connections, and the calls to the topics registered by the same adaptor are received
+// locally. Calls fail once their connection is closed and are not retried.
+type RpcConnAdaptor struct {
+	nextID atomic.Uint64
+
+	// topicsMux orders the register and unregister frames sent to the connections,
+	// it's locked before mux
+	topicsMux sync.Mutex
+
+	mux       sync.Mutex
+	receivers map[string][]*rpcConnReceiver
+	conns     map[*rpcConn]struct{}
+	listeners map[net.Listener]struct{}
+	closed    bool
+}
+
+var _ rpcEventAdaptor = (*RpcConnAdaptor)(nil)
+var _ http.Handler = (*RpcConnAdaptor)(nil)
+
+func CreateRpcConnAdaptor() *RpcConnAdaptor {
+	return &RpcConnAdaptor{
+		receivers: make(map[string][]*rpcConnReceiver),
+		conns:     make(map[*rpcConn]struct{}),
+		listeners: make(map[net.Listener]struct{}),
+	}
+}
+
+func (a *RpcConnAdaptor) Register(topic string, recv recvFunc) (drain func(), err error) {
+	r := newRpcConnReceiver(recv)
+
+	a.topicsMux.Lock()
+	defer a.topicsMux.Unlock()
+
+	a.mux.Lock()
+	a.receivers[topic] = append(a.receivers[topic], r)
+	conns := a.connsLocked()
+	a.mux.Unlock()
+
+	for _, conn := range conns {
+		conn.write(rpcFrame{kind: rpcFrameRegister, topic: topic})
+	}
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			a.topicsMux.Lock()
+			defer a.topicsMux.Unlock()
+
+			a.mux.Lock()
+			receivers := a.receivers[topic]
+			for i, other := range receivers {
+				if other == r {
+					a.receivers[topic] = append(receivers[:i:i], receivers[i+1:]...)
+					break
+				}
+			}
+			if len(a.receivers[topic]) == 0 {
+				delete(a.receivers, topic)
+			}
+			conns := a.connsLocked()
+			a.mux.Unlock()
+
+			// the peers stop sending to the topic before the receiver is closed, and
+			// the messages which are sent meanwhile are rejected
+			for _, conn := range conns {
+				conn.write(rpcFrame{kind: rpcFrameUnregister, topic: topic})
+			}
+
+			r.close()
+		})
+	}, nil
+}
+
+// Send delivers the data to a receiver of the topic, which is either registered on this
+// adaptor or on one of the peers, and waits for its reply
+func (a *RpcConnAdaptor) Send(ctx context.Context, topic string, data []byte) ([]byte, error) {
+	a.mux.Lock()
+	receivers := a.receivers[topic]
+	conns := a.connsLocked()
+	a.mux.Unlock()
+
+	if len(receivers) > 0 {
+		reply := make(chan []byte, 1)
+
+		err := receivers[0].push(&rpcConnMsg{
+			topic: topic,
+			data:  data,
+			replyFn: func(data []byte) error {
+				reply <- data
+				return nil
+			},
+		})
 		if err != nil {
-			httpResponseError(w, err)
-			return
+			return nil, err
+		}
+
+		select {
+		case data := <-reply:
+			return data, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
 		}
+	}
+
+	for _, conn := range conns {
+		if conn.hasTopic(topic) {
+			return conn.request(ctx, a.nextID.Add(1), topic, data)
+		}
+	}
+
+	return nil, ErrServiceMethodNotFound.WithMsg("no receiver is registered for %s", topic)
+}
+
+// Publish delivers the data to all the receivers of the topic, registered either on this
+// adaptor or on the peers, without waiting for them. If the queue of a local receiver is
+// full, the event is dropped for it and ErrServerBusy is returned once the others have it.
+func (a *RpcConnAdaptor) Publish(ctx context.Context, topic string, data []byte) error {
+	a.mux.Lock()
+	receivers := a.receivers[topic]
+	conns := a.connsLocked()
+	a.mux.Unlock()
+
+	busyErr := a.deliver(receivers, topic, data)
+
+	for _, conn := range conns {
+		if !conn.hasTopic(topic) {
+			continue
+		}
+
+		if err := conn.write(rpcFrame{kind: rpcFramePublish, topic: topic, data: data}); err != nil {
+			return err
+		}
+	}
+
+	return busyErr
+}
+
+// deliver pushes the event to the receivers, the receivers which are drained meanwhile
+// are skipped, and the error of the busy ones is returned
+func (a *RpcConnAdaptor) deliver(receivers []*rpcConnReceiver, topic string, data []byte) error {
+	var busyErr error
+
+	for _, r := range receivers {
+		err := r.push(&rpcConnMsg{
+			topic: topic,
+			data:  data,
+			replyFn: func(data []byte) error {
+				return nil
+			},
+		})
+		if errors.Is(err, ErrServerBusy) {
+			busyErr = err
+		}
+	}
+
+	return busyErr
+}
+
+// Serve accepts the connections of the listener until it is closed, e.g. net.Listen("unix", path)
+func (a *RpcConnAdaptor) Serve(l net.Listener) error {
+	a.mux.Lock()
+	if a.closed {
+		a.mux.Unlock()
+		return net.ErrClosed
+	}
+	a.listeners[l] = struct{}{}
+	a.mux.Unlock()
+
+	defer func() {
+		a.mux.Lock()
+		delete(a.listeners, l)
+		a.mux.Unlock()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		a.Connect(conn)
+	}
+}
+
+// ServeHTTP upgrades the http connection to carry the rpc frames, so the peers can
+// connect to the adaptor with DialHttp through the existing http servers
+func (a *RpcConnAdaptor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), rpcHttpUpgrade) {
+		httpResponseError(w, ErrMethodNotAllowed.WithMsg("expected %s upgrade", rpcHttpUpgrade))
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		httpResponseError(w, ErrInternal.WithMsg("response writer does not support hijacking"))
+		return
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: %s\r\nConnection: Upgrade\r\n\r\n", rpcHttpUpgrade)
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return
+	}
+
+	a.Connect(struct {
+		io.Reader
+		io.WriteCloser
+	}{rw.Reader, conn})
+}
+
+// Dial connects to the peer which serves the listener of the address, e.g. Dial(ctx, "unix", path)
+func (a *RpcConnAdaptor) Dial(ctx context.Context, network, address string) error {
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return err
+	}
+
+	a.Connect(conn)
+	return nil
+}
+
+// DialHttp connects to the peer which serves the adaptor on the url, ctx only limits
+// the handshake, and the client must not have a timeout as the connection is kept open
+func (a *RpcConnAdaptor) DialHttp(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", rpcHttpUpgrade)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return decodeHttpResponseError(resp)
+		}
+		return ErrInternal.WithMsg("expected %s upgrade, got status %d", rpcHttpUpgrade, resp.StatusCode)
+	}
+
+	rwc, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		return ErrInternal.WithMsg("invalid %s handshake", rpcHttpUpgrade)
+	}
+
+	a.Connect(rwc)
+	return nil
+}
+
+// Connect serves the rpc frames over the connection, the connection is closed once
+// the peer closes it or the adaptor is closed
+func (a *RpcConnAdaptor) Connect(rwc io.ReadWriteCloser) {
+	conn := &rpcConn{
+		rwc:     rwc,
+		frames:  make(chan []byte, rpcConnMaxPendingFrames),
+		topics:  make(map[string]int),
+		pending: make(map[uint64]chan []byte),
+		closed:  make(chan struct{}),
+	}
+
+	// the topics registered or unregistered meanwhile are sent after the current ones
+	a.topicsMux.Lock()
+	defer a.topicsMux.Unlock()
+
+	a.mux.Lock()
+	if a.closed {
+		a.mux.Unlock()
+		rwc.Close()
+		return
+	}
+
+	var topics []string
+	for topic, receivers := range a.receivers {
+		for range receivers {
+			topics = append(topics, topic)
+		}
+	}
+	a.mux.Unlock()
+
+	go conn.writeLoop()
+	go a.serveConn(conn)
+
+	// the peer learns the topics of the adaptor before any other frame is sent,
+	// as the connection is only used by the calls and events once it's added
+	for _, topic := range topics {
+		conn.write(rpcFrame{kind: rpcFrameRegister, topic: topic})
+	}
+
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	select {
+	case <-conn.closed:
+		return
+	default:
+	}
+
+	if a.closed {
+		rwc.Close()
+		return
+	}
+	a.conns[conn] = struct{}{}
+}
+
+func (a *RpcConnAdaptor) serveConn(conn *rpcConn) {
+	var err error
+
+	defer func() {
+		a.mux.Lock()
+		delete(a.conns, conn)
+		conn.err = fmt.Errorf("rpc connection is closed: %w", err)
+		close(conn.closed)
+		a.mux.Unlock()
+
+		conn.rwc.Close()
+	}()
+
+	r := bufio.NewReader(conn.rwc)
+
+	for {
+		var frame rpcFrame
+		frame, err = readRpcFrame(r)
+		if err != nil {
+			return
+		}
+
+		switch frame.kind {
+		case rpcFrameRegister:
+			conn.mux.Lock()
+			conn.topics[frame.topic]++
+			conn.mux.Unlock()
+		case rpcFrameUnregister:
+			conn.mux.Lock()
+			if conn.topics[frame.topic]--; conn.topics[frame.topic] <= 0 {
+				delete(conn.topics, frame.topic)
+			}
+			conn.mux.Unlock()
+		case rpcFrameReply:
+			conn.mux.Lock()
+			reply, ok := conn.pending[frame.id]
+			conn.mux.Unlock()
+			if ok {
+				reply <- frame.data
+			}
+		case rpcFramePublish:
+			a.mux.Lock()
+			receivers := a.receivers[frame.topic]
+			a.mux.Unlock()
+
+			if busyErr := a.deliver(receivers, frame.topic, frame.data); busyErr != nil {
+				slog.Error("rpc event is dropped", "topic", frame.topic, "error", busyErr)
+			}
+		case rpcFrameRequest:
+			a.mux.Lock()
+			receivers := a.receivers[frame.topic]
+			a.mux.Unlock()
+
+			id := frame.id
+			reply := func(data []byte) error {
+				return conn.write(rpcFrame{kind: rpcFrameReply, id: id, data: data})
+			}
+
+			var recvErr error
+			if len(receivers) == 0 {
+				recvErr = ErrServiceMethodNotFound.WithMsg("no receiver is registered for %s", frame.topic)
+			} else {
+				recvErr = receivers[0].push(&rpcConnMsg{topic: frame.topic, data: frame.data, replyFn: reply})
+			}
+
+			// the reader doesn't wait for the replies to be written, if the peer doesn't
+			// read them, the connection is closed rather than buffering the replies
+			if recvErr != nil && !conn.tryWrite(rpcFrame{kind: rpcFrameReply, id: id, data: encodeRpcError(recvErr)}) {
+				err = errors.New("rpc connection is congested, the peer doesn't read the replies")
+				return
+			}
+		default:
+			err = fmt.Errorf("unknown rpc frame kind: %d", frame.kind)
+			return
+		}
+	}
+}
+
+func (a *RpcConnAdaptor) connsLocked() []*rpcConn {
+	conns := make([]*rpcConn, 0, len(a.conns))
+	for conn := range a.conns {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// Close closes the listeners and the connections of the adaptor, the registered
+// receivers are not drained, so the servers should be drained beforehand
+func (a *RpcConnAdaptor) Close() error {
+	a.mux.Lock()
+	a.closed = true
+	listeners := make([]net.Listener, 0, len(a.listeners))
+	for l := range a.listeners {
+		listeners = append(listeners, l)
+	}
+	conns := a.connsLocked()
+	a.mux.Unlock()
+
+	var errs []error
+	for _, l := range listeners {
+		if err := l.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = append(errs, err)
+		}
+	}
+	for _, conn := range conns {
+		if err := conn.rwc.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// CONTEXT UTILITIES
+// Injecting/extracting values from context usually used for http handlers
+
+type ctxKey string
+
+const (
+	ctxKeyRequest             ctxKey = "http_request"
+	ctxKeyResponse            ctxKey = "http_response"
+	ctxKeyClientMapper        ctxKey = "ella_http_client_mapper"
+	ctxKeyHttpCallOptions     ctxKey = "ella_http_call_options"
+	ctxKeyLastEventID         ctxKey = "ella_http_last_event_id"
+	ctxKeyRpcOutgoingMetadata ctxKey = "ella_rpc_outgoing_metadata"
+	ctxKeyRpcIncomingMetadata ctxKey = "ella_rpc_incoming_metadata"
+)
+
+type ctxClientMapper struct {
+	HttpResponse *http.Response
+}
+
+// CreateCtxClientMapper inject a special object into the context which
+// let's Ella's generated code to inject some values to the context
+// Please refer to GetCtxClientMapper for accessing the values
+// NOTE: use this function only in test functions, to get access to http.Response object
+// of the http client to extract cookies, headers, etc.
+func CreateCtxClientMapper(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxKeyClientMapper, &ctxClientMapper{})
+}
+
+func GetCtxClientMapper(ctx context.Context) (*ctxClientMapper, bool) {
+	return getCtxValue[*ctxClientMapper](ctx, ctxKeyClientMapper)
+}
+
+func GetCtxHttpRequest(ctx context.Context) (result *http.Request, ok bool) {
+	return getCtxValue[*http.Request](ctx, ctxKeyRequest)
+}
+
+func GetCtxHttpResponse(ctx context.Context) (result http.ResponseWriter, ok bool) {
+	return getCtxValue[http.ResponseWriter](ctx, ctxKeyResponse)
+}
+
+// GetCtxLastEventID returns the id of the last event received by the client, which is
+// sent as Last-Event-ID when a stream reconnects. Event ids are set by the service with
+// Stream.WithEventID, so the service should resume after the event with this id.
+func GetCtxLastEventID(ctx context.Context) (result string, ok bool) {
+	return getCtxValue[string](ctx, ctxKeyLastEventID)
+}
+
+func getCtxValue[T any](ctx context.Context, key ctxKey) (result T, ok bool) {
+	value := ctx.Value(key)
+	if value == nil {
+		return result, false
+	}
+	result, ok = value.(T)
+	return result, ok
+}
+
+func injectCommonVars(ctx context.Context, w http.ResponseWriter, r *http.Request) context.Context {
+	ctx = context.WithValue(ctx, ctxKeyRequest, r)
+	ctx = context.WithValue(ctx, ctxKeyResponse, w)
+	return ctx
+}
+
+func injectHttpClientResponse(ctx context.Context, resp *http.Response) {
+	clientMapper, ok := ctx.Value(ctxKeyClientMapper).(*ctxClientMapper)
+	if !ok {
+		return
+	}
+
+	clientMapper.HttpResponse = resp
+}
+
+// STREAM UTILITIES
+// Stream args and returns are Stream values, the producer sends the items with Send and
+// ends the stream with Close, optionally with an error, and the consumer receives the
+// items from Items and gets the error with Err once Items is closed. Over http, events
+// are sent as Server-Sent Events:
+// - "id: <id>\nevent: <return name>\ndata: <json>" for each item, id is only sent if the
+//   service sets it with WithEventID
+// - "event: done" with "{}" data once the stream is ended normally
+// - "event: error" with the json of Error, including its http status, once the stream
+//   is ended with an error, errors which are not Error are sent as internal errors
+
+var errStreamClosed = errors.New("stream is closed")
+
+// Stream is a stream of items which can be ended with an error, see NewStream
+type Stream[T any] struct {
+	items   chan T
+	closing chan struct{}
+	mux     sync.RWMutex // guards items, so it's not closed while an item is being sent
+	once    sync.Once
+	err     error
+	eventID func(item T) string
+}
+
+// NewStream creates a stream, size is the number of items which can be sent before
+// they are received
+func NewStream[T any](size int) *Stream[T] {
+	return &Stream[T]{
+		items:   make(chan T, size),
+		closing: make(chan struct{}),
+	}
+}
+
+// WithEventID sets the id of each event sent over Server-Sent Events, which the client
+// sends back as Last-Event-ID when it reconnects, so the service can resume after it.
+// It must be called before the stream is returned, and ids can't have line breaks.
+func (s *Stream[T]) WithEventID(fn func(item T) string) *Stream[T] {
+	s.eventID = fn
+	return s
+}
+
+// Send sends the item to the consumer, it returns the error of ctx once ctx is done,
+// the consumer's ctx is usually done if it's no longer receiving, or an error if the
+// stream is already closed
+func (s *Stream[T]) Send(ctx context.Context, item T) error {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	select {
+	case <-s.closing:
+		return errStreamClosed
+	default:
+	}
+
+	select {
+	case s.items <- item:
+		return nil
+	case <-s.closing:
+		return errStreamClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close ends the stream once the sent items are received, err is the error which ends
+// the stream or nil if it's ended normally, only the first call has an effect
+func (s *Stream[T]) Close(err error) {
+	s.once.Do(func() {
+		s.err = err
+		close(s.closing)
+
+		s.mux.Lock()
+		close(s.items)
+		s.mux.Unlock()
+	})
+}
+
+// Items returns the channel of the items, which is closed once the stream is closed
+func (s *Stream[T]) Items() <-chan T {
+	return s.items
+}
+
+// Err returns the error which ended the stream, nil if the stream is not closed or it's
+// ended normally. For clients, the error is the one sent by the server, the connection's
+// error if the stream has no event ids to resume it, the reconnect's error, or the
+// context's error if the stream is cancelled.
+func (s *Stream[T]) Err() error {
+	select {
+	case <-s.closing:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// done is closed once Close is called, even if items are not received yet
+func (s *Stream[T]) done() <-chan struct{} {
+	return s.closing
+}
+
+// streamError is the data of error events, unlike http error responses, the http
+// status is part of the payload as the stream's status is already sent
+type streamError struct {
+	Error
+	HTTPStatus int `json:"http_status"`
+}
+
+func encodeStreamError(err error) []byte {
+	httpErr, ok := asError(err)
+	if !ok {
+		httpErr = newError(0, http.StatusInternalServerError, err, "internal server error")
+	}
+
+	slog.Error("http stream error", "http_status", httpErr.HTTPStatus, "code", httpErr.Code, "message", httpErr.Message, "cause", httpErr.cause)
+
+	data, _ := json.Marshal(streamError{Error: httpErr, HTTPStatus: httpErr.HTTPStatus})
+	return data
+}
+
+func decodeStreamError(data []byte) Error {
+	var result streamError
+	if err := json.Unmarshal(data, &result); err != nil {
+		return ErrInternal.WithMsg("%s", data)
+	}
+
+	result.Error.HTTPStatus = result.HTTPStatus
+	return result.Error
+}
+
+// HTTP SERVER UTILITIES
+// Helper utilities for creating http servers
+
+type httpServiceMethodHandler func(context.Context, http.ResponseWriter, *http.Request)
+
+// httpRouter routes the requests to service methods using http.ServeMux patterns,
+// so methods can be mounted on routes with placeholders, e.g. /users/{id}.
+// Methods which share the same route are dispatched based on http method.
+type httpRouter struct {
+	mux    *http.ServeMux
+	routes map[string]map[string]httpServiceMethodHandler
+}
+
+func newHttpRouter() *httpRouter {
+	return &httpRouter{
+		mux:    http.NewServeMux(),
+		routes: make(map[string]map[string]httpServiceMethodHandler),
+	}
+}
+
+func (r *httpRouter) handle(method string, pattern string, handler httpServiceMethodHandler) {
+	handlers, ok := r.routes[pattern]
+	if ok {
+		handlers[method] = handler
+		return
+	}
+
+	handlers = map[string]httpServiceMethodHandler{method: handler}
+	r.routes[pattern] = handlers
+
+	r.mux.HandleFunc(pattern, func(w http.ResponseWriter, req *http.Request) {
+		handler, ok := handlers[req.Method]
+		if !ok {
+			httpResponseError(w, ErrMethodNotAllowed.WithMsg("method %q not allowed", req.Method))
+			return
+		}
+
+		ctx := injectCommonVars(req.Context(), w, req)
+
+		handler(ctx, w, req)
+	})
+}
+
+func (r *httpRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if _, pattern := r.mux.Handler(req); pattern == "" {
+		httpResponseError(w, ErrServiceMethodNotFound.WithMsg("method %q not found", req.URL.Path))
+		return
+	}
+
+	r.mux.ServeHTTP(w, req)
+}
+
+// HttpServerCallInfo describes the service method which is about to be called
+type HttpServerCallInfo struct {
+	Service string
+	Method  string
+}
+
+// HttpServerHandler calls the next interceptor in the chain, the last one
+// calls the service method itself
+type HttpServerHandler func(ctx context.Context, args any) (result any, err error)
+
+// HttpServerInterceptor runs for every http service method once the request
+// is decoded. args is a pointer to the decoded args struct and result is
+// whatever the service method returned, e.g. a pointer to the returns struct,
+// the stream or the io.Reader of a binary stream.
+type HttpServerInterceptor func(ctx context.Context, info HttpServerCallInfo, args any, next HttpServerHandler) (result any, err error)
+
+// HttpServerOption configures the http handler created by Create{Service}Server
+type HttpServerOption func(*httpServerConfig)
+
+type httpServerConfig struct {
+	interceptors []HttpServerInterceptor
+	origins      []string
+}
+
+func (c *httpServerConfig) createCall(service, method string) httpServiceCall {
+	return httpServiceCall{
+		info: HttpServerCallInfo{
+			Service: service,
+			Method:  method,
+		},
+		interceptors: c.interceptors,
+		origins:      c.origins,
+	}
+}
+
+// WithHttpServerInterceptors appends interceptors to the chain, the first
+// interceptor is the outer most one
+func WithHttpServerInterceptors(interceptors ...HttpServerInterceptor) HttpServerOption {
+	return func(c *httpServerConfig) {
+		c.interceptors = append(c.interceptors, interceptors...)
+	}
+}
+
+// WithHttpServerAllowedOrigins allows browsers of other origins, e.g. "https://app.example.com",
+// to open WebSocket connections, "*" allows all origins. By default only the server's own
+// origin and clients which don't send Origin header, e.g. Go clients, are allowed.
+func WithHttpServerAllowedOrigins(origins ...string) HttpServerOption {
+	return func(c *httpServerConfig) {
+		c.origins = append(c.origins, origins...)
+	}
+}
+
+type httpServiceCall struct {
+	info         HttpServerCallInfo
+	interceptors []HttpServerInterceptor
+	origins      []string
+}
+
+func (c httpServiceCall) run(ctx context.Context, args any, handler HttpServerHandler) (any, error) {
+	next := handler
+	for i := len(c.interceptors) - 1; i >= 0; i-- {
+		interceptor := c.interceptors[i]
+		prev := next
+		next = func(ctx context.Context, args any) (any, error) {
+			return interceptor(ctx, c.info, args, prev)
+		}
+	}
+
+	return next(ctx, args)
+}
+
+// errNilStream is returned when a stream method, or one of its interceptors, returns
+// neither a stream nor an error, so the stream is not waited for forever
+func (c httpServiceCall) errNilStream() error {
+	return ErrInternal.WithMsg("%s.%s returned a nil stream", c.info.Service, c.info.Method)
+}
+
+// interceptHttpCall runs the interceptors chain and converts the args and
+// result back to their original types
+func interceptHttpCall[ReqMsg, RespMsg any](ctx context.Context, call httpServiceCall, req *ReqMsg, fn func(ctx context.Context, req *ReqMsg) (RespMsg, error)) (resp RespMsg, err error) {
+	if len(call.interceptors) == 0 {
+		return fn(ctx, req)
+	}
+
+	result, err := call.run(ctx, req, func(ctx context.Context, args any) (any, error) {
+		req, ok := args.(*ReqMsg)
+		if !ok {
+			return nil, ErrInternal.WithMsg("interceptor changed args type of %s.%s to %T", call.info.Service, call.info.Method, args)
+		}
+		return fn(ctx, req)
+	})
+
+	resp, _ = result.(RespMsg)
+	return resp, err
+}
+
+func createServiceMethodHandler[ReqMsg, RespMsg any](call httpServiceCall, method string, hasFields bool, fn func(ctx context.Context, req *ReqMsg) (*RespMsg, error)) httpServiceMethodHandler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			httpResponseError(w, ErrMethodNotAllowed.WithMsg("method %q not allowed", r.Method))
+			return
+		}
+
+		defer func() {
+			if err := recover(); err != nil {
+				// In case of a panic, serve a 500 error and then panic.
+				w.WriteHeader(http.StatusInternalServerError)
+				panic(err)
+			}
+		}()
+
+		var reqMsg ReqMsg
+
+		if method == http.MethodGet {
+			if err := valuesToStruct(r.URL.Query(), &reqMsg); err != nil {
+				httpResponseError(w, ErrInvalidArgs.WithCause(err))
+				return
+			}
+		} else if hasFields {
+			if err := checkContentType(r, "application/json"); err != nil {
+				httpResponseError(w, ErrUnsupportedMediaType.WithCause(err))
+				return
+			}
+
+			if err := json.NewDecoder(r.Body).Decode(&reqMsg); err != nil {
+				httpResponseError(w, ErrInvalidArgs.WithCause(err))
+				return
+			}
+		}
+
+		if err := pathValuesToStruct(r, &reqMsg); err != nil {
+			httpResponseError(w, ErrInvalidArgs.WithCause(err))
+			return
+		}
+
+		if err := validateArgs(&reqMsg); err != nil {
+			httpResponseError(w, err)
+			return
+		}
+
+		respMsg, err := interceptHttpCall(ctx, call, &reqMsg, fn)
+		if err != nil {
+			httpResponseError(w, err)
+			return
+		}
+
+		httpResponse(w, http.StatusOK, respMsg)
+	}
+}
+
+type streamEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+func createStreamServiceMethod[ReqMsg, Event any](call httpServiceCall, method string, hasFields bool, eventName string, fn func(ctx context.Context, req *ReqMsg) (*Stream[Event], error)) httpServiceMethodHandler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			httpResponseError(w, ErrMethodNotAllowed.WithMsg("method %q not allowed", r.Method))
+			return
+		}
+
+		fluser, ok := w.(http.Flusher)
+		if !ok {
+			httpResponseError(w, ErrFlusherNotSupported)
+			return
+		}
+
+		defer func() {
+			if err := recover(); err != nil {
+				// In case of a panic, serve a 500 error and then panic.
+				w.WriteHeader(http.StatusInternalServerError)
+				panic(err)
+			}
+		}()
+
+		var reqMsg ReqMsg
+
+		if method == http.MethodGet {
+			if err := valuesToStruct(r.URL.Query(), &reqMsg); err != nil {
+				httpResponseError(w, ErrInvalidArgs.WithCause(err))
+				return
+			}
+		} else if hasFields {
+			if err := checkContentType(r, "application/json"); err != nil {
+				httpResponseError(w, ErrUnsupportedMediaType.WithCause(err))
+				return
+			}
+
+			if err := json.NewDecoder(r.Body).Decode(&reqMsg); err != nil {
+				httpResponseError(w, ErrInvalidArgs.WithCause(err))
+				return
+			}
+		}
+
+		if err := pathValuesToStruct(r, &reqMsg); err != nil {
+			httpResponseError(w, ErrInvalidArgs.WithCause(err))
+			return
+		}
+
+		if err := validateArgs(&reqMsg); err != nil {
+			httpResponseError(w, err)
+			return
+		}
+
+		if value := r.Header.Get("Last-Event-ID"); value != "" {
+			ctx = context.WithValue(ctx, ctxKeyLastEventID, value)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		events, err := interceptHttpCall(ctx, call, &reqMsg, fn)
+		if err != nil {
+			httpResponseError(w, err)
+			return
+		}
+		if events == nil {
+			httpResponseError(w, call.errNilStream())
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		fluser.Flush()
+
+		var buffer bytes.Buffer
+
+		for item := range toStreamEvents(ctx, eventName, events) {
+			buffer.Reset()
+
+			if item.id != "" {
+				buffer.WriteString("id: ")
+				buffer.WriteString(item.id)
+				buffer.WriteString("\n")
+			}
+			buffer.WriteString("event: ")
+			buffer.WriteString(item.event)
+			buffer.WriteString("\ndata: ")
+			buffer.WriteString(item.data)
+			buffer.WriteString("\n\n")
+
+			_, err := io.Copy(w, &buffer)
+			if err != nil {
+				return
+			}
+			fluser.Flush()
+		}
+	}
+}
+
+// toStreamEvents marshals each event and ends the stream with either the done event
+// or the error event, in case of marshal error or invalid id, the stream is ended with
+// an error, the done and error events have no id
+func toStreamEvents[Event any](ctx context.Context, eventName string, events *Stream[Event]) <-chan *streamEvent {
+	out := make(chan *streamEvent, 1)
+
+	go func() {
+		defer close(out)
+
+		send := func(event *streamEvent) bool {
+			select {
+			case out <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for event := range events.Items() {
+			var id string
+			if events.eventID != nil {
+				id = events.eventID(event)
+			}
+			if strings.ContainsAny(id, "\r\n\x00") {
+				send(&streamEvent{
+					event: "error",
+					data:  string(encodeStreamError(ErrInternal.WithMsg("invalid event id %q", id))),
+				})
+				return
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				send(&streamEvent{
+					event: "error",
+					data:  string(encodeStreamError(ErrInternal.WithCause(err))),
+				})
+				return
+			}
+			if !send(&streamEvent{
+				id:    id,
+				event: eventName,
+				data:  string(data),
+			}) {
+				return
+			}
+		}
+
+		if err := events.Err(); err != nil {
+			send(&streamEvent{
+				event: "error",
+				data:  string(encodeStreamError(err)),
+			})
+			return
+		}
+
+		send(&streamEvent{
+			event: "done",
+			data:  "{}",
+		})
+	}()
+
+	return out
+}
+
+func createStreamBinaryServiceMethod[ReqMsg any](call httpServiceCall, method string, hasFields bool, fn func(ctx context.Context, req *ReqMsg) (io.Reader, string, string, error)) httpServiceMethodHandler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			httpResponseError(w, ErrMethodNotAllowed.WithMsg("method %q not allowed", r.Method))
+			return
+		}
+
+		defer func() {
+			if err := recover(); err != nil {
+				// In case of a panic, serve a 500 error and then panic.
+				w.WriteHeader(http.StatusInternalServerError)
+				panic(err)
+			}
+		}()
+
+		var reqMsg ReqMsg
+
+		if method == http.MethodGet {
+			if err := valuesToStruct(r.URL.Query(), &reqMsg); err != nil {
+				httpResponseError(w, ErrInvalidArgs.WithCause(err))
+				return
+			}
+		} else if hasFields {
+			if err := checkContentType(r, "application/json"); err != nil {
+				httpResponseError(w, ErrUnsupportedMediaType.WithCause(err))
+				return
+			}
+
+			if err := json.NewDecoder(r.Body).Decode(&reqMsg); err != nil {
+				httpResponseError(w, ErrInvalidArgs.WithCause(err))
+				return
+			}
+		}
+
+		if err := pathValuesToStruct(r, &reqMsg); err != nil {
+			httpResponseError(w, ErrInvalidArgs.WithCause(err))
+			return
+		}
+
+		if err := validateArgs(&reqMsg); err != nil {
+			httpResponseError(w, err)
+			return
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var filename, contentType string
+
+		streamReader, err := interceptHttpCall(ctx, call, &reqMsg, func(ctx context.Context, req *ReqMsg) (r io.Reader, err error) {
+			r, filename, contentType, err = fn(ctx, req)
+			return
+		})
+		if err != nil {
+			httpResponseError(w, err)
+			return
+		}
+		if streamReader == nil {
+			httpResponseError(w, call.errNilStream())
+			return
+		}
+		if closer, ok := streamReader.(io.Closer); ok {
+			defer closer.Close()
+		}
+
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		if filename != "" {
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+		}
+
+		io.Copy(w, streamReader)
+	}
+}
+
+func createServiceMethodUploadHandler[ReqMsg, RespMsg any](
+	call httpServiceCall,
+	totalMaxSize int64,
+	fn func(ctx context.Context, nextFile func() (string, io.Reader, error), req *ReqMsg) (*RespMsg, error),
+) httpServiceMethodHandler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			httpResponseError(w, ErrMethodNotAllowed.WithMsg("method %q not allowed", r.Method))
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, totalMaxSize)
+
+		reader, err := r.MultipartReader()
+		if err != nil {
+			httpResponseError(w, err)
+			return
+		}
+
+		part, err := reader.NextPart()
+		if err != nil {
+			httpResponseError(w, err)
+			return
+		}
+
+		if part.FormName() != "payload" {
+			part.Close()
+			httpResponseError(w, ErrMissingPayloadName)
+			return
+		}
+
+		var req ReqMsg
+		err = json.NewDecoder(part).Decode(&req)
+		part.Close()
+		if err != nil {
+			httpResponseError(w, err)
+			return
+		}
+
+		if err := pathValuesToStruct(r, &req); err != nil {
+			httpResponseError(w, ErrInvalidArgs.WithCause(err))
+			return
+		}
+
+		if err := validateArgs(&req); err != nil {
+			httpResponseError(w, err)
+			return
+		}
+
+		nextFile := func() (string, io.Reader, error) {
+			part, err := reader.NextPart()
+			if err != nil {
+				return "", nil, err
+			}
+
+			if part.FormName() != "files" {
+				return "", nil, ErrMissingFilesField
+			}
+
+			return part.FileName(), part, nil
+		}
+
+		resp, err := interceptHttpCall(ctx, call, &req, func(ctx context.Context, req *ReqMsg) (*RespMsg, error) {
+			return fn(ctx, nextFile, req)
+		})
+		if err != nil {
+			httpResponseError(w, err)
+			return
+		}
+
+		httpResponse(w, http.StatusOK, resp)
+	}
+}
+
+// HTTP CLIENT UTILITIES
+// Helper utilities for creating http clients
+
+// HttpClientCallInfo describes the service method which is being called
+type HttpClientCallInfo struct {
+	Service string
+	Method  string
+}
+
+// HttpClientRoundTrip sends the request to the next interceptor in the chain,
+// the last one sends it using the underlying http.Client
+type HttpClientRoundTrip func(req *http.Request) (*http.Response, error)
+
+// HttpClientInterceptor runs for every http request sent by the generated
+// clients, it can modify the request, e.g. adding auth headers, retry the call
+// or observe the response
+type HttpClientInterceptor func(info HttpClientCallInfo, req *http.Request, next HttpClientRoundTrip) (*http.Response, error)
+
+// HttpClientOption configures the client created by CreateHttp{Service}Client
+type HttpClientOption func(*httpClientConfig)
+
+type httpClientConfig struct {
+	client       *http.Client
+	interceptors []HttpClientInterceptor
+}
+
+func (c *httpClientConfig) createCall(service, method string) httpClientCall {
+	client := c.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return httpClientCall{
+		client: client,
+		info: HttpClientCallInfo{
+			Service: service,
+			Method:  method,
+		},
+		interceptors: c.interceptors,
+	}
+}
+
+// WithHttpClientInterceptors appends interceptors to the chain, the first
+// interceptor is the outer most one
+func WithHttpClientInterceptors(interceptors ...HttpClientInterceptor) HttpClientOption {
+	return func(c *httpClientConfig) {
+		c.interceptors = append(c.interceptors, interceptors...)
+	}
+}
+
+// HttpCallOption configures a single call, it is carried by the context
+// please refer to CreateCtxHttpCallOptions
+type HttpCallOption func(*httpCallConfig)
+
+type httpCallConfig struct {
+	headers http.Header
+	timeout time.Duration
+}
+
+// WithHttpCallHeader adds a header to the request
+func WithHttpCallHeader(key, value string) HttpCallOption {
+	return func(c *httpCallConfig) {
+		if c.headers == nil {
+			c.headers = make(http.Header)
+		}
+		c.headers.Add(key, value)
+	}
+}
+
+// WithHttpCallTimeout limits the duration of the call, for streams it limits
+// the whole duration of the stream
+func WithHttpCallTimeout(timeout time.Duration) HttpCallOption {
+	return func(c *httpCallConfig) {
+		c.timeout = timeout
+	}
+}
+
+// CreateCtxHttpCallOptions injects per call options into the context, the
+// options are merged with the ones already in the context
+func CreateCtxHttpCallOptions(ctx context.Context, opts ...HttpCallOption) context.Context {
+	config := getHttpCallConfig(ctx)
+	config.headers = config.headers.Clone()
+
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return context.WithValue(ctx, ctxKeyHttpCallOptions, config)
+}
+
+func getHttpCallConfig(ctx context.Context) httpCallConfig {
+	config, _ := getCtxValue[httpCallConfig](ctx, ctxKeyHttpCallOptions)
+	return config
+}
+
+type httpClientCall struct {
+	client       *http.Client
+	info         HttpClientCallInfo
+	interceptors []HttpClientInterceptor
+}
+
+// newRequest creates a request with the per call options applied, the returned
+// cancel function must be called once the response is fully consumed
+func (c httpClientCall) newRequest(ctx context.Context, method string, url string, body io.Reader) (*http.Request, context.CancelFunc, error) {
+	config := getHttpCallConfig(ctx)
+
+	cancel := context.CancelFunc(func() {})
+	if config.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, config.timeout)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	for key, values := range config.headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	return req, cancel, nil
+}
+
+func (c httpClientCall) do(req *http.Request) (*http.Response, error) {
+	next := HttpClientRoundTrip(c.client.Do)
+	for i := len(c.interceptors) - 1; i >= 0; i-- {
+		interceptor := c.interceptors[i]
+		prev := next
+		next = func(req *http.Request) (*http.Response, error) {
+			return interceptor(c.info, req, prev)
+		}
+	}
+
+	return next(req)
+}
+
+// cancelOnClose calls cancel once the body is closed, it is used to release
+// the resources of per call timeout
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// urlPathJoin joins the already escaped paths to the host's path
+func urlPathJoin(host string, paths ...string) (string, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return "", err
+	}
+
+	return u.JoinPath(paths...).String(), nil
+}
+
+// buildHttpPath replaces the placeholders of the pattern, e.g. /users/{id}, with
+// the escaped values, wildcard placeholders, e.g. {path...}, keep the '/'
+func buildHttpPath(pattern string, values map[string]any) string {
+	segments := strings.Split(pattern, "/")
+
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+
+		name := strings.TrimSuffix(strings.TrimPrefix(segment, "{"), "}")
+		if strings.HasSuffix(name, "...") {
+			parts := strings.Split(fmt.Sprint(values[strings.TrimSuffix(name, "...")]), "/")
+			for j, part := range parts {
+				parts[j] = url.PathEscape(part)
+			}
+			segments[i] = strings.Join(parts, "/")
+			continue
+		}
+
+		segments[i] = url.PathEscape(fmt.Sprint(values[name]))
+	}
+
+	return strings.Join(segments, "/")
+}
+
+func sendHttpRequest(ctx context.Context, call httpClientCall, url string, method string, in any) (resp *http.Response, err error) {
+	var r io.ReadCloser
+
+	if !isStructEmpty(in) {
+		if method == http.MethodGet {
+			url, err = structToURL(url, in)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			r = jsonEncodeReader(in)
+		}
+	}
+
+	req, cancel, err := call.newRequest(ctx, method, url, r)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err = call.do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+
+	injectHttpClientResponse(ctx, resp)
+
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, decodeHttpResponseError(resp)
+	}
+
+	return resp, nil
+}
+
+// decodeHttpResponseError decodes the error which is sent by httpResponseError,
+// the http status is not part of the json, so it's taken from the response
+func decodeHttpResponseError(resp *http.Response) error {
+	err := Error{}
+	if json.NewDecoder(resp.Body).Decode(&err) != nil {
+		// the body is not an Error, e.g. a proxy's error page, so only the status is known
+		return Error{HTTPStatus: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	}
+	err.HTTPStatus = resp.StatusCode
+	return err
+}
+
+func callHttpEndpoint(ctx context.Context, call httpClientCall, url string, method string, in any) (r io.ReadCloser, err error) {
+	resp, err := sendHttpRequest(ctx, call, url, method, in)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+func callHttpStreamEndpoint(ctx context.Context, call httpClientCall, url string, method string, in any) (r io.ReadCloser, filename string, contentType string, err error) {
+	resp, err := sendHttpRequest(ctx, call, url, method, in)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	contentType = resp.Header.Get("Content-Type")
+	filename = strings.Replace(resp.Header.Get("Content-Disposition"), "attachment; filename=", "", 1)
+
+	return resp.Body, filename, contentType, nil
+}
+
+func callHttpServiceMethod(ctx context.Context, call httpClientCall, url string, method string, in any, out any) (err error) {
+	r, err := callHttpEndpoint(ctx, call, url, method, in)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if isStructEmpty(out) {
+		return nil
+	}
+
+	return json.NewDecoder(r).Decode(out)
+}
+
+func sendHttpFilesUpload(ctx context.Context, call httpClientCall, url string, method string, payload any, files func() (string, io.Reader, error), respBody any) error {
+	pr, pw := io.Pipe()
+
+	boundary := getRandomBoundary()
+
+	go func() {
+		var err error
+		var part io.Writer
+		var filename string
+		var content io.Reader
+
+		mw := multipart.NewWriter(pw)
+
+		defer func() {
+			mw.Close()
+			if err != nil {
+				pw.CloseWithError(err)
+			} else {
+				pw.Close()
+			}
+		}()
+
+		err = mw.SetBoundary(boundary)
+		if err != nil {
+			return
+		}
+
+		part, err = mw.CreateFormField("payload")
+		if err != nil {
+			return
+		}
+
+		_, err = io.Copy(part, jsonEncodeReader(payload))
+		if err != nil {
+			return
+		}
+
+		for {
+			err = func() error {
+				filename, content, err = files()
+				if err != nil {
+					return err
+				}
+
+				if closer, ok := content.(io.Closer); ok {
+					defer closer.Close()
+				}
+
+				part, err = mw.CreateFormFile("files", filename)
+				if err != nil {
+					return err
+				}
+
+				_, err = io.Copy(part, content)
+				return err
+			}()
+			if errors.Is(err, io.EOF) {
+				err = nil
+				return
+			}
+		}
+	}()
+
+	req, cancel, err := call.newRequest(ctx, method, url, pr)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	req.Header.Set("Content-Type", "multipart/form-data; boundary="+boundary)
+
+	resp, err := call.do(req)
+	if err != nil {
+		return err
+	}
+
+	injectHttpClientResponse(ctx, resp)
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return decodeHttpResponseError(resp)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(respBody)
+}
+
+const (
+	httpStreamRetryDelay    = 500 * time.Millisecond
+	httpStreamMaxRetryDelay = 30 * time.Second
+	httpStreamMaxRetries    = 10
+)
+
+// callHttpServiceStreamMethod subscribes to the stream, if the connection drops before the
+// server ends the stream, it reconnects with backoff and sends the id of the last received
+// event as Last-Event-ID so the server can resume, the last event is dropped if the server replays it
+func callHttpServiceStreamMethod[Resp any](ctx context.Context, call httpClientCall, url string, method string, in any) (*Stream[Resp], error) {
+	// the per call timeout limits the whole stream, including reconnects
+	cancel := context.CancelFunc(func() {})
+	if config := getHttpCallConfig(ctx); config.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, config.timeout)
+		ctx = CreateCtxHttpCallOptions(ctx, WithHttpCallTimeout(0))
+	}
+
+	r, err := callHttpEndpoint(ctx, call, url, method, in)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	out := NewStream[Resp](1)
+
+	go func() {
+		var streamErr error
+		defer func() {
+			out.Close(streamErr)
+		}()
+		defer cancel()
+
+		var lastID string
+		for {
+			done, err := readStreamEvents(ctx, r, &lastID, out)
+			if done {
+				streamErr = err
+				return
+			}
+
+			if ctx.Err() != nil {
+				streamErr = ctx.Err()
+				return
+			}
+
+			// without an event id the service can't resume, calling it again would
+			// start the stream over and repeat the events already received
+			if lastID == "" {
+				streamErr = err
+				return
+			}
+
+			r, err = reconnectHttpStream(ctx, call, url, method, in, lastID)
+			if err != nil {
+				streamErr = err
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// reconnectHttpStream retries the stream call with exponential backoff, it gives up
+// once the server rejects the call or the max number of retries is reached
+func reconnectHttpStream(ctx context.Context, call httpClientCall, url string, method string, in any, lastID string) (r io.ReadCloser, err error) {
+	if lastID != "" {
+		ctx = CreateCtxHttpCallOptions(ctx, WithHttpCallHeader("Last-Event-ID", lastID))
+	}
+
+	delay := httpStreamRetryDelay
+	for i := 0; i < httpStreamMaxRetries; i++ {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+
+		r, err = callHttpEndpoint(ctx, call, url, method, in)
+		if err == nil {
+			return r, nil
+		}
+
+		var respErr Error
+		if errors.As(err, &respErr) && respErr.HTTPStatus < http.StatusInternalServerError {
+			return nil, err
+		}
+
+		delay = min(delay*2, httpStreamMaxRetryDelay)
+	}
+
+	return nil, err
+}
+
+// readStreamEvents reads the events and sends them to out until the connection is closed,
+// it returns true if the server ended the stream, with the error sent by the server if any,
+// otherwise the error of the connection. An event with the same id as lastID is replayed
+// by the service and dropped
+func readStreamEvents[T any](ctx context.Context, r io.ReadCloser, lastID *string, out *Stream[T]) (done bool, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 32*1024*1024)
+
+	// Set the scanner's split function to split on "\n\n"
+	scanner.Split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		// Return nothing if at end of file and no data passed
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+
+		idx := bytes.Index(data, []byte("\n\n"))
+		if idx >= 0 {
+			return idx + 2, data[:idx], nil
+		}
+
+		if atEOF {
+			return len(data), data, nil
+		}
+
+		// We need more data
+		return 0, nil, nil
+	})
+
+	finished := make(chan struct{})
+	defer close(finished)
+
+	// Close the reader when the context is cancelled
+	// this is make sure the scanner.Scan() will return false
+	// and the function will return
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-finished:
+		}
+		r.Close()
+	}()
+
+	for scanner.Scan() {
+		var id string
+		var event string
+		var data []string
+
+		// fields are parsed as defined by Server-Sent Events spec,
+		// comments and unknown fields are ignored
+		for _, line := range strings.Split(scanner.Text(), "\n") {
+			name, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+
+			switch name {
+			case "id":
+				id = value
+			case "event":
+				event = value
+			case "data":
+				data = append(data, value)
+			}
+		}
+
+		if len(data) == 0 {
+			continue
+		}
+
+		payload := []byte(strings.Join(data, "\n"))
+
+		switch event {
+		case "done":
+			return true, nil
+		case "error":
+			return true, decodeStreamError(payload)
+		}
+
+		if id != "" && id == *lastID {
+			continue
+		}
+
+		msg, ok := initalizePointer[T]()
+
+		var err error
+		if ok {
+			err = json.Unmarshal(payload, msg)
+		} else {
+			err = json.Unmarshal(payload, &msg)
+		}
+		if err != nil {
+			continue
+		}
+
+		if err := out.Send(ctx, msg); err != nil {
+			return false, err
+		}
+		if id != "" {
+			*lastID = id
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return false, err
+	}
+
+	return false, io.ErrUnexpectedEOF
+}
+
+func initalizePointer[T any]() (result T, ok bool) {
+	if reflect.ValueOf(result).Kind() != reflect.Ptr {
+		return
+	}
+
+	ok = true
+	va := reflect.ValueOf(&result).Elem()
+	v := reflect.New(va.Type().Elem())
+	va.Set(v)
+
+	return
+}
+
+func getRandomBoundary() string {
+	var buf [30]byte
+	_, err := io.ReadFull(rand.Reader, buf[:])
+	if err != nil {
+		panic(err)
+	}
+	return fmt.Sprintf("%x", buf[:])
+}
+
+// WEBSOCKET UTILITIES
+// Methods with a stream arg are served over WebSocket, this is a minimal implementation
+// of RFC 6455 which only supports what is needed to exchange json messages, no extensions
+// and no subprotocols. Every message is wrapped in wsMessage, the client sends an "end"
+// message once it has nothing more to send, as browsers can't half close the connection,
+// and the server closes the connection once the service's returned stream is closed,
+// preceded by an "error" message if the stream is ended with an error.
+
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+
+	wsCloseNormal         = 1000
+	wsCloseGoingAway      = 1001
+	wsCloseProtocolError  = 1002
+	wsCloseNoStatus       = 1005
+	wsCloseInvalidPayload = 1007
+	wsCloseTooBig         = 1009
+	wsCloseInternalError  = 1011
+
+	wsMaxMessageSize = 32 * 1024 * 1024
+	wsCloseTimeout   = 5 * time.Second
+	wsAcceptGUID     = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+var errWsClosed = errors.New("websocket connection is closed")
+
+type wsMessage struct {
+	Type string          `json:"type"` // data, end or error
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// wsCloseError is returned by readMessage once the peer closes the connection
+type wsCloseError struct {
+	code   int
+	reason string
+}
+
+func (e *wsCloseError) Error() string {
+	return fmt.Sprintf("websocket closed with code %d: %s", e.code, e.reason)
+}
+
+type wsConn struct {
+	rwc       io.ReadWriteCloser
+	r         *bufio.Reader
+	client    bool       // frames sent by the client must be masked
+	mu        sync.Mutex // guards writes and closeSent
+	closeSent bool
+}
+
+func wsAcceptKey(key string) string {
+	hash := sha1.Sum([]byte(key + wsAcceptGUID))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+func headerHasToken(header http.Header, name string, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, item := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(item), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func isWsUpgrade(r *http.Request) bool {
+	return headerHasToken(r.Header, "Connection", "upgrade") &&
+		headerHasToken(r.Header, "Upgrade", "websocket") &&
+		r.Header.Get("Sec-WebSocket-Version") == "13" &&
+		r.Header.Get("Sec-WebSocket-Key") != ""
+}
+
+// isWsOriginAllowed protects against cross-site WebSocket hijacking, browsers send the
+// cookies of the server along with the handshake of any site, so only the server's own
+// origin and the allowed ones can connect
+func isWsOriginAllowed(r *http.Request, allowed []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	for _, value := range allowed {
+		if value == "*" || strings.EqualFold(value, origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// wsUpgrade hijacks the connection and completes the opening handshake,
+// isWsUpgrade must be checked before calling it
+func wsUpgrade(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, ErrInternal.WithMsg("response writer does not support hijacking")
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", wsAcceptKey(r.Header.Get("Sec-WebSocket-Key")))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &wsConn{rwc: conn, r: rw.Reader}, nil
+}
+
+func (c *wsConn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(c.r, header[:]); err != nil {
+		return
+	}
+
+	fin = header[0]&0x80 != 0
+	opcode = header[0] & 0x0F
+	masked := header[1]&0x80 != 0
 
-		part, err := reader.NextPart()
-		if err != nil {
-			httpResponseError(w, err)
-			return
-		}
+	if header[0]&0x70 != 0 || masked == c.client {
+		err = &wsCloseError{code: wsCloseProtocolError, reason: "invalid frame header"}
+		return
+	}
 
-		if part.FormName() != "payload" {
-			part.Close()
-			httpResponseError(w, ErrMissingPayloadName)
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.r, ext[:]); err != nil {
 			return
 		}
-
-		var req ReqMsg
-		err = json.NewDecoder(part).Decode(&req)
-		part.Close()
-		if err != nil {
-			httpResponseError(w, err)
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.r, ext[:]); err != nil {
 			return
 		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
 
-		nextFile := func() (string, io.Reader, error) {
-			part, err := reader.NextPart()
-			if err != nil {
-				return "", nil, err
-			}
-
-			if part.FormName() != "files" {
-				return "", nil, ErrMissingFilesField
-			}
+	if length > wsMaxMessageSize {
+		err = &wsCloseError{code: wsCloseTooBig, reason: "message too big"}
+		return
+	}
 
-			return part.FileName(), part, nil
-		}
+	if opcode >= wsOpClose && (!fin || length > 125) {
+		err = &wsCloseError{code: wsCloseProtocolError, reason: "invalid control frame"}
+		return
+	}
 
-		resp, err := fn(ctx, nextFile, &req)
-		if err != nil {
-			httpResponseError(w, err)
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.r, mask[:]); err != nil {
 			return
 		}
-
-		httpResponse(w, http.StatusOK, resp)
 	}
-}
 
-// HTTP CLIENT UTILITIES
-// Helper utilities for creating http clients
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.r, payload); err != nil {
+		return
+	}
 
-func urlPathJoin(host string, paths ...string) (string, error) {
-	u, err := url.Parse(host)
-	if err != nil {
-		return "", err
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
 	}
 
-	u.Path = path.Join(u.Path, path.Join(paths...))
-	return u.String(), nil
+	return
 }
 
-func callHttpEndpoint(ctx context.Context, client *http.Client, url string, method string, in any) (r io.ReadCloser, err error) {
-	if !isStructEmpty(in) {
-		if method == http.MethodGet {
-			url, err = structToURL(url, in)
-			if err != nil {
+// readMessage returns the next data message, control frames are handled here,
+// once the peer sends a close frame, it is replied and *wsCloseError is returned
+func (c *wsConn) readMessage() ([]byte, error) {
+	var message []byte
+	var started bool
+
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			var closeErr *wsCloseError
+			if errors.As(err, &closeErr) {
+				c.writeClose(closeErr.code, closeErr.reason)
+			}
+			return nil, err
+		}
+
+		switch opcode {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
 				return nil, err
 			}
-		} else {
-			r = jsonEncodeReader(in)
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			closeErr := &wsCloseError{code: wsCloseNoStatus}
+			if len(payload) >= 2 {
+				closeErr.code = int(binary.BigEndian.Uint16(payload))
+				closeErr.reason = string(payload[2:])
+			}
+			c.writeClose(wsCloseNormal, "")
+			return nil, closeErr
+		case wsOpText, wsOpBinary:
+			if started {
+				c.writeClose(wsCloseProtocolError, "expected continuation frame")
+				return nil, &wsCloseError{code: wsCloseProtocolError, reason: "expected continuation frame"}
+			}
+			started = true
+			message = payload
+		case wsOpContinuation:
+			if !started {
+				c.writeClose(wsCloseProtocolError, "unexpected continuation frame")
+				return nil, &wsCloseError{code: wsCloseProtocolError, reason: "unexpected continuation frame"}
+			}
+			if len(message)+len(payload) > wsMaxMessageSize {
+				c.writeClose(wsCloseTooBig, "message too big")
+				return nil, &wsCloseError{code: wsCloseTooBig, reason: "message too big"}
+			}
+			message = append(message, payload...)
+		default:
+			c.writeClose(wsCloseProtocolError, "unknown opcode")
+			return nil, &wsCloseError{code: wsCloseProtocolError, reason: "unknown opcode"}
 		}
-	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, r)
-	if err != nil {
-		return nil, err
+		if fin {
+			return message, nil
+		}
 	}
+}
 
-	req.Header.Set("Content-Type", "application/json")
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+	if c.closeSent {
+		return errWsClosed
 	}
 
-	injectHttpClientResponse(ctx, resp)
+	if opcode == wsOpClose {
+		c.closeSent = true
+	}
 
-	if resp.StatusCode >= 300 {
-		defer resp.Body.Close()
-		err := Error{}
-		if err := json.NewDecoder(resp.Body).Decode(&err); err != nil {
-			return nil, err
-		}
-		return nil, err
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
 	}
 
-	return resp.Body, nil
-}
+	frame := make([]byte, 0, len(payload)+14)
+	frame = append(frame, 0x80|opcode)
 
-func callHttpStreamEndpoint(ctx context.Context, client *http.Client, url string, method string, in any) (r io.ReadCloser, filename string, contentType string, err error) {
-	if !isStructEmpty(in) {
-		if method == http.MethodGet {
-			url, err = structToURL(url, in)
-			if err != nil {
-				return nil, "", "", err
-			}
-		} else {
-			r = jsonEncodeReader(in)
-		}
+	switch {
+	case len(payload) < 126:
+		frame = append(frame, maskBit|byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, r)
-	if err != nil {
-		return nil, "", "", err
+	if c.client {
+		var mask [4]byte
+		if _, err := io.ReadFull(rand.Reader, mask[:]); err != nil {
+			return err
+		}
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, payload...)
+		for i := range payload {
+			frame[start+i] ^= mask[i%4]
+		}
+	} else {
+		frame = append(frame, payload...)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	_, err := c.rwc.Write(frame)
+	return err
+}
 
-	resp, err := client.Do(req)
+func (c *wsConn) writeMessage(msg wsMessage) error {
+	data, err := json.Marshal(msg)
 	if err != nil {
-		return nil, "", "", err
+		return err
 	}
 
-	injectHttpClientResponse(ctx, resp)
+	return c.writeFrame(wsOpText, data)
+}
 
-	if resp.StatusCode >= 300 {
-		defer resp.Body.Close()
-		err := Error{}
-		if err := json.NewDecoder(resp.Body).Decode(&err); err != nil {
-			return nil, "", "", err
-		}
-		return nil, "", "", err
+// writeClose sends the close frame only once, the reason is truncated
+// as control frames can't be bigger than 125 bytes
+func (c *wsConn) writeClose(code int, reason string) {
+	if len(reason) > 123 {
+		reason = reason[:123]
 	}
 
-	contentType = resp.Header.Get("Content-Type")
-	filename = strings.Replace(resp.Header.Get("Content-Disposition"), "attachment; filename=", "", 1)
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason...)
 
-	return resp.Body, filename, contentType, nil
+	c.writeFrame(wsOpClose, payload)
+}
+
+func (c *wsConn) close() error {
+	return c.rwc.Close()
 }
 
-func callHttpServiceMethod(ctx context.Context, client *http.Client, url string, method string, in any, out any) (err error) {
-	r, err := callHttpEndpoint(ctx, client, url, method, in)
+// decodeWsData decodes the data of the message, and validates it if it is a model
+func decodeWsData[T any](name string, data json.RawMessage) (T, error) {
+	item, ok := initalizePointer[T]()
+
+	var err error
+	if ok {
+		err = json.Unmarshal(data, item)
+	} else {
+		err = json.Unmarshal(data, &item)
+	}
 	if err != nil {
-		return err
+		return item, err
 	}
-	defer r.Close()
 
-	if isStructEmpty(out) {
-		return nil
+	if errs := appendNestedFieldErrors(nil, name, item); len(errs) > 0 {
+		return item, ErrValidation.WithFields(errs...)
 	}
 
-	return json.NewDecoder(r).Decode(out)
+	return item, nil
 }
 
-func sendHttpFilesUpload(ctx context.Context, client *http.Client, url string, method string, payload any, files func() (string, io.Reader, error), respBody any) error {
-	pr, pw := io.Pipe()
+func createBidiStreamServiceMethod[ReqMsg, In, Out any](call httpServiceCall, streamName string, fn func(ctx context.Context, req *ReqMsg, in *Stream[In]) (*Stream[Out], error)) httpServiceMethodHandler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			httpResponseError(w, ErrMethodNotAllowed.WithMsg("method %q not allowed", r.Method))
+			return
+		}
 
-	boundary := getRandomBoundary()
+		if !isWsUpgrade(r) {
+			httpResponseError(w, ErrWebSocketRequired)
+			return
+		}
 
-	go func() {
-		var err error
-		var part io.Writer
-		var filename string
-		var content io.Reader
+		var reqMsg ReqMsg
 
-		mw := multipart.NewWriter(pw)
+		if err := valuesToStruct(r.URL.Query(), &reqMsg); err != nil {
+			httpResponseError(w, ErrInvalidArgs.WithCause(err))
+			return
+		}
 
-		defer func() {
-			mw.Close()
-			if err != nil {
-				pw.CloseWithError(err)
-			} else {
-				pw.Close()
-			}
-		}()
+		if err := pathValuesToStruct(r, &reqMsg); err != nil {
+			httpResponseError(w, ErrInvalidArgs.WithCause(err))
+			return
+		}
 
-		err = mw.SetBoundary(boundary)
-		if err != nil {
+		if err := validateArgs(&reqMsg); err != nil {
+			httpResponseError(w, err)
 			return
 		}
 
-		part, err = mw.CreateFormField("payload")
-		if err != nil {
+		if !isWsOriginAllowed(r, call.origins) {
+			httpResponseError(w, ErrOriginNotAllowed.WithMsg("origin %q not allowed", r.Header.Get("Origin")))
 			return
 		}
 
-		_, err = io.Copy(part, jsonEncodeReader(payload))
+		// the connection is upgraded before calling the service, so the service is
+		// not called if the handshake fails, and its errors are sent as error message
+		conn, err := wsUpgrade(w, r)
 		if err != nil {
+			httpResponseError(w, err)
 			return
 		}
+		defer conn.close()
 
-		for {
-			err = func() error {
-				filename, content, err = files()
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		in := NewStream[In](1)
+
+		readDone := make(chan struct{})
+
+		// reads the client's messages until the connection is closed, the service
+		// is cancelled if the client goes away or sends an invalid message, in which
+		// case the stream of messages is closed with the error
+		go func() {
+			var readErr error
+
+			defer close(readDone)
+			defer cancel()
+			defer func() {
+				in.Close(readErr)
+			}()
+
+			ended := false
+
+			for {
+				data, err := conn.readMessage()
 				if err != nil {
-					return err
+					if !ended {
+						readErr = err
+					}
+					return
 				}
 
-				if closer, ok := content.(io.Closer); ok {
-					defer closer.Close()
+				var msg wsMessage
+				if err := json.Unmarshal(data, &msg); err != nil {
+					readErr = ErrInternal.WithMsg("invalid message").WithCause(err)
+					conn.writeClose(wsCloseInvalidPayload, "invalid message")
+					return
 				}
 
-				part, err = mw.CreateFormFile("files", filename)
-				if err != nil {
-					return err
+				switch msg.Type {
+				case "end":
+					if !ended {
+						ended = true
+						in.Close(nil)
+					}
+				case "data":
+					if ended {
+						conn.writeClose(wsCloseProtocolError, "message sent after end")
+						return
+					}
+
+					item, err := decodeWsData[In](streamName, msg.Data)
+					if err != nil {
+						readErr = err
+						conn.writeClose(wsCloseInvalidPayload, err.Error())
+						return
+					}
+
+					if err := in.Send(ctx, item); err != nil {
+						return
+					}
 				}
+			}
+		}()
 
-				_, err = io.Copy(part, content)
+		streamErr := func() error {
+			out, err := interceptHttpCall(ctx, call, &reqMsg, func(ctx context.Context, req *ReqMsg) (*Stream[Out], error) {
+				return fn(ctx, req, in)
+			})
+			if err != nil {
 				return err
-			}()
-			if errors.Is(err, io.EOF) {
-				err = nil
-				return
 			}
-		}
-	}()
+			if out == nil {
+				return call.errNilStream()
+			}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, pr)
-	if err != nil {
-		return err
-	}
+			for item := range out.Items() {
+				data, err := json.Marshal(item)
+				if err != nil {
+					return ErrInternal.WithCause(err)
+				}
 
-	req.Header.Set("Content-Type", "multipart/form-data; boundary="+boundary)
+				if err := conn.writeMessage(wsMessage{Type: "data", Data: data}); err != nil {
+					return nil
+				}
+			}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
+			return out.Err()
+		}()
 
-	injectHttpClientResponse(ctx, resp)
+		// the reader might be blocked on sending to the service which is already done
+		cancel()
 
-	defer resp.Body.Close()
+		if streamErr != nil {
+			conn.writeMessage(wsMessage{Type: "error", Data: encodeStreamError(streamErr)})
+		}
 
-	if resp.StatusCode >= 300 {
-		err := Error{}
-		if err := json.NewDecoder(resp.Body).Decode(&err); err != nil {
-			return err
+		// wait for the client to reply the close frame before closing the connection
+		conn.writeClose(wsCloseNormal, "")
+		select {
+		case <-readDone:
+		case <-time.After(wsCloseTimeout):
 		}
-		return err
 	}
-
-	return json.NewDecoder(resp.Body).Decode(respBody)
 }
 
-func callHttpServiceStreamMethod[Resp any](ctx context.Context, client *http.Client, url string, method string, in any) (<-chan Resp, error) {
-	r, err := callHttpEndpoint(ctx, client, url, method, in)
+func callHttpServiceBidiStreamMethod[In, Out any](ctx context.Context, call httpClientCall, url string, in any, msgs *Stream[In]) (*Stream[Out], error) {
+	var err error
+
+	if !isStructEmpty(in) {
+		url, err = structToURL(url, in)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	req, cancel, err := call.newRequest(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return parseStreamEvents[Resp](ctx, r), nil
-}
+	key := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		cancel()
+		return nil, err
+	}
 
-func parseStreamEvents[T any](ctx context.Context, r io.ReadCloser) <-chan T {
-	out := make(chan T, 1)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", base64.StdEncoding.EncodeToString(key))
 
-	scanner := bufio.NewScanner(r)
+	resp, err := call.do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
 
-	// Set the scanner's split function to split on "\n\n"
-	scanner.Split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
-		// Return nothing if at end of file and no data passed
-		if atEOF && len(data) == 0 {
-			return 0, nil, nil
-		}
+	injectHttpClientResponse(ctx, resp)
 
-		idx := bytes.Index(data, []byte("\n\n"))
-		if idx >= 0 {
-			return idx + 2, data[:idx], nil
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		defer cancel()
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return nil, decodeHttpResponseError(resp)
 		}
+		return nil, ErrWebSocketRequired.WithMsg("expected websocket upgrade, got status %d", resp.StatusCode)
+	}
 
-		if atEOF {
-			return len(data), data, nil
-		}
+	rwc, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok || resp.Header.Get("Sec-WebSocket-Accept") != wsAcceptKey(req.Header.Get("Sec-WebSocket-Key")) {
+		resp.Body.Close()
+		cancel()
+		return nil, ErrWebSocketRequired.WithMsg("invalid websocket handshake")
+	}
 
-		// We need more data
-		return 0, nil, nil
-	})
+	conn := &wsConn{rwc: rwc, r: bufio.NewReader(rwc), client: true}
 
-	secondPart := func(prefix, value string) (string, bool) {
-		if !strings.HasPrefix(value, prefix) {
-			return "", false
+	out := NewStream[Out](1)
+	done := make(chan struct{})
+
+	// closes the connection once the context is cancelled or the stream is over
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.writeClose(wsCloseGoingAway, "")
+		case <-done:
 		}
-		return strings.TrimSpace(value[len(prefix):]), true
-	}
+		conn.close()
+		cancel()
+	}()
 
-	// Close the reader when the context is cancelled
-	// this is make sure the scanner.Scan() will return false
-	// and the goroutine will exit
 	go func() {
-		<-ctx.Done()
-		r.Close()
+		for {
+			select {
+			case <-done:
+				return
+			case msg, ok := <-msgs.Items():
+				if !ok {
+					conn.writeMessage(wsMessage{Type: "end"})
+					return
+				}
+
+				data, err := json.Marshal(msg)
+				if err != nil {
+					conn.writeClose(wsCloseInvalidPayload, err.Error())
+					return
+				}
+
+				if err := conn.writeMessage(wsMessage{Type: "data", Data: data}); err != nil {
+					return
+				}
+			}
+		}
 	}()
 
 	go func() {
-		defer close(out)
-		for scanner.Scan() {
-			item := scanner.Text()
-			lines := strings.Split(item, "\n")
-
-			if len(lines) != 3 {
-				continue
-			}
+		var streamErr error
+		defer func() {
+			out.Close(streamErr)
+		}()
+		defer close(done)
 
-			identifier, ok := secondPart("id:", lines[0])
-			if !ok {
-				continue
+		for {
+			data, err := conn.readMessage()
+			if err != nil {
+				var closeErr *wsCloseError
+				switch {
+				case streamErr != nil:
+					// keep the error sent by the server
+				case ctx.Err() != nil:
+					streamErr = ctx.Err()
+				case !errors.As(err, &closeErr) || closeErr.code != wsCloseNormal:
+					streamErr = err
+				}
+				return
 			}
 
-			// ignore id for now
-			_, err := strconv.ParseInt(identifier, 10, 64)
-			if err != nil {
+			var msg wsMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
 				continue
 			}
 
-			// ignore event for now
-			_, ok = secondPart("event:", lines[1])
-			if !ok {
+			if msg.Type == "error" {
+				streamErr = decodeStreamError(msg.Data)
 				continue
 			}
 
-			data, ok := secondPart("data:", lines[2])
-			if !ok {
+			if msg.Type != "data" {
 				continue
 			}
 
-			msg, ok := initalizePointer[T]()
-
+			item, ok := initalizePointer[Out]()
 			if ok {
-				err = json.Unmarshal([]byte(data), msg)
+				err = json.Unmarshal(msg.Data, item)
 			} else {
-				err = json.Unmarshal([]byte(data), &msg)
+				err = json.Unmarshal(msg.Data, &item)
 			}
 			if err != nil {
 				continue
 			}
 
-			out <- msg
+			if err := out.Send(ctx, item); err != nil {
+				streamErr = err
+				return
+			}
 		}
 	}()
 
-	return out
-}
-
-func initalizePointer[T any]() (result T, ok bool) {
-	if reflect.ValueOf(result).Kind() != reflect.Ptr {
-		return
-	}
-
-	ok = true
-	va := reflect.ValueOf(&result).Elem()
-	v := reflect.New(va.Type().Elem())
-	va.Set(v)
-
-	return
-}
-
-func getRandomBoundary() string {
-	var buf [30]byte
-	_, err := io.ReadFull(rand.Reader, buf[:])
-	if err != nil {
-		panic(err)
-	}
-	return fmt.Sprintf("%x", buf[:])
+	return out, nil
 }
 
 // HTTP UTILITIES
@@ -883,93 +3506,367 @@ func structToURL(host string, ptr any) (string, error) {
 	return u.String(), nil
 }
 
-// structToValues converts a struct to url.Values
-// supports only basic types, string, int, float, bool
-// and ignores other complex types
+// QUERY STRING CODEC
+// GET methods send their args as query string, the following rules are shared
+// between the server and the clients:
+// - scalars are formatted the same as json, []byte is base64 encoded
+// - enums and timestamps use their text form, timestamps are RFC3339
+// - arrays are encoded as repeated keys, e.g. ids=1&ids=2
+// - models are encoded as dotted keys, e.g. filter.name=ella&filter.age=10
+// - nil values are omitted, args bound to the path are ignored
+
+// structToValues converts the args struct to url.Values
 func structToValues(ptr any) url.Values {
 	values := url.Values{}
-	v := reflect.Indirect(reflect.ValueOf(ptr))
+	encodeQueryFields(values, "", reflect.Indirect(reflect.ValueOf(ptr)))
+	return values
+}
 
+func encodeQueryFields(values url.Values, prefix string, v reflect.Value) {
 	t := v.Type()
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
-		value := v.Field(i)
-
-		// Convert the field value to a string
-		var strValue string
-		switch value.Kind() {
-		case reflect.String:
-			strValue = url.QueryEscape(value.String())
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			strValue = strconv.FormatInt(value.Int(), 10)
-		case reflect.Float32, reflect.Float64:
-			strValue = strconv.FormatFloat(value.Float(), 'f', -1, 64)
-		case reflect.Bool:
-			strValue = strconv.FormatBool(value.Bool())
-		default:
+		name := queryFieldName(field)
+		if name == "" {
 			continue
 		}
 
-		// Add the key-value pair to the values
-		if strValue != "" {
-			key := field.Tag.Get("json")
-			if key == "" {
-				key = field.Name
-			}
-			values.Add(key, strValue)
+		encodeQueryValue(values, prefix+name, v.Field(i))
+	}
+}
+
+func encodeQueryValue(values url.Values, key string, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return
 		}
+		encodeQueryValue(values, key, v.Elem())
+		return
+	}
+
+	if marshaler, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+		if err != nil {
+			// zero value of enums can't be marshaled, which means no value
+			return
+		}
+		values.Add(key, string(text))
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		values.Add(key, v.String())
+	case reflect.Bool:
+		values.Add(key, strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		values.Add(key, strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		values.Add(key, strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		values.Add(key, strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()))
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			values.Add(key, base64.StdEncoding.EncodeToString(v.Bytes()))
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			encodeQueryValue(values, key, v.Index(i))
+		}
+	case reflect.Struct:
+		encodeQueryFields(values, key+".", v)
 	}
-	return values
 }
 
-// valuesToStruct converts url.Values to a struct
-// supports only basic types, string, int, float, bool
-// returns an error if the struct contains unsupported types
+// valuesToStruct converts url.Values to the args struct, missing keys
+// keep their zero value
 func valuesToStruct(values url.Values, ptr any) error {
-	dType := reflect.TypeOf(ptr)
-	dhVal := reflect.ValueOf(ptr)
+	return decodeQueryFields(values, "", reflect.Indirect(reflect.ValueOf(ptr)))
+}
+
+func decodeQueryFields(values url.Values, prefix string, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := queryFieldName(field)
+		if name == "" {
+			continue
+		}
+
+		if err := decodeQueryValue(values, prefix+name, v.Field(i)); err != nil {
+			return err
+		}
+	}
 
-	for i := 0; i < dType.Elem().NumField(); i++ {
-		field := dType.Elem().Field(i)
-		key := field.Tag.Get("json")
-		kind := field.Type.Kind()
+	return nil
+}
 
-		val := values.Get(key)
+func decodeQueryValue(values url.Values, key string, v reflect.Value) error {
+	if !hasQueryKey(values, key) {
+		return nil
+	}
 
-		result := dhVal.Elem().Field(i)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeQueryValue(values, key, v.Elem())
+	}
 
-		switch kind {
-		case reflect.String:
-			val, err := url.QueryUnescape(val)
-			if err != nil {
-				return err
-			}
-			result.SetString(val)
-		case reflect.Int:
-			v, err := strconv.ParseInt(val, 10, 64)
-			if err != nil {
-				return err
-			}
-			result.SetInt(v)
-		case reflect.Float64:
-			v, err := strconv.ParseFloat(val, 64)
+	if v.Addr().Type().Implements(textUnmarshalerType) {
+		return setValueFromString(v, values.Get(key))
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return decodeQueryFields(values, key+".", v)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b, err := base64.StdEncoding.DecodeString(values.Get(key))
 			if err != nil {
-				return err
+				return fmt.Errorf("invalid query value for %s: %w", key, err)
 			}
-			result.SetFloat(v)
-		case reflect.Bool:
-			v, err := strconv.ParseBool(val)
-			if err != nil {
-				return err
+			v.SetBytes(b)
+			return nil
+		}
+
+		items := values[key]
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValueFromString(slice.Index(i), item); err != nil {
+				return fmt.Errorf("invalid query value for %s: %w", key, err)
 			}
-			result.SetBool(v)
-		default:
-			return fmt.Errorf("unsupported type %s", kind)
 		}
+		v.Set(slice)
+		return nil
+	default:
+		if err := setValueFromString(v, values.Get(key)); err != nil {
+			return fmt.Errorf("invalid query value for %s: %w", key, err)
+		}
+		return nil
+	}
+}
+
+// hasQueryKey returns true if the key, or any of its nested keys, exists
+func hasQueryKey(values url.Values, key string) bool {
+	if _, ok := values[key]; ok {
+		return true
+	}
+
+	for k := range values {
+		if strings.HasPrefix(k, key+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// queryFieldName returns the json name of the field, or empty string if
+// the field is not encoded, e.g. unexported or bound to path
+func queryFieldName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	default:
+		return name
+	}
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// pathValuesToStruct sets the args bound to the path placeholders, these
+// args are marked by path tag, e.g. `path:"id"`
+func pathValuesToStruct(r *http.Request, ptr any) error {
+	v := reflect.Indirect(reflect.ValueOf(ptr))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("path")
+		if name == "" {
+			continue
+		}
+
+		if err := setValueFromString(v.Field(i), r.PathValue(name)); err != nil {
+			return fmt.Errorf("invalid path value for %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// setValueFromString parses the string into the value, supports types which
+// implement encoding.TextUnmarshaler such as enums and timestamps, string, bool and numbers
+func setValueFromString(value reflect.Value, str string) error {
+	if value.CanAddr() && value.Addr().Type().Implements(textUnmarshalerType) {
+		return value.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str))
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(str)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(str)
+		if err != nil {
+			return err
+		}
+		value.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(str, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(str, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(str, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(v)
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+
+	return nil
+}
+
+// VALIDATION UTILITIES
+// Helper utilities for validating models and service method's args
+
+type validatable interface {
+	Validate() error
+}
+
+var validatableType = reflect.TypeOf((*validatable)(nil)).Elem()
+
+// validateArgs validates every arg which is a model, or an array or map of models
+// args must be a pointer to the args struct
+func validateArgs(args any) error {
+	v := reflect.Indirect(reflect.ValueOf(args))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs []FieldError
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || !canValidate(field.Type) {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+
+		errs = appendNestedFieldErrors(errs, name, v.Field(i).Interface())
 	}
+
+	if len(errs) > 0 {
+		return ErrValidation.WithFields(errs...)
+	}
+
 	return nil
 }
 
+func canValidate(t reflect.Type) bool {
+	if t.Implements(validatableType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return canValidate(t.Elem())
+	default:
+		return false
+	}
+}
+
+// appendNestedFieldErrors validates the value, which can be a model or an array
+// or map of models, and appends the errors to errs with the prefix path
+func appendNestedFieldErrors(errs []FieldError, prefix string, value any) []FieldError {
+	v := reflect.ValueOf(value)
+
+	switch v.Kind() {
+	case reflect.Invalid:
+		return errs
+	case reflect.Slice, reflect.Array:
+		if !canValidate(v.Type().Elem()) {
+			return errs
+		}
+		for i := 0; i < v.Len(); i++ {
+			errs = appendNestedFieldErrors(errs, fmt.Sprintf("%s[%d]", prefix, i), v.Index(i).Interface())
+		}
+		return errs
+	case reflect.Map:
+		if !canValidate(v.Type().Elem()) {
+			return errs
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			errs = appendNestedFieldErrors(errs, fmt.Sprintf("%s[%v]", prefix, iter.Key().Interface()), iter.Value().Interface())
+		}
+		return errs
+	case reflect.Pointer:
+		if v.IsNil() {
+			return errs
+		}
+	}
+
+	model, ok := value.(validatable)
+	if !ok {
+		return errs
+	}
+
+	err := model.Validate()
+	if err == nil {
+		return errs
+	}
+
+	validationErr, _ := asError(err)
+	if len(validationErr.Fields) == 0 {
+		return append(errs, FieldError{Field: prefix, Message: err.Error()})
+	}
+
+	for _, field := range validationErr.Fields {
+		field.Field = prefix + "." + field.Field
+		errs = append(errs, field)
+	}
+
+	return errs
+}
+
+func matchPatternAll(pattern *regexp.Regexp, values []string) bool {
+	for _, value := range values {
+		if !pattern.MatchString(value) {
+			return false
+		}
+	}
+
+	return true
+}
+
 // ERROR UTILITIES
 // Helper utilities for creating uniform error responses
 // Partially inspired by webrpc's error handling
@@ -979,27 +3876,57 @@ func valuesToStruct(values url.Values, ptr any) error {
 // - WithMsg is added to allow changing the message of the error, the comparison of the error will still be based on the code
 // - It is recommended to use the generated code to create errors
 type Error struct {
-	Code       int    `json:"code"`
-	Message    string `json:"message"`
-	HTTPStatus int    `json:"-"`
+	Code       int          `json:"code"`
+	Message    string       `json:"message"`
+	Fields     []FieldError `json:"fields,omitempty"`
+	HTTPStatus int          `json:"-"`
 	cause      error
 }
 
-var _ error = Error{}
+// FieldError describes a single invalid field, Field is the json path of the
+// field, e.g. "address.street" or "tags[1]". It's an alias, so the field errors of
+// all the generated packages have the same type.
+type FieldError = struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// packageError is implemented by the Error type of every generated package, so the
+// errors of imported packages, e.g. validation errors of their models, are converted
+// to Error instead of being reported as internal errors
+type packageError interface {
+	error
+	Unwrap() error
+	ErrorDetails() (code int, httpStatus int, message string, fields []FieldError)
+}
+
+var (
+	_ error        = Error{}
+	_ packageError = Error{}
+)
 
 func (e Error) Error() string {
+	msg := e.Message
+	if len(e.Fields) > 0 {
+		fields := make([]string, 0, len(e.Fields))
+		for _, field := range e.Fields {
+			fields = append(fields, field.Field+" "+field.Message)
+		}
+		msg += " (" + strings.Join(fields, ", ") + ")"
+	}
 	if e.cause != nil {
-		return fmt.Sprintf("%d: %s: %v", e.Code, e.Message, e.cause)
+		return fmt.Sprintf("%d: %s: %v", e.Code, msg, e.cause)
 	}
-	return fmt.Sprintf("%d: %s", e.Code, e.Message)
+	return fmt.Sprintf("%d: %s", e.Code, msg)
 }
 
 func (e Error) Is(target error) bool {
 	if target == nil {
 		return false
 	}
-	if rpcErr, ok := target.(Error); ok {
-		return rpcErr.Code == e.Code
+	if rpcErr, ok := target.(packageError); ok {
+		code, _, _, _ := rpcErr.ErrorDetails()
+		return code == e.Code
 	}
 	return errors.Is(e.cause, target)
 }
@@ -1008,6 +3935,12 @@ func (e Error) Unwrap() error {
 	return e.cause
 }
 
+// ErrorDetails returns the fields of the error, it's used to convert the errors
+// of other generated packages, see asError
+func (e Error) ErrorDetails() (code int, httpStatus int, message string, fields []FieldError) {
+	return e.Code, e.HTTPStatus, e.Message, e.Fields
+}
+
 func (e Error) WithCause(cause error) Error {
 	err := e
 	err.cause = cause
@@ -1020,19 +3953,28 @@ func (e Error) WithMsg(msg string, args ...any) Error {
 	return err
 }
 
+func (e Error) WithFields(fields ...FieldError) Error {
+	err := e
+	err.Fields = append(append([]FieldError(nil), e.Fields...), fields...)
+	return err
+}
+
 // encodeRpcError encodes an error to a byte slice
-// the format is "EllaError:-:<code>:-:<message>:-:<http_status>:-:<cause>"
-// there are 3 different formats:
-// - 1 segments "<message> (for normal error without http status code)
-// - 3 segments "<code>:-:<message>:-:<http_status> (for normal error with http status code)
-// - 4 segments "<code>:-:<message>:-:<http_status>:-:<cause>" (for error with cause)
+// the format is "EllaError:-:<code>:-:<message>:-:<http_status>:-:<cause>:-:<fields>"
+// there are 4 different formats:
+//   - 1 segments "<message> (for normal error without http status code)
+//   - 3 segments "<code>:-:<message>:-:<http_status> (for normal error with http status code)
+//   - 4 segments "<code>:-:<message>:-:<http_status>:-:<cause>" (for error with cause)
+//   - 5 segments "<code>:-:<message>:-:<http_status>:-:<cause>:-:<fields>" (for error with fields,
+//     the cause might be empty and fields is the json array of FieldError)
+//
 // NOTE: use custom encode/decode for performance reasons
 func encodeRpcError(err error) []byte {
 	var b bytes.Buffer
 
 	b.WriteString("EllaError:-:")
 
-	e, ok := err.(Error)
+	e, ok := asError(err)
 	if !ok {
 		b.WriteString(err.Error())
 		return b.Bytes()
@@ -1043,9 +3985,16 @@ func encodeRpcError(err error) []byte {
 	b.WriteString(e.Message)
 	b.WriteString(":-:")
 	b.WriteString(strconv.FormatInt(int64(e.HTTPStatus), 10))
-	if e.cause != nil {
+	if e.cause != nil || len(e.Fields) > 0 {
+		b.WriteString(":-:")
+		if e.cause != nil {
+			b.WriteString(e.cause.Error())
+		}
+	}
+	if len(e.Fields) > 0 {
+		fields, _ := json.Marshal(e.Fields)
 		b.WriteString(":-:")
-		b.WriteString(e.cause.Error())
+		b.Write(fields)
 	}
 
 	return b.Bytes()
@@ -1058,38 +4007,59 @@ func decodeRpcError(b []byte) (Error, bool) {
 
 	b = bytes.TrimPrefix(b, []byte("EllaError:-:"))
 
-	segments := bytes.Split(b, []byte(":-:"))
+	segments := bytes.SplitN(b, []byte(":-:"), 5)
 
-	switch len(segments) {
-	case 1: // normal error
-		return ErrInternal.WithMsg(string(segments[1])), true
-	case 3: // error with http status
-		code, err := strconv.ParseInt(string(segments[0]), 10, 64)
-		if err != nil {
-			return ErrInternal.WithMsg(string(b)), true
-		}
+	if len(segments) == 1 { // normal error
+		return ErrInternal.WithMsg("%s", segments[0]), true
+	}
 
-		httpStatus, err := strconv.ParseInt(string(segments[2]), 10, 64)
-		if err != nil {
-			return ErrInternal.WithMsg(string(b)), true
-		}
+	if len(segments) < 3 {
+		return ErrInternal.WithMsg("%s", b), true
+	}
+
+	code, err := strconv.ParseInt(string(segments[0]), 10, 64)
+	if err != nil {
+		return ErrInternal.WithMsg("%s", b), true
+	}
 
-		return newError(int(code), int(httpStatus), nil, string(segments[1])), true
+	httpStatus, err := strconv.ParseInt(string(segments[2]), 10, 64)
+	if err != nil {
+		return ErrInternal.WithMsg("%s", b), true
+	}
 
-	case 4: // error with cause
-		code, err := strconv.ParseInt(string(segments[0]), 10, 64)
-		if err != nil {
-			return ErrInternal.WithMsg(string(b)), true
-		}
+	var cause error
+	if len(segments) > 3 && len(segments[3]) > 0 { // error with cause
+		cause = errors.New(string(segments[3]))
+	}
 
-		httpStatus, err := strconv.ParseInt(string(segments[2]), 10, 64)
-		if err != nil {
-			return ErrInternal.WithMsg(string(b)), true
+	result := newError(int(code), int(httpStatus), cause, "%s", segments[1])
+
+	if len(segments) > 4 { // error with fields
+		if err := json.Unmarshal(segments[4], &result.Fields); err != nil {
+			return ErrInternal.WithMsg("%s", b), true
 		}
+	}
+
+	return result, true
+}
 
-		return newError(int(code), int(httpStatus), nil, string(segments[1]), errors.New(string(segments[3]))), true
+// asError returns err as Error, the Error of other generated packages is converted
+// with its details and cause
+func asError(err error) (Error, bool) {
+	switch err := err.(type) {
+	case Error:
+		return err, true
+	case packageError:
+		code, httpStatus, message, fields := err.ErrorDetails()
+		return Error{
+			Code:       code,
+			Message:    message,
+			Fields:     fields,
+			HTTPStatus: httpStatus,
+			cause:      err.Unwrap(),
+		}, true
 	default:
-		return ErrInternal.WithMsg(string(b)), true
+		return Error{}, false
 	}
 }
 
@@ -1107,21 +4077,22 @@ func newError(code int, httpStatus int, cause error, msg string, args ...any) Er
 }
 
 // httpResponseError is a helper function that writes an error to the http response
-// if the error is of type *Error, it will write the error code and message
+// if the error is an Error of any generated package, it will write the error code and message
 // and if code is valid http status code, it will write the http status code
 // otherwise it will write 500
 func httpResponseError(w http.ResponseWriter, err error) {
-	switch err := err.(type) {
-	case Error:
-		if err.HTTPStatus < 100 || err.HTTPStatus > 599 {
-			httpResponse(w, http.StatusInternalServerError, err)
-			return
-		}
-		slog.Error("http response error", "http_status", err.HTTPStatus, "code", err.Code, "message", err.Message, "cause", err.cause)
-		httpResponse(w, err.HTTPStatus, err)
-	default:
+	httpErr, ok := asError(err)
+	if !ok {
 		httpResponseError(w, newError(0, http.StatusInternalServerError, err, "internal server error"))
+		return
+	}
+
+	if httpErr.HTTPStatus < 100 || httpErr.HTTPStatus > 599 {
+		httpResponse(w, http.StatusInternalServerError, httpErr)
+		return
 	}
+	slog.Error("http response error", "http_status", httpErr.HTTPStatus, "code", httpErr.Code, "message", httpErr.Message, "cause", httpErr.cause)
+	httpResponse(w, httpErr.HTTPStatus, httpErr)
 }
 
 // Default Errors
@@ -1134,4 +4105,9 @@ var (
 	ErrMethodNotAllowed      = newError(-5, http.StatusMethodNotAllowed, nil, "method not allowed")
 	ErrFlusherNotSupported   = newError(-6, http.StatusNotExtended, nil, "response writer does not support flushing")
 	ErrInternal              = newError(-7, http.StatusInternalServerError, nil, "internal server error")
+	ErrValidation            = newError(-8, http.StatusBadRequest, nil, "validation failed")
+	ErrWebSocketRequired     = newError(-9, http.StatusUpgradeRequired, nil, "websocket upgrade required")
+	ErrServerBusy            = newError(-10, http.StatusServiceUnavailable, nil, "server is busy")
+	ErrOriginNotAllowed      = newError(-11, http.StatusForbidden, nil, "origin not allowed")
+	ErrInvalidArgs           = newError(-12, http.StatusBadRequest, nil, "invalid arguments")
 )
@@ -31,3 +31,96 @@ func TestCallHttpMethod(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, result)
 }
+
+func TestHttpServerInterceptors(t *testing.T) {
+	var calls []string
+
+	server := httptest.NewServer(
+		CreatePeopleServiceServer(
+			&HttpPeopleServiceImpl{},
+			WithHttpServerInterceptors(
+				func(ctx context.Context, info HttpServerCallInfo, args any, next HttpServerHandler) (any, error) {
+					calls = append(calls, "outer:"+info.Service+"."+info.Method)
+					return next(ctx, args)
+				},
+				func(ctx context.Context, info HttpServerCallInfo, args any, next HttpServerHandler) (any, error) {
+					in, ok := args.(*struct {
+						Age int8 `json:"age"`
+					})
+					assert.True(t, ok)
+					if in.Age == 42 {
+						return nil, ErrAgen.WithMsg("age 42 is not allowed")
+					}
+
+					result, err := next(ctx, args)
+					calls = append(calls, "inner:"+info.Method)
+					return result, err
+				},
+			),
+		),
+	)
+	defer server.Close()
+
+	client := CreateHttpPeopleServiceClient(server.URL, &http.Client{})
+
+	result, err := client.GetRandom(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int8(10), result.Age)
+	assert.Equal(t, []string{"outer:PeopleService.GetRandom", "inner:GetRandom"}, calls)
+
+	_, err = client.GetRandom(context.Background(), 42)
+	assert.ErrorIs(t, err, ErrAgen)
+}
+
+func TestHttpClientInterceptors(t *testing.T) {
+	server := httptest.NewServer(
+		CreatePeopleServiceServer(
+			&HttpPeopleServiceImpl{},
+			WithHttpServerInterceptors(
+				func(ctx context.Context, info HttpServerCallInfo, args any, next HttpServerHandler) (any, error) {
+					r, _ := GetCtxHttpRequest(ctx)
+					if r.Header.Get("Authorization") != "Bearer token" {
+						return nil, ErrAgen.WithMsg("unauthorized")
+					}
+					if r.Header.Get("X-Request-Id") == "blocked" {
+						return nil, ErrAgen.WithMsg("blocked")
+					}
+					return next(ctx, args)
+				},
+			),
+		),
+	)
+	defer server.Close()
+
+	var statuses []int
+
+	client := CreateHttpPeopleServiceClient(
+		server.URL,
+		&http.Client{},
+		WithHttpClientInterceptors(
+			func(info HttpClientCallInfo, req *http.Request, next HttpClientRoundTrip) (*http.Response, error) {
+				assert.Equal(t, "PeopleService", info.Service)
+				assert.Equal(t, "GetRandom", info.Method)
+				req.Header.Set("Authorization", "Bearer token")
+				resp, err := next(req)
+				if err == nil {
+					statuses = append(statuses, resp.StatusCode)
+				}
+				return resp, err
+			},
+		),
+	)
+
+	_, err := client.GetRandom(context.Background(), 10)
+	assert.NoError(t, err)
+
+	ctx := CreateCtxHttpCallOptions(context.Background(), WithHttpCallHeader("X-Request-Id", "blocked"))
+	_, err = client.GetRandom(ctx, 10)
+	assert.ErrorIs(t, err, ErrAgen)
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusInternalServerError}, statuses)
+
+	// calling without the interceptor should fail as the auth header is missing
+	_, err = CreateHttpPeopleServiceClient(server.URL, nil).GetRandom(context.Background(), 10)
+	assert.ErrorIs(t, err, ErrAgen)
+}
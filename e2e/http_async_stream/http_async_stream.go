@@ -18,8 +18,25 @@ func (h *HttpSignalServiceImpl) Send(ctx context.Context, inbox string, msg stri
 	return h.bus.Send(ctx, inbox, msg)
 }
 
-func (h *HttpSignalServiceImpl) Recv(ctx context.Context, inbox string) (msgs <-chan string, err error) {
-	return h.bus.Recv(ctx, inbox)
+func (h *HttpSignalServiceImpl) Recv(ctx context.Context, inbox string) (msgs *Stream[string], err error) {
+	inboxMsgs, err := h.bus.Recv(ctx, inbox)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs = NewStream[string](0)
+
+	go func() {
+		defer msgs.Close(nil)
+
+		for msg := range inboxMsgs {
+			if err := msgs.Send(ctx, msg); err != nil {
+				return
+			}
+		}
+	}()
+
+	return msgs, nil
 }
 
 func NewHttpSignalServiceImpl(bus Bus[string]) *HttpSignalServiceImpl {
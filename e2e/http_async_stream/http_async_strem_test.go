@@ -54,7 +54,7 @@ func TestStreamWithAsync(t *testing.T) {
 		msgs, err := client.Recv(ctx, "inbox")
 		assert.NoError(t, err)
 
-		msg := <-msgs
+		msg := <-msgs.Items()
 		assert.Equal(t, "Hello", msg)
 	}()
 
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"sync"
+
+	"compiler.ella.to/e2e/imports/common"
+)
+
+type TeamServiceImpl struct {
+	mu    sync.Mutex
+	teams map[string]*Team
+}
+
+var _ HttpTeamService = (*TeamServiceImpl)(nil)
+
+func NewTeamService() *TeamServiceImpl {
+	return &TeamServiceImpl{
+		teams: make(map[string]*Team),
+	}
+}
+
+func (s *TeamServiceImpl) Create(ctx context.Context, team *Team) (created *Team, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.teams[team.Name] = team
+	return team, nil
+}
+
+func (s *TeamServiceImpl) Get(ctx context.Context, name string) (team *Team, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	team, ok := s.teams[name]
+	if !ok {
+		return nil, ErrTeamNotFound
+	}
+	return team, nil
+}
+
+func (s *TeamServiceImpl) Owner(ctx context.Context, name string) (owner *common.User, err error) {
+	team, err := s.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if team.Owner == nil {
+		return nil, common.ErrUserNotFound
+	}
+	return team.Owner, nil
+}
+
+func (s *TeamServiceImpl) Members(ctx context.Context, name string, role *common.Role) (members []*common.User, err error) {
+	team, err := s.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, member := range team.Members {
+		if role == nil || member.Role == *role {
+			members = append(members, member)
+		}
+	}
+	return members, nil
+}
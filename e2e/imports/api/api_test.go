@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"compiler.ella.to/e2e/imports/common"
+	"compiler.ella.to/e2e/imports/geo"
+)
+
+func TestImportedModels(t *testing.T) {
+	server := httptest.NewServer(CreateTeamServiceServer(NewTeamService()))
+	defer server.Close()
+
+	client := CreateHttpTeamServiceClient(server.URL, nil)
+	ctx := context.Background()
+
+	owner := &common.User{
+		Name: "ella",
+		Role: common.Role_Admin,
+		Address: &common.Address{
+			City:     "Toronto",
+			Location: &geo.Point{Lat: 43.65, Lng: -79.38},
+		},
+	}
+
+	created, err := client.Create(ctx, &Team{
+		CreatedBy: "admin",
+		Name:      "compiler",
+		Owner:     owner,
+		Members:   []*common.User{owner, {Name: "bob", Role: common.Role_Member}},
+		Roles:     map[string]common.Role{"ella": common.Role_Admin},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", created.CreatedBy)
+
+	got, err := client.Owner(ctx, "compiler")
+	assert.NoError(t, err)
+	assert.Equal(t, owner, got)
+
+	role := common.Role_Member
+	members, err := client.Members(ctx, "compiler", &role)
+	assert.NoError(t, err)
+	assert.Len(t, members, 1)
+	assert.Equal(t, "bob", members[0].Name)
+
+	_, err = client.Owner(ctx, "unknown")
+	assert.True(t, errors.Is(err, ErrTeamNotFound))
+}
+
+func TestImportedModelsValidation(t *testing.T) {
+	server := httptest.NewServer(CreateTeamServiceServer(NewTeamService()))
+	defer server.Close()
+
+	client := CreateHttpTeamServiceClient(server.URL, nil)
+
+	_, err := client.Create(context.Background(), &Team{
+		Name: "compiler",
+		Owner: &common.User{
+			Address: &common.Address{
+				City:     "Toronto",
+				Location: &geo.Point{Lat: 100},
+			},
+		},
+	})
+
+	var validationErr Error
+	assert.True(t, errors.As(err, &validationErr))
+	assert.ElementsMatch(t, []FieldError{
+		{Field: "team.owner.address.location.lat", Message: "must be less than or equal to 90"},
+		{Field: "team.owner.name", Message: "is required"},
+	}, validationErr.Fields)
+}
+
+func TestImportedErrors(t *testing.T) {
+	server := httptest.NewServer(CreateTeamServiceServer(NewTeamService()))
+	defer server.Close()
+
+	client := CreateHttpTeamServiceClient(server.URL, nil)
+	ctx := context.Background()
+
+	_, err := client.Create(ctx, &Team{Name: "compiler"})
+	assert.NoError(t, err)
+
+	_, err = client.Owner(ctx, "compiler")
+
+	var respErr Error
+	assert.True(t, errors.As(err, &respErr))
+	assert.Equal(t, common.ErrUserNotFound.Code, respErr.Code)
+	assert.True(t, errors.Is(err, common.ErrUserNotFound))
+	assert.False(t, errors.Is(err, ErrTeamNotFound))
+}
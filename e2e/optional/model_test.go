@@ -0,0 +1,49 @@
+package optional
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptionalModelFields(t *testing.T) {
+	base := Base{Name: "ella"}
+	data, err := json.Marshal(base)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name":"ella"}`, string(data))
+
+	base.Nickname = ptr("")
+	data, err = json.Marshal(base)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name":"ella","nickname":""}`, string(data))
+
+	// Profile redefines Nickname as a required field
+	profile := Profile{Name: "ella", Nickname: "el"}
+	data, err = json.Marshal(profile)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name":"ella","nickname":"el"}`, string(data))
+
+	var decoded Profile
+	assert.NoError(t, json.Unmarshal([]byte(`{"name":"ella","nickname":"el","age":0,"status":"active"}`), &decoded))
+	assert.Equal(t, ptr[uint8](0), decoded.Age)
+	assert.Equal(t, ptr(Status_Active), decoded.Status)
+	assert.Nil(t, decoded.Tags)
+	assert.Nil(t, decoded.Friend)
+}
+
+func TestOptionalModelFieldsValidate(t *testing.T) {
+	profile := Profile{Name: "ella"}
+	assert.NoError(t, profile.Validate())
+
+	profile.Age = ptr[uint8](121)
+	profile.Tags = []string{}
+
+	var validationErr Error
+	assert.True(t, errors.As(profile.Validate(), &validationErr))
+	assert.Equal(t, []FieldError{
+		{Field: "age", Message: "must be less than or equal to 120"},
+		{Field: "tags", Message: "length must be at least 1"},
+	}, validationErr.Fields)
+}
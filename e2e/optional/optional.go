@@ -0,0 +1,33 @@
+package optional
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type PeopleServiceImpl struct{}
+
+var _ HttpPeopleService = (*PeopleServiceImpl)(nil)
+
+func (s *PeopleServiceImpl) List(ctx context.Context, limit *int32, status *Status, since *time.Time, tags []string) (query string, err error) {
+	return fmt.Sprintf("%s|%s|%s|%v", format(limit), format(status), format(since), tags), nil
+}
+
+func (s *PeopleServiceImpl) Update(ctx context.Context, id int64, name *string, age *uint8, person *Person) (result string, err error) {
+	result = fmt.Sprintf("%d|%s|%s", id, format(name), format(age))
+	if person != nil {
+		result += "|" + person.Name
+	}
+	return result, nil
+}
+
+func format[T any](value *T) string {
+	if value == nil {
+		return "nil"
+	}
+	if t, ok := any(value).(*time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+	return fmt.Sprint(*value)
+}
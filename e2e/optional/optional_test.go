@@ -0,0 +1,54 @@
+package optional
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+func TestOptionalArgs(t *testing.T) {
+	server := httptest.NewServer(CreatePeopleServiceServer(&PeopleServiceImpl{}))
+	defer server.Close()
+
+	client := CreateHttpPeopleServiceClient(server.URL, nil)
+	ctx := context.Background()
+
+	query, err := client.List(ctx, nil, nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "nil|nil|nil|[]", query)
+
+	since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	query, err = client.List(ctx, ptr[int32](0), ptr(Status_Active), &since, []string{"a"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0|active|2024-01-02T03:04:05Z|[a]", query)
+
+	result, err := client.Update(ctx, 1, nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "1|nil|nil", result)
+
+	result, err = client.Update(ctx, 1, ptr(""), ptr[uint8](0), &Person{Name: "ella"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1||0|ella", result)
+}
+
+func TestOptionalArgsMissingFromBody(t *testing.T) {
+	server := httptest.NewServer(CreatePeopleServiceServer(&PeopleServiceImpl{}))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+PathHttpPeopleServiceUpdateMethod, "application/json", strings.NewReader(`{"id": 2, "age": 30}`))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"result":"2|nil|30"}`, string(body))
+}
@@ -0,0 +1,33 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type SearchServiceImpl struct{}
+
+var _ HttpSearchService = (*SearchServiceImpl)(nil)
+
+func (s *SearchServiceImpl) Search(
+	ctx context.Context,
+	term string,
+	ids []int32,
+	limit uint16,
+	score float32,
+	status Status,
+	after time.Time,
+	filter *Filter,
+	raw []byte,
+) (query string, err error) {
+	query = fmt.Sprintf("%s|%v|%d|%g|%s|%s|%s", term, ids, limit, score, status, after.Format(time.RFC3339), raw)
+	if filter != nil {
+		query += fmt.Sprintf("|%s|%v", filter.Name, filter.Statuses)
+		if filter.Created != nil {
+			query += "|" + filter.Created.From.Format(time.RFC3339)
+		}
+	}
+
+	return query, nil
+}
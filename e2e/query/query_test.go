@@ -0,0 +1,61 @@
+package query
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryCodec(t *testing.T) {
+	server := httptest.NewServer(CreateSearchServiceServer(&SearchServiceImpl{}))
+	defer server.Close()
+
+	client := CreateHttpSearchServiceClient(server.URL, nil)
+
+	after := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	query, err := client.Search(
+		context.Background(),
+		"a+b c&d%",
+		[]int32{1, 2, 3},
+		10,
+		0.5,
+		Status_Archived,
+		after,
+		&Filter{
+			Name:     "ella",
+			Statuses: []Status{Status_Active, Status_Archived},
+			Created:  &Range{From: after},
+		},
+		[]byte("raw"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "a+b c&d%|[1 2 3]|10|0.5|archived|2024-01-02T03:04:05Z|raw|ella|[active archived]|2024-01-02T03:04:05Z", query)
+
+	query, err = client.Search(context.Background(), "", nil, 0, 0, 0, time.Time{}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "|[]|0|0||0001-01-01T00:00:00Z|", query)
+}
+
+func TestQueryCodecRawRequest(t *testing.T) {
+	server := httptest.NewServer(CreateSearchServiceServer(&SearchServiceImpl{}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + PathHttpSearchServiceSearchMethod + "?term=x&ids=4&ids=5&status=active&filter.name=f&filter.statuses=archived")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"query":"x|[4 5]|0|0|active|0001-01-01T00:00:00Z||f|[archived]"}`, string(body))
+
+	resp, err = http.Get(server.URL + PathHttpSearchServiceSearchMethod + "?limit=-1")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
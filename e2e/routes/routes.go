@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+)
+
+type UserServiceImpl struct {
+	names map[int64]string
+}
+
+var _ HttpUserService = (*UserServiceImpl)(nil)
+
+func (s *UserServiceImpl) Ping(ctx context.Context) (pong string, err error) {
+	return "pong", nil
+}
+
+func (s *UserServiceImpl) GetUser(ctx context.Context, id int64) (name string, err error) {
+	name, ok := s.names[id]
+	if !ok {
+		return "", ErrUserNotFound.WithMsg("user %d not found", id)
+	}
+
+	return name, nil
+}
+
+func (s *UserServiceImpl) UpdateUser(ctx context.Context, id int64, name string) (updated string, err error) {
+	s.names[id] = name
+	return name, nil
+}
+
+func (s *UserServiceImpl) GetFile(ctx context.Context, id int64, path string, download bool) (content string, err error) {
+	return fmt.Sprintf("%d:%s:%t", id, path, download), nil
+}
+
+func (s *UserServiceImpl) SetEmotion(ctx context.Context, emotion Emotion, reason string) (result string, err error) {
+	return emotion.String() + ":" + reason, nil
+}
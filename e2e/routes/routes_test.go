@@ -0,0 +1,76 @@
+package routes
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHttpRoutes(t *testing.T) {
+	server := httptest.NewServer(CreateUserServiceServer(&UserServiceImpl{names: map[int64]string{}}))
+	defer server.Close()
+
+	client := CreateHttpUserServiceClient(server.URL, nil)
+	ctx := context.Background()
+
+	pong, err := client.Ping(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "pong", pong)
+
+	_, err = client.GetUser(ctx, 1)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	name, err := client.UpdateUser(ctx, 1, "ella")
+	assert.NoError(t, err)
+	assert.Equal(t, "ella", name)
+
+	name, err = client.GetUser(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "ella", name)
+
+	content, err := client.GetFile(ctx, 2, "docs/a b.txt", true)
+	assert.NoError(t, err)
+	assert.Equal(t, "2:docs/a b.txt:true", content)
+
+	result, err := client.SetEmotion(ctx, Emotion_Happy, "sunny")
+	assert.NoError(t, err)
+	assert.Equal(t, "happy:sunny", result)
+}
+
+func TestHttpRoutesRawRequests(t *testing.T) {
+	server := httptest.NewServer(CreateUserServiceServer(&UserServiceImpl{names: map[int64]string{1: "ella"}}))
+	defer server.Close()
+
+	testCases := []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{http.MethodGet, "/api/users/1", http.StatusOK, `{"name":"ella"}`},
+		{http.MethodGet, "/api/users/abc", http.StatusBadRequest, `{"code":-12,"message":"invalid arguments"}`},
+		{http.MethodDelete, "/api/users/1", http.StatusMethodNotAllowed, ``},
+		{http.MethodGet, "/api/users/1/files/a/b.txt?download=false", http.StatusOK, `{"content":"1:a/b.txt:false"}`},
+		{http.MethodGet, "/ella/http/UserService/Ping", http.StatusNotFound, ``},
+	}
+
+	for _, tc := range testCases {
+		req, err := http.NewRequest(tc.method, server.URL+tc.path, nil)
+		assert.NoError(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Equal(t, tc.status, resp.StatusCode, tc.path)
+		if tc.body != "" {
+			assert.JSONEq(t, tc.body, string(body), tc.path)
+		}
+	}
+}
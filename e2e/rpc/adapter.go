@@ -2,6 +2,7 @@ package rpc
 
 import (
 	"context"
+	"fmt"
 	"sync"
 )
 
@@ -25,70 +26,131 @@ func (a *AdapterMsg) Reply(data []byte) error {
 	return a.replyFn(data)
 }
 
+// receiver is registered for a topic, its messages are received one at a time
+type receiver struct {
+	msgs chan *AdapterMsg
+	done chan struct{}
+}
+
+func (r *receiver) deliver(ctx context.Context, msg *AdapterMsg) error {
+	select {
+	case r.msgs <- msg:
+		return nil
+	case <-r.done:
+		return fmt.Errorf("receiver of %s is drained", msg.topic)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 type Adapter struct {
 	mux    sync.Mutex
-	topics map[string]chan *AdapterMsg
+	topics map[string][]*receiver
 }
 
-var _ rpcAdaptor = (*Adapter)(nil)
+var _ rpcEventAdaptor = (*Adapter)(nil)
 
 func (a *Adapter) Register(topic string, recv recvFunc) (drain func(), err error) {
-	topicChannel := a.getTopicChannel(topic)
+	r := &receiver{
+		msgs: make(chan *AdapterMsg, 10),
+		done: make(chan struct{}),
+	}
+
+	a.mux.Lock()
+	a.topics[topic] = append(a.topics[topic], r)
+	a.mux.Unlock()
 
 	go func() {
-		for msg := range topicChannel {
-			recv(msg)
+		for {
+			select {
+			case msg := <-r.msgs:
+				recv(msg)
+			case <-r.done:
+				return
+			}
 		}
 	}()
 
+	var once sync.Once
+
 	return func() {
-		a.mux.Lock()
-		defer a.mux.Unlock()
+		once.Do(func() {
+			a.mux.Lock()
+			defer a.mux.Unlock()
+
+			receivers := a.topics[topic]
+			for i, other := range receivers {
+				if other == r {
+					a.topics[topic] = append(receivers[:i:i], receivers[i+1:]...)
+					break
+				}
+			}
+			if len(a.topics[topic]) == 0 {
+				delete(a.topics, topic)
+			}
 
-		close(topicChannel)
-		delete(a.topics, topic)
+			close(r.done)
+		})
 	}, nil
 }
 
+// Send delivers the data to the first receiver of the topic and waits for its reply
 func (a *Adapter) Send(ctx context.Context, topic string, data []byte) ([]byte, error) {
-	resp := make(chan *AdapterMsg, 1)
+	receivers := a.receivers(topic)
+	if len(receivers) == 0 {
+		return nil, fmt.Errorf("no receiver is registered for %s", topic)
+	}
 
-	topicChannel := a.getTopicChannel(topic)
-	topicChannel <- &AdapterMsg{
+	resp := make(chan []byte, 1)
+
+	err := receivers[0].deliver(ctx, &AdapterMsg{
 		topic: topic,
 		data:  data,
 		replyFn: func(data []byte) error {
-			resp <- &AdapterMsg{
-				topic: topic,
-				data:  data,
-			}
+			resp <- data
 			return nil
 		},
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
-	case msg := <-resp:
-		return msg.data, nil
+	case data := <-resp:
+		return data, nil
+	}
+}
+
+// Publish delivers the data to all the receivers of the topic, replies are ignored
+func (a *Adapter) Publish(ctx context.Context, topic string, data []byte) error {
+	for _, r := range a.receivers(topic) {
+		err := r.deliver(ctx, &AdapterMsg{
+			topic: topic,
+			data:  data,
+			replyFn: func(data []byte) error {
+				return nil
+			},
+		})
+		// receivers which are drained meanwhile are skipped
+		if err != nil && ctx.Err() != nil {
+			return err
+		}
 	}
+
+	return nil
 }
 
-func (a *Adapter) getTopicChannel(name string) chan *AdapterMsg {
+func (a *Adapter) receivers(topic string) []*receiver {
 	a.mux.Lock()
 	defer a.mux.Unlock()
 
-	if ch, ok := a.topics[name]; ok {
-		return ch
-	}
-
-	ch := make(chan *AdapterMsg, 10)
-	a.topics[name] = ch
-	return ch
+	return a.topics[topic]
 }
 
 func NewMemoryAdapter() *Adapter {
 	return &Adapter{
-		topics: make(map[string]chan *AdapterMsg),
+		topics: make(map[string][]*receiver),
 	}
 }
@@ -1,8 +1,21 @@
 package rpc
 
-import "context"
+import (
+	"context"
+	"sync/atomic"
+)
 
 type RpcGreetingServiceImpl struct {
+	// waited receives the error of the context once Wait is cancelled
+	waited chan error
+	// watched receives the error of the context once Watch is stopped
+	watched chan error
+	// counted is the number of numbers which are sent by Count
+	counted atomic.Int64
+	// entered receives the id of each Block call once it is started
+	entered chan string
+	// release unblocks one Block call
+	release chan struct{}
 }
 
 var _ RpcGreetingService = (*RpcGreetingServiceImpl)(nil)
@@ -10,3 +23,73 @@ var _ RpcGreetingService = (*RpcGreetingServiceImpl)(nil)
 func (s *RpcGreetingServiceImpl) SayHello(ctx context.Context, name string) (string, error) {
 	return "Hello " + name, nil
 }
+
+func (s *RpcGreetingServiceImpl) Metadata(ctx context.Context, key string) (string, bool, error) {
+	md, _ := GetCtxRpcMetadata(ctx)
+	_, hasDeadline := ctx.Deadline()
+	return md[key], hasDeadline, nil
+}
+
+func (s *RpcGreetingServiceImpl) Wait(ctx context.Context) error {
+	<-ctx.Done()
+	s.waited <- ctx.Err()
+	return ctx.Err()
+}
+
+// Count sends the numbers from 1 to the given number, numbers more than 10
+// are not sent and the stream is ended with ErrCountLimit
+func (s *RpcGreetingServiceImpl) Count(ctx context.Context, to int64) (*Stream[int64], error) {
+	results := NewStream[int64](0)
+
+	go func() {
+		for i := int64(1); i <= to; i++ {
+			if i > 10 {
+				results.Close(ErrCountLimit)
+				return
+			}
+
+			if err := results.Send(ctx, i); err != nil {
+				results.Close(err)
+				return
+			}
+			s.counted.Add(1)
+		}
+
+		results.Close(nil)
+	}()
+
+	return results, nil
+}
+
+// Watch sends ticks until the stream is stopped
+func (s *RpcGreetingServiceImpl) Watch(ctx context.Context) (*Stream[int64], error) {
+	results := NewStream[int64](0)
+
+	go func() {
+		for i := int64(1); ; i++ {
+			if err := results.Send(ctx, i); err != nil {
+				s.watched <- ctx.Err()
+				results.Close(err)
+				return
+			}
+		}
+	}()
+
+	return results, nil
+}
+
+// Block waits until it is released
+func (s *RpcGreetingServiceImpl) Block(ctx context.Context, id string) error {
+	s.entered <- id
+
+	select {
+	case <-s.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *RpcGreetingServiceImpl) Panic(ctx context.Context) error {
+	panic("something went wrong")
+}
@@ -2,7 +2,11 @@ package rpc
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -20,3 +24,530 @@ func TestRpcCall(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "Hello World", resp)
 }
+
+func TestRpcMetadata(t *testing.T) {
+	adapter := NewMemoryAdapter()
+
+	done, err := StartRpcGreetingServiceServer(&RpcGreetingServiceImpl{}, adapter)
+	assert.NoError(t, err)
+	defer done()
+
+	client := CreateRpcGreetingServiceClient(adapter)
+
+	value, hasDeadline, err := client.Metadata(context.Background(), "request-id")
+	assert.NoError(t, err)
+	assert.Equal(t, "", value)
+	assert.False(t, hasDeadline)
+
+	ctx := CreateCtxRpcMetadata(context.Background(), RpcMetadata{"request-id": "1", "auth": "token"})
+	ctx = CreateCtxRpcMetadata(ctx, RpcMetadata{"request-id": "2"})
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	value, hasDeadline, err = client.Metadata(ctx, "request-id")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", value)
+	assert.True(t, hasDeadline)
+
+	value, _, err = client.Metadata(ctx, "auth")
+	assert.NoError(t, err)
+	assert.Equal(t, "token", value)
+}
+
+func TestRpcDeadline(t *testing.T) {
+	adapter := NewMemoryAdapter()
+
+	service := &RpcGreetingServiceImpl{waited: make(chan error, 1)}
+
+	done, err := StartRpcGreetingServiceServer(service, adapter)
+	assert.NoError(t, err)
+	defer done()
+
+	client := CreateRpcGreetingServiceClient(adapter)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = client.Wait(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	select {
+	case err := <-service.waited:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("server's context is not cancelled once the deadline passed")
+	}
+}
+
+func TestRpcStream(t *testing.T) {
+	adapter := NewMemoryAdapter()
+
+	done, err := StartRpcGreetingServiceServer(&RpcGreetingServiceImpl{}, adapter)
+	assert.NoError(t, err)
+	defer done()
+
+	client := CreateRpcGreetingServiceClient(adapter)
+
+	numbers, err := client.Count(context.Background(), 5)
+	assert.NoError(t, err)
+
+	var results []int64
+	for number := range numbers.Items() {
+		results = append(results, number)
+	}
+
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, results)
+	assert.NoError(t, numbers.Err())
+}
+
+func TestRpcStreamError(t *testing.T) {
+	adapter := NewMemoryAdapter()
+
+	done, err := StartRpcGreetingServiceServer(&RpcGreetingServiceImpl{}, adapter)
+	assert.NoError(t, err)
+	defer done()
+
+	client := CreateRpcGreetingServiceClient(adapter)
+
+	numbers, err := client.Count(context.Background(), 20)
+	assert.NoError(t, err)
+
+	var count int
+	for range numbers.Items() {
+		count++
+	}
+
+	assert.Equal(t, 10, count)
+	assert.ErrorIs(t, numbers.Err(), ErrCountLimit)
+}
+
+func TestRpcStreamFlowControl(t *testing.T) {
+	adapter := NewMemoryAdapter()
+
+	service := &RpcGreetingServiceImpl{}
+
+	done, err := StartRpcGreetingServiceServer(service, adapter)
+	assert.NoError(t, err)
+	defer done()
+
+	client := CreateRpcGreetingServiceClient(adapter)
+
+	numbers, err := client.Count(context.Background(), 10)
+	assert.NoError(t, err)
+
+	// nothing is received, so the server can't get far ahead of the client
+	time.Sleep(100 * time.Millisecond)
+	assert.LessOrEqual(t, service.counted.Load(), int64(2))
+
+	var count int
+	for range numbers.Items() {
+		count++
+	}
+
+	assert.Equal(t, 10, count)
+	assert.Equal(t, int64(10), service.counted.Load())
+}
+
+func TestRpcStreamCancel(t *testing.T) {
+	adapter := NewMemoryAdapter()
+
+	service := &RpcGreetingServiceImpl{watched: make(chan error, 1)}
+
+	done, err := StartRpcGreetingServiceServer(service, adapter)
+	assert.NoError(t, err)
+	defer done()
+
+	client := CreateRpcGreetingServiceClient(adapter)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ticks, err := client.Watch(ctx)
+	assert.NoError(t, err)
+
+	for i := int64(1); i <= 3; i++ {
+		assert.Equal(t, i, <-ticks.Items())
+	}
+
+	cancel()
+
+	for range ticks.Items() {
+	}
+	assert.ErrorIs(t, ticks.Err(), context.Canceled)
+
+	select {
+	case err := <-service.watched:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("server's context is not cancelled once the client gave up")
+	}
+}
+
+func TestRpcStreamDeadline(t *testing.T) {
+	adapter := NewMemoryAdapter()
+
+	service := &RpcGreetingServiceImpl{watched: make(chan error, 1)}
+
+	done, err := StartRpcGreetingServiceServer(service, adapter)
+	assert.NoError(t, err)
+	defer done()
+
+	client := CreateRpcGreetingServiceClient(adapter)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	ticks, err := client.Watch(ctx)
+	assert.NoError(t, err)
+
+	for range ticks.Items() {
+	}
+	assert.ErrorIs(t, ticks.Err(), context.DeadlineExceeded)
+
+	select {
+	case err := <-service.watched:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("server's context is not cancelled once the deadline passed")
+	}
+}
+
+type userCreated struct {
+	subscriber string
+	user       *User
+	source     *string
+	requestID  string
+}
+
+func TestRpcEvents(t *testing.T) {
+	adapter := NewMemoryAdapter()
+
+	events := CreateRpcUserServiceEvents(adapter)
+
+	received := make(chan userCreated, 10)
+
+	subscribe := func(subscriber string) func() {
+		unsubscribe, err := events.SubscribeUserCreated(func(ctx context.Context, user *User, source *string) {
+			md, _ := GetCtxRpcMetadata(ctx)
+			received <- userCreated{subscriber: subscriber, user: user, source: source, requestID: md["request-id"]}
+		})
+		assert.NoError(t, err)
+		return unsubscribe
+	}
+
+	unsubscribeMail := subscribe("mail")
+	defer unsubscribeMail()
+
+	unsubscribeAudit := subscribe("audit")
+
+	ctx := CreateCtxRpcMetadata(context.Background(), RpcMetadata{"request-id": "1"})
+	source := "signup"
+
+	err := events.PublishUserCreated(ctx, &User{Id: "1", Name: "ella"}, &source)
+	assert.NoError(t, err)
+
+	subscribers := make(map[string]userCreated)
+	for range 2 {
+		select {
+		case event := <-received:
+			subscribers[event.subscriber] = event
+		case <-time.After(time.Second):
+			t.Fatal("event is not delivered to all the subscribers")
+		}
+	}
+
+	for _, name := range []string{"mail", "audit"} {
+		event := subscribers[name]
+		assert.Equal(t, &User{Id: "1", Name: "ella"}, event.user)
+		assert.Equal(t, &source, event.source)
+		assert.Equal(t, "1", event.requestID)
+	}
+
+	unsubscribeAudit()
+
+	// invalid events are dropped by the subscribers
+	err = events.PublishUserCreated(context.Background(), &User{Id: "2"}, nil)
+	assert.NoError(t, err)
+
+	err = events.PublishUserCreated(context.Background(), &User{Id: "3", Name: "compiler"}, nil)
+	assert.NoError(t, err)
+
+	select {
+	case event := <-received:
+		assert.Equal(t, "mail", event.subscriber)
+		assert.Equal(t, "3", event.user.Id)
+		assert.Nil(t, event.source)
+	case <-time.After(time.Second):
+		t.Fatal("event is not delivered")
+	}
+
+	select {
+	case event := <-received:
+		t.Fatalf("unexpected event %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func newBlockingService() *RpcGreetingServiceImpl {
+	return &RpcGreetingServiceImpl{
+		entered: make(chan string, 10),
+		release: make(chan struct{}),
+	}
+}
+
+func waitEntered(t *testing.T, service *RpcGreetingServiceImpl) string {
+	t.Helper()
+
+	select {
+	case id := <-service.entered:
+		return id
+	case <-time.After(time.Second):
+		t.Fatal("call is not started")
+		return ""
+	}
+}
+
+func assertNotEntered(t *testing.T, service *RpcGreetingServiceImpl) {
+	t.Helper()
+
+	select {
+	case id := <-service.entered:
+		t.Fatalf("call %s is started", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRpcServerConcurrentCalls(t *testing.T) {
+	adapter := NewMemoryAdapter()
+
+	service := newBlockingService()
+
+	done, err := StartRpcGreetingServiceServer(service, adapter)
+	assert.NoError(t, err)
+	defer done()
+
+	client := CreateRpcGreetingServiceClient(adapter)
+
+	results := make(chan error, 2)
+	for _, id := range []string{"1", "2"} {
+		go func() {
+			results <- client.Block(context.Background(), id)
+		}()
+	}
+
+	// a slow call doesn't block the other calls of the same method
+	waitEntered(t, service)
+	waitEntered(t, service)
+
+	close(service.release)
+	assert.NoError(t, <-results)
+	assert.NoError(t, <-results)
+}
+
+func TestRpcServerMaxConcurrency(t *testing.T) {
+	adapter := NewMemoryAdapter()
+
+	service := newBlockingService()
+
+	done, err := StartRpcGreetingServiceServer(
+		service,
+		adapter,
+		WithRpcMethodMaxConcurrency("Block", 1),
+		WithRpcServerMaxQueue(1),
+	)
+	assert.NoError(t, err)
+	defer done()
+
+	client := CreateRpcGreetingServiceClient(adapter)
+
+	results := make(chan error, 2)
+
+	go func() {
+		results <- client.Block(context.Background(), "1")
+	}()
+	assert.Equal(t, "1", waitEntered(t, service))
+
+	go func() {
+		results <- client.Block(context.Background(), "2")
+	}()
+	assertNotEntered(t, service)
+
+	// the queue is full
+	err = client.Block(context.Background(), "3")
+	assert.ErrorIs(t, err, ErrServerBusy)
+
+	// other methods are not limited
+	value, err := client.SayHello(context.Background(), "World")
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello World", value)
+
+	service.release <- struct{}{}
+	assert.NoError(t, <-results)
+
+	assert.Equal(t, "2", waitEntered(t, service))
+	service.release <- struct{}{}
+	assert.NoError(t, <-results)
+}
+
+func TestRpcServerDefaultMaxQueue(t *testing.T) {
+	adapter := NewMemoryAdapter()
+
+	service := &RpcGreetingServiceImpl{
+		entered: make(chan string, 2*rpcServerDefaultMaxQueue),
+		release: make(chan struct{}),
+	}
+
+	done, err := StartRpcGreetingServiceServer(
+		service,
+		adapter,
+		WithRpcMethodMaxConcurrency("Block", 1),
+	)
+	assert.NoError(t, err)
+	defer done()
+
+	client := CreateRpcGreetingServiceClient(adapter)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	results := make(chan error, rpcServerDefaultMaxQueue+1)
+	for i := 0; i <= rpcServerDefaultMaxQueue; i++ {
+		go func() {
+			results <- client.Block(ctx, strconv.Itoa(i))
+		}()
+	}
+
+	// the queue is bounded even though WithRpcServerMaxQueue is not used
+	assert.Eventually(t, func() bool {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		return errors.Is(client.Block(ctx, "busy"), ErrServerBusy)
+	}, time.Second, 10*time.Millisecond)
+
+	close(service.release)
+	for i := 0; i <= rpcServerDefaultMaxQueue; i++ {
+		assert.NoError(t, <-results)
+	}
+}
+
+func TestRpcServerServiceMaxConcurrency(t *testing.T) {
+	adapter := NewMemoryAdapter()
+
+	service := newBlockingService()
+
+	done, err := StartRpcGreetingServiceServer(
+		service,
+		adapter,
+		WithRpcServerMaxConcurrency(1),
+		WithRpcServerMaxQueue(0),
+	)
+	assert.NoError(t, err)
+	defer done()
+
+	client := CreateRpcGreetingServiceClient(adapter)
+
+	result := make(chan error, 1)
+	go func() {
+		result <- client.Block(context.Background(), "1")
+	}()
+	waitEntered(t, service)
+
+	// the limit is shared by all the methods of the service
+	_, err = client.SayHello(context.Background(), "World")
+	assert.ErrorIs(t, err, ErrServerBusy)
+
+	service.release <- struct{}{}
+	assert.NoError(t, <-result)
+
+	_, err = client.SayHello(context.Background(), "World")
+	assert.NoError(t, err)
+}
+
+// failingAdapter fails to register the topic, the other topics are registered
+type failingAdapter struct {
+	*Adapter
+	topic string
+}
+
+func (a failingAdapter) Register(topic string, recv recvFunc) (drain func(), err error) {
+	if topic == a.topic {
+		return nil, fmt.Errorf("failed to register %s", topic)
+	}
+	return a.Adapter.Register(topic, recv)
+}
+
+func TestRpcServerRegisterError(t *testing.T) {
+	adapter := NewMemoryAdapter()
+
+	drain, err := StartRpcGreetingServiceServer(&RpcGreetingServiceImpl{}, failingAdapter{
+		Adapter: adapter,
+		topic:   TopicRpcGreetingServiceBlockMethod,
+	})
+	assert.EqualError(t, err, "failed to register "+TopicRpcGreetingServiceBlockMethod)
+	assert.Nil(t, drain)
+
+	// the methods which were registered before the error are unregistered
+	assert.Empty(t, adapter.topics)
+
+	_, err = CreateRpcGreetingServiceClient(adapter).SayHello(context.Background(), "World")
+	assert.Error(t, err)
+}
+
+func TestRpcServerPanic(t *testing.T) {
+	adapter := NewMemoryAdapter()
+
+	done, err := StartRpcGreetingServiceServer(&RpcGreetingServiceImpl{}, adapter)
+	assert.NoError(t, err)
+	defer done()
+
+	client := CreateRpcGreetingServiceClient(adapter)
+
+	err = client.Panic(context.Background())
+	assert.ErrorIs(t, err, ErrInternal)
+
+	value, err := client.SayHello(context.Background(), "World")
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello World", value)
+}
+
+func TestRpcServerDrain(t *testing.T) {
+	adapter := NewMemoryAdapter()
+
+	service := newBlockingService()
+
+	drain, err := StartRpcGreetingServiceServer(service, adapter)
+	assert.NoError(t, err)
+
+	client := CreateRpcGreetingServiceClient(adapter)
+
+	result := make(chan error, 1)
+	go func() {
+		result <- client.Block(context.Background(), "1")
+	}()
+	waitEntered(t, service)
+
+	drained := make(chan struct{})
+	go func() {
+		drain()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		t.Fatal("drain doesn't wait for the in-flight calls")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// new calls are not received anymore
+	_, err = client.SayHello(context.Background(), "World")
+	assert.Error(t, err)
+
+	service.release <- struct{}{}
+	assert.NoError(t, <-result)
+
+	select {
+	case <-drained:
+	case <-time.After(time.Second):
+		t.Fatal("drain is not finished once the in-flight calls are done")
+	}
+}
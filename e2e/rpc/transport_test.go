@@ -0,0 +1,275 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// connectUnix returns the adaptors of two processes which are connected over a unix domain socket
+func connectUnix(t *testing.T) (server, client *RpcConnAdaptor) {
+	t.Helper()
+
+	l, err := net.Listen("unix", filepath.Join(t.TempDir(), "rpc.sock"))
+	assert.NoError(t, err)
+
+	server = CreateRpcConnAdaptor()
+	go server.Serve(l)
+	t.Cleanup(func() { server.Close() })
+
+	client = CreateRpcConnAdaptor()
+	assert.NoError(t, client.Dial(context.Background(), "unix", l.Addr().String()))
+	t.Cleanup(func() { client.Close() })
+
+	return server, client
+}
+
+// connectHttp returns the adaptors of two processes which are connected over http
+func connectHttp(t *testing.T) (server, client *RpcConnAdaptor) {
+	t.Helper()
+
+	server = CreateRpcConnAdaptor()
+	t.Cleanup(func() { server.Close() })
+
+	mux := http.NewServeMux()
+	mux.Handle("/rpc", server)
+
+	httpServer := httptest.NewServer(mux)
+	t.Cleanup(httpServer.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	client = CreateRpcConnAdaptor()
+	assert.NoError(t, client.DialHttp(ctx, http.DefaultClient, httpServer.URL+"/rpc"))
+	t.Cleanup(func() { client.Close() })
+
+	return server, client
+}
+
+func TestRpcTransports(t *testing.T) {
+	transports := map[string]func(t *testing.T) (server, client *RpcConnAdaptor){
+		"unix": connectUnix,
+		"http": connectHttp,
+	}
+
+	for name, connect := range transports {
+		t.Run(name, func(t *testing.T) {
+			server, client := connect(t)
+
+			done, err := StartRpcGreetingServiceServer(&RpcGreetingServiceImpl{}, server)
+			assert.NoError(t, err)
+			defer done()
+
+			greeting := CreateRpcGreetingServiceClient(client)
+
+			// the topics are registered asynchronously by the server
+			assert.Eventually(t, func() bool {
+				return peerHasTopic(client, TopicRpcGreetingServiceSayHelloMethod)
+			}, time.Second, 10*time.Millisecond)
+
+			// calls share the same connection
+			results := make(chan string, 10)
+			for i := 0; i < 10; i++ {
+				go func() {
+					value, err := greeting.SayHello(context.Background(), "World")
+					assert.NoError(t, err)
+					results <- value
+				}()
+			}
+			for i := 0; i < 10; i++ {
+				assert.Equal(t, "Hello World", <-results)
+			}
+
+			ctx := CreateCtxRpcMetadata(context.Background(), RpcMetadata{"request-id": "1"})
+			value, _, err := greeting.Metadata(ctx, "request-id")
+			assert.NoError(t, err)
+			assert.Equal(t, "1", value)
+
+			// stream items are sent to the reply topic registered by the client
+			numbers, err := greeting.Count(context.Background(), 20)
+			assert.NoError(t, err)
+
+			var count int
+			for range numbers.Items() {
+				count++
+			}
+			assert.Equal(t, 10, count)
+			assert.ErrorIs(t, numbers.Err(), ErrCountLimit)
+
+			err = greeting.Panic(context.Background())
+			assert.ErrorIs(t, err, ErrInternal)
+
+			// events are published to the subscribers of both processes
+			received := make(chan string, 2)
+			for name, adaptor := range map[string]*RpcConnAdaptor{"server": server, "client": client} {
+				unsubscribe, err := CreateRpcUserServiceEvents(adaptor).SubscribeUserCreated(func(ctx context.Context, user *User, source *string) {
+					received <- name + ":" + user.Id
+				})
+				assert.NoError(t, err)
+				defer unsubscribe()
+			}
+
+			// the subscription of the server is sent to the client asynchronously
+			assert.Eventually(t, func() bool {
+				return peerHasTopic(client, TopicEventUserServiceUserCreated)
+			}, time.Second, 10*time.Millisecond)
+
+			err = CreateRpcUserServiceEvents(client).PublishUserCreated(context.Background(), &User{Id: "1", Name: "ella"}, nil)
+			assert.NoError(t, err)
+
+			var events []string
+			for i := 0; i < 2; i++ {
+				select {
+				case event := <-received:
+					events = append(events, event)
+				case <-time.After(time.Second):
+					t.Fatal("event is not delivered to all the subscribers")
+				}
+			}
+			assert.ElementsMatch(t, []string{"server:1", "client:1"}, events)
+		})
+	}
+}
+
+// peerHasTopic returns true once one of the peers of the adaptor has registered the topic
+func peerHasTopic(adaptor *RpcConnAdaptor, topic string) bool {
+	adaptor.mux.Lock()
+	defer adaptor.mux.Unlock()
+
+	for conn := range adaptor.conns {
+		if conn.hasTopic(topic) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestRpcTransportNoReceiver(t *testing.T) {
+	_, client := connectUnix(t)
+
+	greeting := CreateRpcGreetingServiceClient(client)
+
+	_, err := greeting.SayHello(context.Background(), "World")
+	assert.ErrorIs(t, err, ErrServiceMethodNotFound)
+}
+
+func TestRpcTransportClosedConnection(t *testing.T) {
+	server, client := connectUnix(t)
+
+	service := newBlockingService()
+
+	done, err := StartRpcGreetingServiceServer(service, server)
+	assert.NoError(t, err)
+	defer done()
+
+	greeting := CreateRpcGreetingServiceClient(client)
+
+	assert.Eventually(t, func() bool {
+		return peerHasTopic(client, TopicRpcGreetingServiceBlockMethod)
+	}, time.Second, 10*time.Millisecond)
+
+	result := make(chan error, 1)
+	go func() {
+		result <- greeting.Block(context.Background(), "1")
+	}()
+	waitEntered(t, service)
+
+	// pending calls fail once the connection is closed
+	server.Close()
+
+	select {
+	case err := <-result:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("pending call is not failed")
+	}
+
+	close(service.release)
+}
+
+func TestRpcTransportReceiverMaxQueue(t *testing.T) {
+	adaptor := CreateRpcConnAdaptor()
+	defer adaptor.Close()
+
+	const topic = "ella.test.slow"
+
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+
+	drain, err := adaptor.Register(topic, func(msg rpcMsg) {
+		entered <- struct{}{}
+		<-release
+	})
+	assert.NoError(t, err)
+	defer drain()
+	defer close(release)
+
+	ctx := context.Background()
+
+	assert.NoError(t, adaptor.Publish(ctx, topic, nil))
+	<-entered
+
+	for i := 0; i < rpcConnReceiverMaxQueue; i++ {
+		assert.NoError(t, adaptor.Publish(ctx, topic, nil))
+	}
+
+	// the queue of the receiver is full
+	assert.ErrorIs(t, adaptor.Publish(ctx, topic, nil), ErrServerBusy)
+
+	_, err = adaptor.Send(ctx, topic, nil)
+	assert.ErrorIs(t, err, ErrServerBusy)
+}
+
+func TestRpcTransportTopicsOrder(t *testing.T) {
+	l, err := net.Listen("unix", filepath.Join(t.TempDir(), "rpc.sock"))
+	assert.NoError(t, err)
+
+	server := CreateRpcConnAdaptor()
+	go server.Serve(l)
+	defer server.Close()
+
+	const topic = "ella.test.flaky"
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			drain, err := server.Register(topic, func(msg rpcMsg) {})
+			assert.NoError(t, err)
+			drain()
+		}
+	}()
+
+	// clients which connect while the topic is registered and unregistered
+	// must not end up with a stale topic
+	var clients []*RpcConnAdaptor
+	for i := 0; i < 20; i++ {
+		client := CreateRpcConnAdaptor()
+		defer client.Close()
+
+		assert.NoError(t, client.Dial(context.Background(), "unix", l.Addr().String()))
+		clients = append(clients, client)
+	}
+
+	<-done
+
+	drain, err := server.Register("ella.test.sync", func(msg rpcMsg) {})
+	assert.NoError(t, err)
+	defer drain()
+
+	for _, client := range clients {
+		assert.Eventually(t, func() bool {
+			return peerHasTopic(client, "ella.test.sync")
+		}, time.Second, 10*time.Millisecond)
+
+		assert.False(t, peerHasTopic(client, topic))
+	}
+}
@@ -3,6 +3,7 @@ package stream
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 )
 
@@ -11,23 +12,56 @@ type HttpEventServiceImpl struct {
 
 var _ HttpEventService = (*HttpEventServiceImpl)(nil)
 
-func (s *HttpEventServiceImpl) GetRandomValues(ctx context.Context) (values <-chan string, err error) {
-	results := make(chan string, 10)
+func (s *HttpEventServiceImpl) GetRandomValues(ctx context.Context) (values *Stream[string], err error) {
+	results := NewStream[string](10)
 
 	go func() {
-		defer close(results)
+		defer results.Close(nil)
 		count := 0
 
 		for {
-			select {
-			case <-ctx.Done():
+			if err := results.Send(ctx, fmt.Sprintf("Hello %d", count)); err != nil {
 				return
-			case results <- fmt.Sprintf("Hello %d", count):
-				count++
-				time.Sleep(500 * time.Millisecond)
 			}
+			count++
+			time.Sleep(500 * time.Millisecond)
 		}
 	}()
 
 	return results, nil
 }
+
+// Count sends the numbers from 1 to the given number, the id of each event is the
+// same as the number, so the stream resumes from the last event received by the client.
+// Numbers more than 10 are not sent and the stream is ended with ErrCountLimit.
+func (s *HttpEventServiceImpl) Count(ctx context.Context, to int64) (numbers *Stream[int64], err error) {
+	var from int64
+	if lastID, ok := GetCtxLastEventID(ctx); ok {
+		from, err = strconv.ParseInt(lastID, 10, 64)
+		if err != nil {
+			return nil, ErrInvalidLastEventId
+		}
+	}
+
+	results := NewStream[int64](0).WithEventID(func(number int64) string {
+		return strconv.FormatInt(number, 10)
+	})
+
+	go func() {
+		for i := from + 1; i <= to; i++ {
+			if i > 10 {
+				results.Close(ErrCountLimit)
+				return
+			}
+
+			if err := results.Send(ctx, i); err != nil {
+				results.Close(err)
+				return
+			}
+		}
+
+		results.Close(nil)
+	}()
+
+	return results, nil
+}
@@ -2,8 +2,11 @@ package stream
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 )
@@ -28,7 +31,453 @@ func TestHttpStream(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	for result := range results {
+	for result := range results.Items() {
 		println(result)
 	}
 }
+
+func TestHttpStreamCallTimeout(t *testing.T) {
+	server := httptest.NewServer(
+		CreateEventServiceServer(&HttpEventServiceImpl{}),
+	)
+	defer server.Close()
+
+	client := CreateHttpEventServiceClient(server.URL, &http.Client{})
+
+	ctx := CreateCtxHttpCallOptions(context.Background(), WithHttpCallTimeout(1200*time.Millisecond))
+
+	results, err := client.GetRandomValues(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	for range results.Items() {
+		count++
+	}
+
+	if count == 0 || count > 4 {
+		t.Fatalf("expected stream to be closed by the call timeout, got %d values", count)
+	}
+}
+
+// abortingWriter drops the connection once the limit of writes is reached
+type abortingWriter struct {
+	http.ResponseWriter
+	limit int
+}
+
+func (w *abortingWriter) Write(b []byte) (int, error) {
+	w.limit--
+	if w.limit < 0 {
+		panic(http.ErrAbortHandler)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *abortingWriter) Flush() {
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func TestHttpStreamResume(t *testing.T) {
+	handler := CreateEventServiceServer(&HttpEventServiceImpl{})
+
+	var mu sync.Mutex
+	var lastEventIDs []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+		first := len(lastEventIDs) == 1
+		mu.Unlock()
+
+		if first {
+			w = &abortingWriter{ResponseWriter: w, limit: 2}
+		}
+
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := CreateHttpEventServiceClient(server.URL, &http.Client{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	numbers, err := client.Count(ctx, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var results []int64
+	for number := range numbers.Items() {
+		results = append(results, number)
+	}
+
+	if fmt.Sprint(results) != "[1 2 3 4 5]" {
+		t.Fatalf("expected all numbers exactly once, got %v", results)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if fmt.Sprint(lastEventIDs) != "[ 2]" {
+		t.Fatalf("expected the stream to resume from event 2, got %q", lastEventIDs)
+	}
+}
+
+func TestHttpStreamResumeAfterServerError(t *testing.T) {
+	handler := CreateEventServiceServer(&HttpEventServiceImpl{})
+
+	var mu sync.Mutex
+	var lastEventIDs []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+		attempt := len(lastEventIDs)
+		mu.Unlock()
+
+		switch attempt {
+		case 1:
+			w = &abortingWriter{ResponseWriter: w, limit: 2}
+		case 2:
+			httpResponseError(w, ErrServerBusy)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := CreateHttpEventServiceClient(server.URL, &http.Client{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	numbers, err := client.Count(ctx, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var results []int64
+	for number := range numbers.Items() {
+		results = append(results, number)
+	}
+
+	if err := numbers.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(results) != "[1 2 3 4 5]" {
+		t.Fatalf("expected all numbers exactly once, got %v", results)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if fmt.Sprint(lastEventIDs) != "[ 2 2]" {
+		t.Fatalf("expected the stream to be retried after the server error, got %q", lastEventIDs)
+	}
+}
+
+func TestHttpStreamDropWithoutEventIds(t *testing.T) {
+	handler := CreateEventServiceServer(&HttpEventServiceImpl{})
+
+	var mu sync.Mutex
+	calls := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+
+		handler.ServeHTTP(&abortingWriter{ResponseWriter: w, limit: 2}, r)
+	}))
+	defer server.Close()
+
+	client := CreateHttpEventServiceClient(server.URL, &http.Client{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	values, err := client.GetRandomValues(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var results []string
+	for value := range values.Items() {
+		results = append(results, value)
+	}
+
+	if fmt.Sprint(results) != "[Hello 0 Hello 1]" {
+		t.Fatalf("expected the values sent before the connection is dropped, got %v", results)
+	}
+
+	if err := values.Err(); err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the stream to end with the connection error, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if calls != 1 {
+		t.Fatalf("expected the stream without event ids not to be called again, got %d calls", calls)
+	}
+}
+
+func TestHttpStreamResumeRejectedWithoutBody(t *testing.T) {
+	handler := CreateEventServiceServer(&HttpEventServiceImpl{})
+
+	var mu sync.Mutex
+	calls := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		attempt := calls
+		mu.Unlock()
+
+		if attempt > 1 {
+			// e.g. a proxy which rejects the call without an error body
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		handler.ServeHTTP(&abortingWriter{ResponseWriter: w, limit: 2}, r)
+	}))
+	defer server.Close()
+
+	client := CreateHttpEventServiceClient(server.URL, &http.Client{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	numbers, err := client.Count(ctx, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range numbers.Items() {
+	}
+
+	var respErr Error
+	if !errors.As(numbers.Err(), &respErr) || respErr.HTTPStatus != http.StatusBadRequest {
+		t.Fatalf("expected the stream to end with the bad request error, got %v", numbers.Err())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if calls != 2 {
+		t.Fatalf("expected the rejected reconnect not to be retried, got %d calls", calls)
+	}
+}
+
+// replayingEventService resumes the count from the last event received by the client
+// instead of the next one, so the last event is sent twice
+type replayingEventService struct {
+	HttpEventServiceImpl
+}
+
+func (s *replayingEventService) Count(ctx context.Context, to int64) (*Stream[int64], error) {
+	from := int64(1)
+	if lastID, ok := GetCtxLastEventID(ctx); ok {
+		if _, err := fmt.Sscanf(lastID, "number-%d", &from); err != nil {
+			return nil, ErrInvalidLastEventId
+		}
+	}
+
+	results := NewStream[int64](0).WithEventID(func(number int64) string {
+		return fmt.Sprintf("number-%d", number)
+	})
+
+	go func() {
+		for i := from; i <= to; i++ {
+			if err := results.Send(ctx, i); err != nil {
+				results.Close(err)
+				return
+			}
+		}
+
+		results.Close(nil)
+	}()
+
+	return results, nil
+}
+
+func TestHttpStreamResumeReplay(t *testing.T) {
+	handler := CreateEventServiceServer(&replayingEventService{})
+
+	var mu sync.Mutex
+	var lastEventIDs []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+		first := len(lastEventIDs) == 1
+		mu.Unlock()
+
+		if first {
+			w = &abortingWriter{ResponseWriter: w, limit: 2}
+		}
+
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := CreateHttpEventServiceClient(server.URL, &http.Client{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	numbers, err := client.Count(ctx, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var results []int64
+	for number := range numbers.Items() {
+		results = append(results, number)
+	}
+
+	if err := numbers.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(results) != "[1 2 3 4 5]" {
+		t.Fatalf("expected the replayed number to be dropped, got %v", results)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if fmt.Sprint(lastEventIDs) != "[ number-2]" {
+		t.Fatalf("expected the stream to resume from number-2, got %q", lastEventIDs)
+	}
+}
+
+func TestHttpStreamError(t *testing.T) {
+	server := httptest.NewServer(CreateEventServiceServer(&HttpEventServiceImpl{}))
+	defer server.Close()
+
+	client := CreateHttpEventServiceClient(server.URL, &http.Client{})
+
+	numbers, err := client.Count(context.Background(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range numbers.Items() {
+	}
+
+	if err := numbers.Err(); err != nil {
+		t.Fatalf("expected stream to be ended normally, got %v", err)
+	}
+
+	numbers, err = client.Count(context.Background(), 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	for range numbers.Items() {
+		count++
+	}
+
+	if count != 10 {
+		t.Fatalf("expected 10 numbers before the error, got %d", count)
+	}
+
+	err = numbers.Err()
+	if !errors.Is(err, ErrCountLimit) {
+		t.Fatalf("expected ErrCountLimit, got %v", err)
+	}
+
+	var httpErr Error
+	if !errors.As(err, &httpErr) || httpErr.HTTPStatus != http.StatusBadRequest || httpErr.Message != ErrCountLimit.Message {
+		t.Fatalf("expected the error to keep its status and message, got %#v", err)
+	}
+}
+
+func TestHttpStreamCancelErr(t *testing.T) {
+	server := httptest.NewServer(CreateEventServiceServer(&HttpEventServiceImpl{}))
+	defer server.Close()
+
+	client := CreateHttpEventServiceClient(server.URL, &http.Client{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	values, err := client.GetRandomValues(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	<-values.Items()
+	cancel()
+
+	for range values.Items() {
+	}
+
+	if err := values.Err(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestHttpStreamNilStream(t *testing.T) {
+	server := httptest.NewServer(CreateEventServiceServer(
+		&HttpEventServiceImpl{},
+		WithHttpServerInterceptors(func(ctx context.Context, info HttpServerCallInfo, args any, next HttpServerHandler) (any, error) {
+			return nil, nil
+		}),
+	))
+	defer server.Close()
+
+	client := CreateHttpEventServiceClient(server.URL, &http.Client{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	_, err := client.Count(ctx, 3)
+	if !errors.Is(err, ErrInternal) {
+		t.Fatalf("expected ErrInternal, got %v", err)
+	}
+}
+
+func TestStreamClose(t *testing.T) {
+	stream := NewStream[int64](1)
+
+	if err := stream.Send(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := make(chan error, 1)
+	go func() {
+		sent <- stream.Send(context.Background(), 2)
+	}()
+
+	if err := stream.Err(); err != nil {
+		t.Fatalf("expected no error before the stream is closed, got %v", err)
+	}
+
+	stream.Close(ErrCountLimit)
+	stream.Close(nil)
+
+	if err := <-sent; err == nil {
+		t.Fatal("expected pending send to fail once the stream is closed")
+	}
+
+	var results []int64
+	for number := range stream.Items() {
+		results = append(results, number)
+	}
+
+	if fmt.Sprint(results) != "[1]" {
+		t.Fatalf("expected the buffered item to be received, got %v", results)
+	}
+
+	if err := stream.Err(); !errors.Is(err, ErrCountLimit) {
+		t.Fatalf("expected ErrCountLimit, got %v", err)
+	}
+}
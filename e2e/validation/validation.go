@@ -0,0 +1,15 @@
+package validation
+
+import (
+	"context"
+)
+
+type UserServiceImpl struct {
+}
+
+var _ HttpUserService = (*UserServiceImpl)(nil)
+var _ RpcUserService = (*UserServiceImpl)(nil)
+
+func (s *UserServiceImpl) Create(ctx context.Context, user *User) (id string, err error) {
+	return "id-" + user.Name, nil
+}
@@ -0,0 +1,104 @@
+package validation
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validUser() *User {
+	return &User{
+		Name:    "ella",
+		Age:     20,
+		Tags:    []string{"#go"},
+		Address: &Address{Street: "main"},
+	}
+}
+
+func TestModelValidate(t *testing.T) {
+	assert.NoError(t, validUser().Validate())
+
+	user := &User{
+		Name:    "Ella1",
+		Age:     10,
+		Tags:    []string{"#a", "b", "#c"},
+		Avatar:  bytes.Repeat([]byte("a"), 1025),
+		Address: &Address{},
+	}
+
+	err := user.Validate()
+	assert.ErrorIs(t, err, ErrValidation)
+
+	var validationErr Error
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []FieldError{
+		{Field: "name", Message: "must match pattern ^[a-z]+$"},
+		{Field: "age", Message: "must be greater than or equal to 18"},
+		{Field: "tags", Message: "length must be at most 2"},
+		{Field: "avatar", Message: "size must be at most 1024 bytes"},
+		{Field: "address.street", Message: "is required"},
+	}, validationErr.Fields)
+}
+
+func TestHttpValidation(t *testing.T) {
+	server := httptest.NewServer(CreateUserServiceServer(&UserServiceImpl{}))
+	defer server.Close()
+
+	var status int
+	client := CreateHttpUserServiceClient(server.URL, &http.Client{}, WithHttpClientInterceptors(
+		func(info HttpClientCallInfo, req *http.Request, next HttpClientRoundTrip) (*http.Response, error) {
+			resp, err := next(req)
+			if err == nil {
+				status = resp.StatusCode
+			}
+			return resp, err
+		},
+	))
+
+	id, err := client.Create(context.Background(), validUser())
+	assert.NoError(t, err)
+	assert.Equal(t, "id-ella", id)
+
+	user := validUser()
+	user.Name = ""
+
+	_, err = client.Create(context.Background(), user)
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	var validationErr Error
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []FieldError{{Field: "user.name", Message: "is required"}}, validationErr.Fields)
+}
+
+func TestRpcValidation(t *testing.T) {
+	adapter := NewMemoryAdapter()
+
+	drain, err := StartRpcUserServiceServer(&UserServiceImpl{}, adapter)
+	assert.NoError(t, err)
+	defer drain()
+
+	client := CreateRpcUserServiceClient(adapter)
+
+	_, err = client.Create(context.Background(), validUser())
+	assert.NoError(t, err)
+
+	user := validUser()
+	user.Age = 121
+	user.Address = &Address{}
+
+	_, err = client.Create(context.Background(), user)
+	assert.ErrorIs(t, err, ErrValidation)
+
+	var validationErr Error
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []FieldError{
+		{Field: "user.age", Message: "must be less than or equal to 120"},
+		{Field: "user.address.street", Message: "is required"},
+	}, validationErr.Fields)
+}
@@ -0,0 +1,59 @@
+package websocket
+
+import (
+	"context"
+	"strings"
+)
+
+type ChatServiceImpl struct{}
+
+var _ HttpChatService = (*ChatServiceImpl)(nil)
+
+func (s *ChatServiceImpl) Chat(ctx context.Context, room string, msgs *Stream[*Message]) (replies *Stream[*Reply], err error) {
+	if room == "closed" {
+		return nil, ErrRoomClosed
+	}
+
+	out := NewStream[*Reply](0)
+
+	go func() {
+		for msg := range msgs.Items() {
+			if msg.Text == "bye" {
+				out.Close(ErrRoomClosed)
+				return
+			}
+
+			if err := out.Send(ctx, &Reply{Room: room, Text: strings.ToUpper(msg.Text)}); err != nil {
+				out.Close(err)
+				return
+			}
+		}
+
+		out.Close(msgs.Err())
+	}()
+
+	return out, nil
+}
+
+// Count sends the running total of the received numbers, and
+// sends the final total once more after the client ends the stream
+func (s *ChatServiceImpl) Count(ctx context.Context, msgs *Stream[int64]) (totals *Stream[int64], err error) {
+	out := NewStream[int64](0)
+
+	go func() {
+		var total int64
+		for msg := range msgs.Items() {
+			total += msg
+			if err := out.Send(ctx, total); err != nil {
+				out.Close(err)
+				return
+			}
+		}
+
+		// the error of ctx ends the stream if the final total can't be sent
+		err := out.Send(ctx, total)
+		out.Close(err)
+	}()
+
+	return out, nil
+}
@@ -0,0 +1,189 @@
+package websocket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func collect[T any](t *testing.T, stream *Stream[T]) []T {
+	t.Helper()
+
+	var items []T
+	for {
+		select {
+		case item, ok := <-stream.Items():
+			if !ok {
+				return items
+			}
+			items = append(items, item)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for stream to be closed")
+		}
+	}
+}
+
+func TestWebSocketChat(t *testing.T) {
+	server := httptest.NewServer(CreateChatServiceServer(&ChatServiceImpl{}))
+	defer server.Close()
+
+	client := CreateHttpChatServiceClient(server.URL, nil)
+
+	msgs := NewStream[*Message](0)
+	replies, err := client.Chat(context.Background(), "general", msgs)
+	assert.NoError(t, err)
+
+	for _, text := range []string{"hello", "world"} {
+		msgs.Send(context.Background(), &Message{Text: text})
+		reply := <-replies.Items()
+		assert.Equal(t, &Reply{Room: "general", Text: strings.ToUpper(text)}, reply)
+	}
+	msgs.Close(nil)
+
+	assert.Empty(t, collect(t, replies))
+	assert.NoError(t, replies.Err())
+}
+
+func TestWebSocketStreamError(t *testing.T) {
+	server := httptest.NewServer(CreateChatServiceServer(&ChatServiceImpl{}))
+	defer server.Close()
+
+	client := CreateHttpChatServiceClient(server.URL, nil)
+
+	msgs := NewStream[*Message](2)
+	msgs.Send(context.Background(), &Message{Text: "hello"})
+	msgs.Send(context.Background(), &Message{Text: "bye"})
+
+	replies, err := client.Chat(context.Background(), "general", msgs)
+	assert.NoError(t, err)
+	assert.Len(t, collect(t, replies), 1)
+	assert.ErrorIs(t, replies.Err(), ErrRoomClosed)
+}
+
+func TestWebSocketEndOfInput(t *testing.T) {
+	server := httptest.NewServer(CreateChatServiceServer(&ChatServiceImpl{}))
+	defer server.Close()
+
+	client := CreateHttpChatServiceClient(server.URL, nil)
+
+	msgs := NewStream[int64](3)
+	msgs.Send(context.Background(), 1)
+	msgs.Send(context.Background(), 2)
+	msgs.Send(context.Background(), 3)
+	msgs.Close(nil)
+
+	totals, err := client.Count(context.Background(), msgs)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 3, 6, 6}, collect(t, totals))
+}
+
+func TestWebSocketError(t *testing.T) {
+	server := httptest.NewServer(CreateChatServiceServer(&ChatServiceImpl{}))
+	defer server.Close()
+
+	client := CreateHttpChatServiceClient(server.URL, nil)
+
+	replies, err := client.Chat(context.Background(), "closed", NewStream[*Message](0))
+	assert.NoError(t, err)
+	assert.Empty(t, collect(t, replies))
+	assert.ErrorIs(t, replies.Err(), ErrRoomClosed)
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	var calls atomic.Int64
+
+	countCalls := WithHttpServerInterceptors(func(ctx context.Context, info HttpServerCallInfo, args any, next HttpServerHandler) (any, error) {
+		calls.Add(1)
+		return next(ctx, args)
+	})
+
+	withOrigin := func(origin string) HttpClientOption {
+		return WithHttpClientInterceptors(func(info HttpClientCallInfo, req *http.Request, next HttpClientRoundTrip) (*http.Response, error) {
+			req.Header.Set("Origin", origin)
+			return next(req)
+		})
+	}
+
+	chat := func(host string, origin string) error {
+		client := CreateHttpChatServiceClient(host, nil, withOrigin(origin))
+
+		msgs := NewStream[*Message](0)
+		replies, err := client.Chat(context.Background(), "general", msgs)
+		if err != nil {
+			return err
+		}
+		msgs.Close(nil)
+		collect(t, replies)
+		return replies.Err()
+	}
+
+	server := httptest.NewServer(CreateChatServiceServer(&ChatServiceImpl{}, countCalls))
+	defer server.Close()
+
+	assert.NoError(t, chat(server.URL, server.URL))
+	assert.ErrorIs(t, chat(server.URL, "https://evil.example.com"), ErrOriginNotAllowed)
+	assert.Equal(t, int64(1), calls.Load())
+
+	allowed := httptest.NewServer(CreateChatServiceServer(
+		&ChatServiceImpl{},
+		countCalls,
+		WithHttpServerAllowedOrigins("https://app.example.com"),
+	))
+	defer allowed.Close()
+
+	assert.NoError(t, chat(allowed.URL, "https://app.example.com"))
+	assert.ErrorIs(t, chat(allowed.URL, "https://evil.example.com"), ErrOriginNotAllowed)
+	assert.Equal(t, int64(2), calls.Load())
+}
+
+func TestWebSocketInvalidMessage(t *testing.T) {
+	server := httptest.NewServer(CreateChatServiceServer(&ChatServiceImpl{}))
+	defer server.Close()
+
+	client := CreateHttpChatServiceClient(server.URL, nil)
+
+	msgs := NewStream[*Message](1)
+	msgs.Send(context.Background(), &Message{})
+
+	replies, err := client.Chat(context.Background(), "general", msgs)
+	assert.NoError(t, err)
+	assert.Empty(t, collect(t, replies))
+	assert.Error(t, replies.Err())
+}
+
+func TestWebSocketCancel(t *testing.T) {
+	server := httptest.NewServer(CreateChatServiceServer(&ChatServiceImpl{}))
+	defer server.Close()
+
+	client := CreateHttpChatServiceClient(server.URL, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	msgs := NewStream[*Message](0)
+	replies, err := client.Chat(ctx, "general", msgs)
+	assert.NoError(t, err)
+
+	msgs.Send(context.Background(), &Message{Text: "hello"})
+	assert.Equal(t, "HELLO", (<-replies.Items()).Text)
+
+	cancel()
+	assert.Empty(t, collect(t, replies))
+	assert.ErrorIs(t, replies.Err(), context.Canceled)
+}
+
+func TestWebSocketUpgradeRequired(t *testing.T) {
+	server := httptest.NewServer(CreateChatServiceServer(&ChatServiceImpl{}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/ella/http/ChatService/rooms/general/chat")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusUpgradeRequired, resp.StatusCode)
+}
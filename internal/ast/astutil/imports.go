@@ -0,0 +1,137 @@
+package astutil
+
+import (
+	"strings"
+
+	"compiler.ella.to/internal/ast"
+	"compiler.ella.to/internal/token"
+)
+
+// QualifyName returns the name which is used to refer to a declaration of
+// the imported package, e.g. common.User
+func QualifyName(pkg, name string) string {
+	return pkg + "." + name
+}
+
+// SplitQualifiedName splits the qualified name into the package and the name,
+// pkg is empty if the name is not qualified
+func SplitQualifiedName(name string) (pkg string, rest string) {
+	idx := strings.LastIndex(name, ".")
+	if idx == -1 {
+		return "", name
+	}
+
+	return name[:idx], name[idx+1:]
+}
+
+// IsImported reports whether the declaration belongs to another package,
+// declarations can't have a qualified name unless they have been imported
+func IsImported(name string) bool {
+	pkg, _ := SplitQualifiedName(name)
+	return pkg != ""
+}
+
+func GetImports(node ast.Node) []*ast.Import {
+	return getContent[*ast.Import](node)
+}
+
+// GetLocalModels returns the models which are declared in the program itself,
+// imported models are only used for type lookups and are generated by their own package
+func GetLocalModels(node ast.Node) []*ast.Model {
+	var models []*ast.Model
+	for _, model := range GetModels(node) {
+		if !IsImported(model.Name.String()) {
+			models = append(models, model)
+		}
+	}
+	return models
+}
+
+// GetLocalEnums returns the enums which are declared in the program itself
+func GetLocalEnums(node ast.Node) []*ast.Enum {
+	var enums []*ast.Enum
+	for _, enum := range GetEnums(node) {
+		if !IsImported(enum.Name.String()) {
+			enums = append(enums, enum)
+		}
+	}
+	return enums
+}
+
+// ImportedStatements returns copies of the models and enums of the package, their names
+// and the types of the fields are qualified by the package name so they can be appended
+// to the program which imports the package. The declarations, which the package has
+// imported itself, are returned as they are already qualified.
+//
+// The package's program must be validated beforehand, as extends and constants used
+// in the options are expected to be resolved.
+func ImportedStatements(pkg string, prog *ast.Program) []ast.Statement {
+	var stmts []ast.Statement
+
+	for _, stmt := range prog.Statements {
+		switch stmt := stmt.(type) {
+		case *ast.Enum:
+			if IsImported(stmt.Name.String()) {
+				stmts = append(stmts, stmt)
+				continue
+			}
+
+			stmts = append(stmts, &ast.Enum{
+				Token: stmt.Token,
+				Name:  qualifyIdentifier(pkg, stmt.Name),
+				Size:  stmt.Size,
+				Sets:  stmt.Sets,
+			})
+		case *ast.Model:
+			if IsImported(stmt.Name.String()) {
+				stmts = append(stmts, stmt)
+				continue
+			}
+
+			fields := make(ast.Fields, 0, len(stmt.Fields))
+			for _, field := range stmt.Fields {
+				fields = append(fields, &ast.Field{
+					Name:     field.Name,
+					Type:     qualifyType(pkg, field.Type),
+					Optional: field.Optional,
+					Options:  field.Options,
+				})
+			}
+
+			// extends have already been merged into the fields
+			stmts = append(stmts, &ast.Model{
+				Token:  stmt.Token,
+				Name:   qualifyIdentifier(pkg, stmt.Name),
+				Fields: fields,
+			})
+		}
+	}
+
+	return stmts
+}
+
+func qualifyIdentifier(pkg string, ident *ast.Identifier) *ast.Identifier {
+	return &ast.Identifier{Token: qualifyToken(pkg, ident.Token)}
+}
+
+func qualifyToken(pkg string, tok *token.Token) *token.Token {
+	qualified := *tok
+	qualified.Literal = QualifyName(pkg, tok.Literal)
+	return &qualified
+}
+
+func qualifyType(pkg string, typ ast.Type) ast.Type {
+	switch typ := typ.(type) {
+	case *ast.CustomType:
+		if IsImported(typ.String()) {
+			return typ
+		}
+		return &ast.CustomType{Token: qualifyToken(pkg, typ.Token)}
+	case *ast.Array:
+		return &ast.Array{Token: typ.Token, Type: qualifyType(pkg, typ.Type)}
+	case *ast.Map:
+		return &ast.Map{Token: typ.Token, Key: qualifyType(pkg, typ.Key), Value: qualifyType(pkg, typ.Value)}
+	default:
+		return typ
+	}
+}
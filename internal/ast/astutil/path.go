@@ -0,0 +1,111 @@
+package astutil
+
+import (
+	"strings"
+
+	"compiler.ella.to/internal/ast"
+	"compiler.ella.to/pkg/strcase"
+)
+
+type ServiceOptions struct {
+	BasePath string
+}
+
+func ParseServiceOptions(options ast.Options) ServiceOptions {
+	var result ServiceOptions
+
+	for _, opt := range options {
+		switch strcase.ToPascal(opt.Name.Token.Literal) {
+		case "BasePath":
+			if v, ok := opt.Value.(*ast.ValueString); ok {
+				result.BasePath = v.Value
+			}
+		}
+	}
+
+	return result
+}
+
+// HttpBasePath returns the path which all http methods of the service are mounted
+// under, by default it is /ella/http/<Service>
+func HttpBasePath(service *ast.Service) string {
+	basePath := ParseServiceOptions(service.Options).BasePath
+	if basePath == "" {
+		basePath = "/ella/http/" + strcase.ToPascal(service.Name.String())
+	}
+
+	return strings.TrimSuffix(basePath, "/")
+}
+
+// HttpPath returns the full route of the http method, which might contain
+// placeholders such as /users/{id}, by default it is /ella/http/<Service>/<Method>
+func HttpPath(service *ast.Service, method *ast.Method) string {
+	path := ParseMethodOptions(method.Options).Path
+	if path == "" {
+		path = "/" + strcase.ToPascal(method.Name.String())
+	}
+
+	return HttpBasePath(service) + path
+}
+
+// HttpPathParam is a placeholder defined in http path, e.g. {id} or {path...}
+type HttpPathParam struct {
+	Name     string
+	Wildcard bool // matches the remaining segments of the path, e.g. {path...}
+}
+
+// ParseHttpPathParams returns all the placeholders defined in the path,
+// the syntax is the same as Go's http.ServeMux patterns
+func ParseHttpPathParams(path string) []HttpPathParam {
+	var params []HttpPathParam
+
+	for _, segment := range strings.Split(path, "/") {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+
+		name := strings.TrimSuffix(strings.TrimPrefix(segment, "{"), "}")
+		wildcard := strings.HasSuffix(name, "...")
+
+		params = append(params, HttpPathParam{
+			Name:     strings.TrimSuffix(name, "..."),
+			Wildcard: wildcard,
+		})
+	}
+
+	return params
+}
+
+// FindHttpPathParam returns the placeholder which is bound to the method's arg,
+// placeholders are matched with args regardless of their case, e.g. {Id} and {id}
+// are both bound to id arg
+func FindHttpPathParam(params []HttpPathParam, arg *ast.Arg) (HttpPathParam, bool) {
+	for _, param := range params {
+		if strcase.ToPascal(param.Name) == strcase.ToPascal(arg.Name.String()) {
+			return param, true
+		}
+	}
+
+	return HttpPathParam{}, false
+}
+
+// HttpMethod returns the http method which the method is served with, methods
+// with a stream arg are carried over WebSocket, so they are always GET
+func HttpMethod(method *ast.Method) string {
+	if StreamArg(method) != nil {
+		return "GET"
+	}
+
+	return ParseMethodOptions(method.Options).HttpMethod
+}
+
+// StreamArg returns the arg marked as stream, nil if the method has none
+func StreamArg(method *ast.Method) *ast.Arg {
+	for _, arg := range method.Args {
+		if arg.Stream {
+			return arg
+		}
+	}
+
+	return nil
+}
@@ -1,6 +1,8 @@
 package astutil
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 
 	"compiler.ella.to/internal/ast"
@@ -12,6 +14,7 @@ type MethodOptions struct {
 	ContentType   string // only used for Download methods or stream []byte
 	MaxUploadSize int64
 	RawControl    bool
+	Path          string // custom route of http method, relative to service's base path
 }
 
 func ParseMethodOptions(options ast.Options) MethodOptions {
@@ -43,6 +46,7 @@ func ParseMethodOptions(options ast.Options) MethodOptions {
 		ContentType:   castString(mapper["ContentType"], "application/octet-stream"),
 		MaxUploadSize: castInt64(mapper["MaxUploadSize"], 1*1024*1024),
 		RawControl:    castBool(mapper["RawControl"], false),
+		Path:          castString(mapper["Path"], ""),
 	}
 }
 
@@ -66,3 +70,167 @@ func castValue[T any](value any, defaultValue T) T {
 
 	return defaultValue
 }
+
+// FieldValidation holds the validation rules defined as field options, e.g.
+//
+//	model User {
+//		Name: string {
+//			Required
+//			MaxLen = 32
+//		}
+//	}
+//
+// Min and Max are kept as number literals so they can be used directly by the
+// code generators
+type FieldValidation struct {
+	Required bool
+	Min      string
+	Max      string
+	MinLen   *int64
+	MaxLen   *int64
+	Pattern  string
+	MaxSize  *int64
+}
+
+func (f FieldValidation) IsEmpty() bool {
+	return !f.Required && f.Min == "" && f.Max == "" && f.MinLen == nil && f.MaxLen == nil && f.Pattern == "" && f.MaxSize == nil
+}
+
+// The following messages are shared between all the code generators, so the same
+// validation error is produced by the server and the clients
+
+func (f FieldValidation) RequiredMsg() string {
+	return "is required"
+}
+
+func (f FieldValidation) MinMsg() string {
+	return "must be greater than or equal to " + f.Min
+}
+
+func (f FieldValidation) MaxMsg() string {
+	return "must be less than or equal to " + f.Max
+}
+
+func (f FieldValidation) MinLenMsg() string {
+	return fmt.Sprintf("length must be at least %d", *f.MinLen)
+}
+
+func (f FieldValidation) MaxLenMsg() string {
+	return fmt.Sprintf("length must be at most %d", *f.MaxLen)
+}
+
+func (f FieldValidation) PatternMsg() string {
+	return "must match pattern " + f.Pattern
+}
+
+func (f FieldValidation) MaxSizeMsg() string {
+	return fmt.Sprintf("size must be at most %d bytes", *f.MaxSize)
+}
+
+// ParseFieldValidation extracts the validation rules from field's options,
+// the options' values must be already validated and resolved by validator package
+func ParseFieldValidation(options ast.Options) FieldValidation {
+	var result FieldValidation
+
+	for _, opt := range options {
+		switch strcase.ToPascal(opt.Name.Token.Literal) {
+		case "Required":
+			if v, ok := opt.Value.(*ast.ValueBool); ok {
+				result.Required = v.Value
+			}
+		case "Min":
+			result.Min = NumberLiteral(opt.Value)
+		case "Max":
+			result.Max = NumberLiteral(opt.Value)
+		case "MinLen":
+			result.MinLen = intValue(opt.Value)
+		case "MaxLen":
+			result.MaxLen = intValue(opt.Value)
+		case "Pattern":
+			if v, ok := opt.Value.(*ast.ValueString); ok {
+				result.Pattern = v.Value
+			}
+		case "MaxSize":
+			result.MaxSize = intValue(opt.Value)
+		}
+	}
+
+	return result
+}
+
+// NumberLiteral converts numeric values into a literal which can be used in both
+// Go and Typescript, returns empty string if value is not a number
+func NumberLiteral(value ast.Value) string {
+	switch v := value.(type) {
+	case *ast.ValueInt:
+		return strconv.FormatInt(v.Value, 10)
+	case *ast.ValueUint:
+		return strconv.FormatUint(v.Value, 10)
+	case *ast.ValueFloat:
+		return strconv.FormatFloat(v.Value, 'g', -1, 64)
+	case *ast.ValueByteSize:
+		return strconv.FormatInt(v.Value*int64(v.Scale), 10)
+	case *ast.ValueDuration:
+		return strconv.FormatInt(v.Value*int64(v.Scale), 10)
+	default:
+		return ""
+	}
+}
+
+func intValue(value ast.Value) *int64 {
+	var result int64
+
+	switch v := value.(type) {
+	case *ast.ValueInt:
+		result = v.Value
+	case *ast.ValueByteSize:
+		result = v.Value * int64(v.Scale)
+	default:
+		return nil
+	}
+
+	return &result
+}
+
+// JsonFieldName returns the name of the model's field in json, it can be changed by
+// Json option, "-" means the field is not encoded
+func JsonFieldName(field *ast.Field) string {
+	name := strings.ToLower(strcase.ToSnake(field.Name.String()))
+
+	for _, opt := range field.Options {
+		if strings.ToLower(opt.Name.Token.Literal) != "json" {
+			continue
+		}
+
+		switch value := opt.Value.(type) {
+		case *ast.ValueString:
+			name = value.Value
+		case *ast.ValueBool:
+			if !value.Value {
+				name = "-"
+			}
+		}
+	}
+
+	return name
+}
+
+// JsonOmitEmpty returns true if the model's field is omitted from json when it is
+// empty, optional fields are always omitted
+func JsonOmitEmpty(field *ast.Field) bool {
+	if field.Optional {
+		return true
+	}
+
+	for _, opt := range field.Options {
+		if strings.ToLower(opt.Name.Token.Literal) != "jsonomitempty" {
+			continue
+		}
+
+		if value, ok := opt.Value.(*ast.ValueBool); ok && value.Value {
+			return true
+		}
+	}
+
+	return false
+}
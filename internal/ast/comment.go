@@ -0,0 +1,75 @@
+package ast
+
+import (
+	"strings"
+
+	"compiler.ella.to/internal/token"
+)
+
+// Comment is a single line comment starts with '#', the token's literal
+// is the content of the comment without '#'
+type Comment struct {
+	Token *token.Token `json:"token"`
+}
+
+var _ Node = (*Comment)(nil)
+
+func (c *Comment) TokenLiteral() string {
+	return c.Token.Literal
+}
+
+// Text returns the content of the comment without the leading and trailing spaces
+func (c *Comment) Text() string {
+	return strings.TrimSpace(c.Token.Literal)
+}
+
+func (c *Comment) String() string {
+	return "#" + strings.TrimRight(c.Token.Literal, " \t")
+}
+
+// Comments are the comments attached to a node
+//   - Top comments are written on their own lines right before the node
+//     and they are used as the node's documentation
+//   - Right comment is written at the end of the node's last line
+//   - End comments are written before the closing curly brace of the node's block
+type Comments struct {
+	Top   []*Comment `json:"top,omitempty"`
+	Right *Comment   `json:"right,omitempty"`
+	End   []*Comment `json:"end,omitempty"`
+}
+
+// Doc returns the lines of the node's documentation
+func (c Comments) Doc() []string {
+	lines := make([]string, 0, len(c.Top))
+	for _, comment := range c.Top {
+		lines = append(lines, comment.Text())
+	}
+	return lines
+}
+
+// writeTop writes top comments, each of them followed by a new line and the indentation
+func (c Comments) writeTop(sb *strings.Builder, indent string) {
+	for _, comment := range c.Top {
+		sb.WriteString(comment.String())
+		sb.WriteString("\n")
+		sb.WriteString(indent)
+	}
+}
+
+func (c Comments) writeRight(sb *strings.Builder) {
+	if c.Right == nil {
+		return
+	}
+
+	sb.WriteString(" ")
+	sb.WriteString(c.Right.String())
+}
+
+// writeEnd writes end comments, each of them on a new line with the indentation
+func (c Comments) writeEnd(sb *strings.Builder, indent string) {
+	for _, comment := range c.End {
+		sb.WriteString("\n")
+		sb.WriteString(indent)
+		sb.WriteString(comment.String())
+	}
+}
@@ -1,6 +1,8 @@
 package ast
 
 import (
+	"bytes"
+	"encoding/json"
 	"strings"
 
 	"compiler.ella.to/internal/token"
@@ -10,6 +12,8 @@ type Const struct {
 	Token *token.Token `json:"token"`
 	Name  *Identifier  `json:"name"`
 	Value Value        `json:"value"`
+
+	Comments Comments `json:"comments"`
 }
 
 var _ Statement = (*Const)(nil)
@@ -23,11 +27,64 @@ func (c *Const) TokenLiteral() string {
 func (c *Const) String() string {
 	var sb strings.Builder
 
+	c.Comments.writeTop(&sb, "")
 	sb.WriteString(c.TokenLiteral())
 	sb.WriteString(" ")
 	sb.WriteString(c.Name.String())
 	sb.WriteString(" = ")
 	sb.WriteString(c.Value.String())
+	c.Comments.writeRight(&sb)
 
 	return sb.String()
 }
+
+func (c *Const) MarshalText() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteString(`{"token":`)
+	tok, err := json.Marshal(c.Token)
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(tok)
+	buf.WriteString(`,"name":`)
+	name, err := json.Marshal(c.Name)
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(name)
+	buf.WriteString(`,"value":`)
+	val, err := marshalTextValue(c.Value)
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(val)
+	buf.WriteString(`,"comments":`)
+	comments, err := json.Marshal(c.Comments)
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(comments)
+	buf.WriteString(`}`)
+
+	return buf.Bytes(), nil
+}
+
+func (c *Const) UnmarshalText(text []byte) error {
+	results := struct {
+		Token    *token.Token    `json:"token"`
+		Name     *Identifier     `json:"name"`
+		Value    json.RawMessage `json:"value"`
+		Comments Comments        `json:"comments"`
+	}{}
+
+	if err := json.Unmarshal(text, &results); err != nil {
+		return err
+	}
+
+	c.Token = results.Token
+	c.Name = results.Name
+	c.Comments = results.Comments
+
+	return unmarshalTextValue(results.Value, &c.Value)
+}
@@ -11,6 +11,8 @@ type EnumSet struct {
 	Name    *Identifier `json:"name"`
 	Value   *ValueInt   `json:"value"`
 	Defined bool        `json:"defined"`
+
+	Comments Comments `json:"comments"`
 }
 
 var _ Node = (*EnumSet)(nil)
@@ -22,11 +24,13 @@ func (e *EnumSet) TokenLiteral() string {
 func (e *EnumSet) String() string {
 	var sb strings.Builder
 
+	e.Comments.writeTop(&sb, "\t")
 	sb.WriteString(e.Name.String())
 	if e.Defined {
 		sb.WriteString(" = ")
 		sb.WriteString(fmt.Sprintf("%d", e.Value.Value))
 	}
+	e.Comments.writeRight(&sb)
 
 	return sb.String()
 }
@@ -36,6 +40,8 @@ type Enum struct {
 	Name  *Identifier
 	Size  int // 8, 16, 32, 64 selected by compiler based on the largest and smallest values
 	Sets  []*EnumSet
+
+	Comments Comments
 }
 
 var _ Statement = (*Enum)(nil)
@@ -49,6 +55,7 @@ func (e *Enum) TokenLiteral() string {
 func (e *Enum) String() string {
 	var sb strings.Builder
 
+	e.Comments.writeTop(&sb, "")
 	sb.WriteString("enum ")
 	sb.WriteString(e.Name.String())
 	sb.WriteString(" {")
@@ -58,11 +65,14 @@ func (e *Enum) String() string {
 		sb.WriteString(set.String())
 	}
 
-	if len(e.Sets) > 0 {
+	e.Comments.writeEnd(&sb, "\t")
+
+	if len(e.Sets) > 0 || len(e.Comments.End) > 0 {
 		sb.WriteString("\n")
 	}
 
 	sb.WriteString("}")
+	e.Comments.writeRight(&sb)
 
 	return sb.String()
 }
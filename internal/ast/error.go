@@ -13,6 +13,7 @@ type CustomError struct {
 	Code       int64
 	HttpStatus int
 	Msg        *ValueString
+	Comments   Comments
 }
 
 var _ Statement = (*Enum)(nil)
@@ -26,6 +27,7 @@ func (c *CustomError) TokenLiteral() string {
 func (c *CustomError) String() string {
 	var sb strings.Builder
 
+	c.Comments.writeTop(&sb, "")
 	sb.WriteString("error ")
 	sb.WriteString(c.Name.String())
 	sb.WriteString(" {")
@@ -40,6 +42,7 @@ func (c *CustomError) String() string {
 	sb.WriteString(c.Msg.String())
 
 	sb.WriteString(" }")
+	c.Comments.writeRight(&sb)
 
 	return sb.String()
 }
@@ -15,3 +15,9 @@ func (i *Identifier) TokenLiteral() string {
 func (i *Identifier) String() string {
 	return i.Token.Literal
 }
+
+// newIdentifier creates an identifier from its literal, which is used
+// when the node is unmarshaled and the original token is not available
+func newIdentifier(literal string) *Identifier {
+	return &Identifier{Token: &token.Token{Type: token.Identifier, Literal: literal}}
+}
@@ -0,0 +1,36 @@
+package ast
+
+import (
+	"strings"
+
+	"compiler.ella.to/internal/token"
+)
+
+// Import brings the models and enums of another schema package into scope,
+// they are referenced by the package name, e.g. common.User
+type Import struct {
+	Token *token.Token `json:"token"`
+	Path  *ValueString `json:"path"`
+
+	Comments Comments `json:"comments"`
+}
+
+var _ Statement = (*Import)(nil)
+
+func (i *Import) statementLiteral() {}
+
+func (i *Import) TokenLiteral() string {
+	return i.Token.Literal
+}
+
+func (i *Import) String() string {
+	var sb strings.Builder
+
+	i.Comments.writeTop(&sb, "")
+	sb.WriteString(i.TokenLiteral())
+	sb.WriteString(" ")
+	sb.WriteString(i.Path.String())
+	i.Comments.writeRight(&sb)
+
+	return sb.String()
+}
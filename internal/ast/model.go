@@ -9,9 +9,11 @@ import (
 )
 
 type Field struct {
-	Name    *Identifier `json:"name"`
-	Type    Type        `json:"type"`
-	Options Options     `json:"options"`
+	Name     *Identifier `json:"name"`
+	Type     Type        `json:"type"`
+	Optional bool        `json:"optional"`
+	Options  Options     `json:"options"`
+	Comments Comments    `json:"comments"`
 }
 
 var _ Node = (*Field)(nil)
@@ -23,10 +25,15 @@ func (f *Field) TokenLiteral() string {
 func (f *Field) String() string {
 	var sb strings.Builder
 
+	f.Comments.writeTop(&sb, "\t")
 	sb.WriteString(f.Name.String())
+	if f.Optional {
+		sb.WriteString("?")
+	}
 	sb.WriteString(": ")
 	sb.WriteString(f.Type.String())
-	sb.WriteString(f.Options.String(2))
+	sb.WriteString(f.Options.block(2, f.Comments.End))
+	f.Comments.writeRight(&sb)
 
 	return sb.String()
 }
@@ -42,12 +49,24 @@ func (f *Field) MarshalText() ([]byte, error) {
 		return nil, err
 	}
 	buf.Write(typ)
+	buf.WriteString(`,"optional":`)
+	if f.Optional {
+		buf.WriteString(`true`)
+	} else {
+		buf.WriteString(`false`)
+	}
 	buf.WriteString(`,"options":`)
 	opt, err := json.Marshal(f.Options)
 	if err != nil {
 		return nil, err
 	}
 	buf.Write(opt)
+	buf.WriteString(`,"comments":`)
+	comments, err := json.Marshal(f.Comments)
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(comments)
 	buf.WriteString(`}`)
 
 	return buf.Bytes(), nil
@@ -55,17 +74,21 @@ func (f *Field) MarshalText() ([]byte, error) {
 
 func (f *Field) UnmarshalText(text []byte) error {
 	results := struct {
-		Name    *Identifier     `json:"name"`
-		Type    json.RawMessage `json:"type"`
-		Options Options         `json:"options"`
+		Name     string          `json:"name"`
+		Type     json.RawMessage `json:"type"`
+		Optional bool            `json:"optional"`
+		Options  Options         `json:"options"`
+		Comments Comments        `json:"comments"`
 	}{}
 
 	if err := json.Unmarshal(text, &results); err != nil {
 		return err
 	}
 
-	f.Name = results.Name
+	f.Name = newIdentifier(results.Name)
+	f.Optional = results.Optional
 	f.Options = results.Options
+	f.Comments = results.Comments
 
 	return unmarshalTextType(results.Type, &f.Type)
 }
@@ -94,6 +117,8 @@ type Model struct {
 	Name    *Identifier   `json:"name"`
 	Extends []*Identifier `json:"extends"`
 	Fields  Fields        `json:"fields"`
+
+	Comments Comments `json:"comments"`
 }
 
 var _ Statement = (*Model)(nil)
@@ -107,6 +132,7 @@ func (m *Model) TokenLiteral() string {
 func (m *Model) String() string {
 	var sb strings.Builder
 
+	m.Comments.writeTop(&sb, "")
 	sb.WriteString(m.TokenLiteral())
 	sb.WriteString(" ")
 	sb.WriteString(m.Name.String())
@@ -117,12 +143,19 @@ func (m *Model) String() string {
 		sb.WriteString(extend.String())
 	}
 
-	if len(m.Extends) > 0 && len(m.Fields) == 0 {
+	for _, field := range m.Fields {
+		sb.WriteString("\n\t")
+		sb.WriteString(field.String())
+	}
+
+	m.Comments.writeEnd(&sb, "\t")
+
+	if len(m.Extends) > 0 || len(m.Fields) > 0 || len(m.Comments.End) > 0 {
 		sb.WriteString("\n")
 	}
 
-	sb.WriteString(m.Fields.String())
 	sb.WriteString("}")
+	m.Comments.writeRight(&sb)
 
 	return sb.String()
 }
@@ -7,8 +7,9 @@ import (
 )
 
 type Option struct {
-	Name  *Identifier `json:"name"`
-	Value Value       `json:"value"`
+	Name     *Identifier `json:"name"`
+	Value    Value       `json:"value"`
+	Comments Comments    `json:"comments"`
 }
 
 var _ Node = (*Option)(nil)
@@ -24,6 +25,12 @@ func (o *Option) MarshalText() ([]byte, error) {
 		return nil, err
 	}
 	buff.Write(val)
+	buff.WriteString(`,"comments":`)
+	comments, err := json.Marshal(o.Comments)
+	if err != nil {
+		return nil, err
+	}
+	buff.Write(comments)
 	buff.WriteString(`}`)
 
 	return buff.Bytes(), nil
@@ -31,15 +38,17 @@ func (o *Option) MarshalText() ([]byte, error) {
 
 func (o *Option) UnmarshalText(text []byte) error {
 	results := struct {
-		Name  *Identifier     `json:"name"`
-		Value json.RawMessage `json:"value"`
+		Name     string          `json:"name"`
+		Value    json.RawMessage `json:"value"`
+		Comments Comments        `json:"comments"`
 	}{}
 
 	if err := json.Unmarshal(text, &results); err != nil {
 		return err
 	}
 
-	o.Name = results.Name
+	o.Name = newIdentifier(results.Name)
+	o.Comments = results.Comments
 
 	return unmarshalTextValue(results.Value, &o.Value)
 }
@@ -71,20 +80,32 @@ func (o *Option) TokenLiteral() string {
 type Options []*Option
 
 func (o Options) String(tabs int) string {
-	if len(o) == 0 {
+	return o.block(tabs, nil)
+}
+
+// block returns the options wrapped in curly braces, end comments are
+// written before the closing curly brace
+func (o Options) block(tabs int, end []*Comment) string {
+	if len(o) == 0 && len(end) == 0 {
 		return ""
 	}
 
 	var sb strings.Builder
 
+	indent := strings.Repeat("\t", tabs)
+
 	sb.WriteString(" {")
 
 	for _, opt := range o {
 		sb.WriteString("\n")
-		sb.WriteString(strings.Repeat("\t", tabs))
+		sb.WriteString(indent)
+		opt.Comments.writeTop(&sb, indent)
 		sb.WriteString(opt.String())
+		opt.Comments.writeRight(&sb)
 	}
 
+	Comments{End: end}.writeEnd(&sb, indent)
+
 	sb.WriteString("\n")
 	sb.WriteString(strings.Repeat("\t", tabs-1))
 	sb.WriteString("}")
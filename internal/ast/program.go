@@ -9,6 +9,7 @@ import (
 
 type Program struct {
 	Statements []Statement `json:"statements"`
+	Comments   []*Comment  `json:"comments"` // comments at the end of the program
 }
 
 var _ Node = (*Program)(nil)
@@ -31,6 +32,15 @@ func (p *Program) String() string {
 		sb.WriteString(stmt.String())
 	}
 
+	for i, comment := range p.Comments {
+		if i == 0 && len(p.Statements) > 0 {
+			sb.WriteString("\n\n")
+		} else if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(comment.String())
+	}
+
 	return sb.String()
 }
 
@@ -78,12 +88,18 @@ func (p *Program) UnmarshalText(text []byte) error {
 		var stmt Statement
 
 		switch result.Type {
+		case "import":
+			stmt = &Import{}
 		case "const":
 			stmt = &Const{}
+		case "enum":
+			stmt = &Enum{}
 		case "model":
 			stmt = &Model{}
 		case "service":
 			stmt = &Service{}
+		case "error":
+			stmt = &CustomError{}
 		default:
 			return fmt.Errorf("unknown type: %s", result.Type)
 		}
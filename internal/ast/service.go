@@ -13,6 +13,7 @@ type Arg struct {
 	Name     *Identifier `json:"name"`
 	Type     Type        `json:"type"`
 	Optional bool        `json:"optional"`
+	Stream   bool        `json:"stream"`
 }
 
 var _ Node = (*Arg)(nil)
@@ -34,6 +35,12 @@ func (a *Arg) MarshalText() ([]byte, error) {
 	} else {
 		buff.WriteString(`false`)
 	}
+	buff.WriteString(`,"stream":`)
+	if a.Stream {
+		buff.WriteString(`true`)
+	} else {
+		buff.WriteString(`false`)
+	}
 	buff.WriteString(`}`)
 
 	return buff.Bytes(), nil
@@ -41,17 +48,19 @@ func (a *Arg) MarshalText() ([]byte, error) {
 
 func (a *Arg) UnmarshalText(text []byte) error {
 	results := struct {
-		Name     *Identifier     `json:"name"`
+		Name     string          `json:"name"`
 		Type     json.RawMessage `json:"type"`
 		Optional bool            `json:"optional"`
+		Stream   bool            `json:"stream"`
 	}{}
 
 	if err := json.Unmarshal(text, &results); err != nil {
 		return err
 	}
 
-	a.Name = results.Name
+	a.Name = newIdentifier(results.Name)
 	a.Optional = results.Optional
+	a.Stream = results.Stream
 
 	return unmarshalTextType(results.Type, &a.Type)
 }
@@ -68,6 +77,9 @@ func (a *Arg) String() string {
 		sb.WriteString("?")
 	}
 	sb.WriteString(": ")
+	if a.Stream {
+		sb.WriteString("stream ")
+	}
 	sb.WriteString(a.Type.String())
 
 	return sb.String()
@@ -125,16 +137,16 @@ func (r *Return) MarshalText() ([]byte, error) {
 
 func (r *Return) UnmarshalText(text []byte) error {
 	results := struct {
-		Name   *Identifier     `json:"name"`
+		Name   string          `json:"name"`
 		Type   json.RawMessage `json:"type"`
-		Stream bool            `json:"optional"`
+		Stream bool            `json:"stream"`
 	}{}
 
 	if err := json.Unmarshal(text, &results); err != nil {
 		return err
 	}
 
-	r.Name = results.Name
+	r.Name = newIdentifier(results.Name)
 	r.Stream = results.Stream
 
 	return unmarshalTextType(results.Type, &r.Type)
@@ -180,9 +192,10 @@ func (r Returns) String() string {
 type MethodType int
 
 const (
-	_          MethodType = iota
-	MethodRPC             // rpc
-	MethodHTTP            // http
+	_           MethodType = iota
+	MethodRPC              // rpc
+	MethodHTTP             // http
+	MethodEvent            // event
 )
 
 func (m MethodType) String() string {
@@ -191,6 +204,8 @@ func (m MethodType) String() string {
 		return "rpc"
 	case MethodHTTP:
 		return "http"
+	case MethodEvent:
+		return "event"
 	}
 
 	panic("unknown method type")
@@ -206,6 +221,8 @@ func (m *MethodType) UnmarshalText(text []byte) error {
 		*m = MethodRPC
 	case "http":
 		*m = MethodHTTP
+	case "event":
+		*m = MethodEvent
 	default:
 		return fmt.Errorf("unknown type: %s", text)
 	}
@@ -214,11 +231,13 @@ func (m *MethodType) UnmarshalText(text []byte) error {
 }
 
 type Method struct {
-	Type    MethodType  `json:"type"` // rpc, http
+	Type    MethodType  `json:"type"` // rpc, http, event
 	Name    *Identifier `json:"name"`
 	Args    Args        `json:"args"`
 	Returns Returns     `json:"returns"`
 	Options Options     `json:"options"`
+
+	Comments Comments `json:"comments"`
 }
 
 type Methods []*Method
@@ -232,6 +251,7 @@ func (m Methods) String() string {
 
 	for _, method := range m {
 		sb.WriteString("\n\t")
+		method.Comments.writeTop(&sb, "\t")
 		sb.WriteString(method.Type.String())
 		sb.WriteString(" ")
 		sb.WriteString(method.Name.String())
@@ -239,15 +259,14 @@ func (m Methods) String() string {
 		sb.WriteString(method.Args.String())
 		sb.WriteString(")")
 
-		if len(method.Returns) == 0 {
-			sb.WriteString("\n")
-			continue
+		if len(method.Returns) != 0 {
+			sb.WriteString(" => (")
+			sb.WriteString(method.Returns.String())
+			sb.WriteString(")")
 		}
 
-		sb.WriteString(" => (")
-		sb.WriteString(method.Returns.String())
-		sb.WriteString(")")
-		sb.WriteString(method.Options.String(2))
+		sb.WriteString(method.Options.block(2, method.Comments.End))
+		method.Comments.writeRight(&sb)
 
 		sb.WriteString("\n")
 	}
@@ -258,7 +277,10 @@ func (m Methods) String() string {
 type Service struct {
 	Token   *token.Token `json:"token"`
 	Name    *Identifier  `json:"name"`
+	Options Options      `json:"options"`
 	Methods Methods      `json:"methods"`
+
+	Comments Comments `json:"comments"`
 }
 
 var _ Statement = (*Service)(nil)
@@ -272,11 +294,37 @@ func (s *Service) TokenLiteral() string {
 func (s *Service) String() string {
 	var sb strings.Builder
 
+	s.Comments.writeTop(&sb, "")
 	sb.WriteString("service ")
 	sb.WriteString(s.Name.String())
 	sb.WriteString(" {")
+
+	for _, opt := range s.Options {
+		sb.WriteString("\n\t")
+		opt.Comments.writeTop(&sb, "\t")
+		sb.WriteString(opt.String())
+		opt.Comments.writeRight(&sb)
+	}
+
+	if len(s.Options) > 0 {
+		sb.WriteString("\n")
+	}
+
 	sb.WriteString(s.Methods.String())
+
+	if len(s.Comments.End) > 0 {
+		if len(s.Options) == 0 && len(s.Methods) == 0 {
+			sb.WriteString("\n")
+		}
+		for _, comment := range s.Comments.End {
+			sb.WriteString("\t")
+			sb.WriteString(comment.String())
+			sb.WriteString("\n")
+		}
+	}
+
 	sb.WriteString("}")
+	s.Comments.writeRight(&sb)
 
 	return sb.String()
 }
@@ -271,6 +271,41 @@ func (t *Array) String() string {
 	return sb.String()
 }
 
+func (t *Array) MarshalText() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteString(`{"token":`)
+	tok, err := json.Marshal(t.Token)
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(tok)
+	buf.WriteString(`,"type":`)
+	typ, err := marshalTextType(t.Type)
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(typ)
+	buf.WriteString(`}`)
+
+	return buf.Bytes(), nil
+}
+
+func (t *Array) UnmarshalText(text []byte) error {
+	results := struct {
+		Token *token.Token    `json:"token"`
+		Type  json.RawMessage `json:"type"`
+	}{}
+
+	if err := json.Unmarshal(text, &results); err != nil {
+		return err
+	}
+
+	t.Token = results.Token
+
+	return unmarshalTextType(results.Type, &t.Type)
+}
+
 // MAP
 
 type Map struct {
@@ -297,6 +332,52 @@ func (t *Map) String() string {
 	return sb.String()
 }
 
+func (t *Map) MarshalText() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteString(`{"token":`)
+	tok, err := json.Marshal(t.Token)
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(tok)
+	buf.WriteString(`,"key":`)
+	key, err := marshalTextType(t.Key)
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(key)
+	buf.WriteString(`,"value":`)
+	value, err := marshalTextType(t.Value)
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(value)
+	buf.WriteString(`}`)
+
+	return buf.Bytes(), nil
+}
+
+func (t *Map) UnmarshalText(text []byte) error {
+	results := struct {
+		Token *token.Token    `json:"token"`
+		Key   json.RawMessage `json:"key"`
+		Value json.RawMessage `json:"value"`
+	}{}
+
+	if err := json.Unmarshal(text, &results); err != nil {
+		return err
+	}
+
+	t.Token = results.Token
+
+	if err := unmarshalTextType(results.Key, &t.Key); err != nil {
+		return err
+	}
+
+	return unmarshalTextType(results.Value, &t.Value)
+}
+
 // TIMESTAMP
 
 type Timestamp struct {
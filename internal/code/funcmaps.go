@@ -13,4 +13,50 @@ var DefaultFuncsMap = template.FuncMap{
 	"ToPascalCase": strcase.ToPascal,
 	"ToCamelCase":  strcase.ToCamel,
 	"ToSnakeCase":  strcase.ToSnake,
+	"LineDoc":      lineDoc,
+	"BlockDoc":     blockDoc,
+}
+
+// lineDoc renders the documentation as line comments, e.g. // User is ..., which
+// is placed right before the declaration, so indent is written after each line
+func lineDoc(indent string, lines []string) string {
+	var sb strings.Builder
+
+	for _, line := range lines {
+		sb.WriteString("//")
+		if line != "" {
+			sb.WriteString(" ")
+			sb.WriteString(line)
+		}
+		sb.WriteString("\n")
+		sb.WriteString(indent)
+	}
+
+	return sb.String()
+}
+
+// blockDoc renders the documentation as a block comment, e.g. /** User is ... */, which
+// is placed right before the declaration, so indent is written after the comment
+func blockDoc(indent string, lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("/**\n")
+	for _, line := range lines {
+		sb.WriteString(indent)
+		sb.WriteString(" *")
+		if line != "" {
+			sb.WriteString(" ")
+			sb.WriteString(strings.ReplaceAll(line, "*/", "*\\/"))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(indent)
+	sb.WriteString(" */\n")
+	sb.WriteString(indent)
+
+	return sb.String()
 }
@@ -8,6 +8,7 @@ import (
 
 type Constant struct {
 	Name  string
+	Doc   []string
 	Value string
 }
 
@@ -17,6 +18,7 @@ func (c *Constants) Parse(prog *ast.Program) error {
 	*c = sliceutil.Mapper(astutil.GetConstants(prog), func(constant *ast.Const) Constant {
 		return Constant{
 			Name:  constant.Name.String(),
+			Doc:   constant.Comments.Doc(),
 			Value: getValue(constant.Value),
 		}
 	})
@@ -10,11 +10,13 @@ import (
 
 type EnumKeyValue struct {
 	Name  string
+	Doc   []string
 	Value string
 }
 
 type Enum struct {
 	Name string
+	Doc  []string
 	Type string // int8, int16, int32, int64
 	Keys []EnumKeyValue
 }
@@ -22,13 +24,15 @@ type Enum struct {
 type Enums []Enum
 
 func (e *Enums) Parse(prog *ast.Program) error {
-	*e = sliceutil.Mapper(astutil.GetEnums(prog), func(enum *ast.Enum) Enum {
+	*e = sliceutil.Mapper(astutil.GetLocalEnums(prog), func(enum *ast.Enum) Enum {
 		return Enum{
 			Name: enum.Name.String(),
+			Doc:  enum.Comments.Doc(),
 			Type: fmt.Sprintf("int%d", enum.Size),
 			Keys: sliceutil.Mapper(enum.Sets, func(set *ast.EnumSet) EnumKeyValue {
 				return EnumKeyValue{
 					Name:  set.Name.String(),
+					Doc:   set.Comments.Doc(),
 					Value: fmt.Sprintf("%d", set.Value.Value),
 				}
 			}),
@@ -11,6 +11,7 @@ import (
 
 type CustomError struct {
 	Name       string
+	Doc        []string
 	Code       int64
 	HttpStatus string
 	Msg        string
@@ -22,6 +23,7 @@ func (c *CustomErrors) Parse(prog *ast.Program) error {
 	*c = sliceutil.Mapper(astutil.GetCustomErrors(prog), func(customError *ast.CustomError) CustomError {
 		return CustomError{
 			Name:       customError.Name.String(),
+			Doc:        customError.Comments.Doc(),
 			Code:       customError.Code,
 			HttpStatus: fmt.Sprintf("http.Status%s", ast.HttpStatusCode2String[customError.HttpStatus]),
 			Msg:        customError.Msg.Value,
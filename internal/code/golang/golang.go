@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
 
 	"compiler.ella.to/internal/ast"
 	"compiler.ella.to/internal/code"
@@ -14,14 +17,22 @@ import (
 //go:embed templates/*.tmpl
 var files embed.FS
 
+// Import is a generated package of an imported schema package
+type Import struct {
+	Name string
+	Path string
+}
+
 type Golang struct {
-	PkgName      string
-	CustomErrors CustomErrors
-	Constants    Constants
-	Enums        Enums
-	Models       Models
-	HttpServices HttpServices
-	RpcServices  RpcServices
+	PkgName       string
+	Imports       []Import
+	CustomErrors  CustomErrors
+	Constants     Constants
+	Enums         Enums
+	Models        Models
+	HttpServices  HttpServices
+	RpcServices   RpcServices
+	EventServices EventServices
 }
 
 func (g Golang) HasRpcServices() bool {
@@ -38,6 +49,10 @@ func (g Golang) HasRpcServices() bool {
 	return false
 }
 
+func (g Golang) HasEventServices() bool {
+	return len(g.EventServices) > 0
+}
+
 func (g Golang) HasHttpServices() bool {
 	if len(g.HttpServices) == 0 {
 		return false
@@ -65,20 +80,44 @@ func (g *Golang) Parse(prog *ast.Program) error {
 		g.Models.Parse,
 		g.HttpServices.Parse,
 		g.RpcServices.Parse,
+		g.EventServices.Parse,
 	)
 }
 
-func New(pkg string) code.Generator {
+func init() {
+	code.Register(code.Target{
+		Name:          "go",
+		Suffixes:      []string{".go"},
+		PackageSuffix: ".gen.go",
+		New: func(cfg code.Config) (code.Generator, error) {
+			imports := make([]Import, 0, len(cfg.Imports))
+			for _, dep := range cfg.Imports {
+				path, err := importPath(filepath.Dir(dep.Out))
+				if err != nil {
+					return nil, err
+				}
+				imports = append(imports, Import{Name: dep.Name, Path: path})
+			}
+
+			return New(cfg.Pkg, cfg.Templates, imports...), nil
+		},
+	})
+}
+
+// New creates the generator of the Go package, templates is the directory which
+// overrides the embedded templates, see code.LoadTemplate
+func New(pkg string, templates string, imports ...Import) code.Generator {
 	return code.GeneratorFunc(func(outFilename string, prog *ast.Program) error {
 		golang := Golang{
 			PkgName: pkg,
+			Imports: imports,
 		}
 
 		if err := golang.Parse(prog); err != nil {
 			return err
 		}
 
-		tmpl, err := code.LoadTemplate(files, "templates", "golang")
+		tmpl, err := code.LoadTemplate(files, "templates", "golang", templates)
 		if err != nil {
 			return err
 		}
@@ -104,6 +143,38 @@ func New(pkg string) code.Generator {
 	})
 }
 
+// importPath returns the import path of the go package stored in dir, it is
+// resolved based on the module path defined in the nearest go.mod
+func importPath(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for modDir := dir; ; modDir = filepath.Dir(modDir) {
+		content, err := os.ReadFile(filepath.Join(modDir, "go.mod"))
+		if err == nil {
+			for _, line := range strings.Split(string(content), "\n") {
+				fields := strings.Fields(line)
+				if len(fields) == 2 && fields[0] == "module" {
+					rel, err := filepath.Rel(modDir, dir)
+					if err != nil {
+						return "", err
+					}
+					return path.Join(strings.Trim(fields[1], `"`), filepath.ToSlash(rel)), nil
+				}
+			}
+			return "", fmt.Errorf("module path is not defined in %s", filepath.Join(modDir, "go.mod"))
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		if filepath.Dir(modDir) == modDir {
+			return "", fmt.Errorf("failed to find go.mod for %s", dir)
+		}
+	}
+}
+
 func isArrayOf[T ast.Type](typ ast.Type) bool {
 	arr, ok := typ.(*ast.Array)
 	if !ok {
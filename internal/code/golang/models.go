@@ -1,6 +1,7 @@
 package golang
 
 import (
+	"fmt"
 	"strings"
 
 	"compiler.ella.to/internal/ast"
@@ -9,10 +10,25 @@ import (
 	"compiler.ella.to/pkg/strcase"
 )
 
+type ModelFieldCheck struct {
+	Cond string // go expression which is true when the value is invalid
+	Msg  string
+}
+
 type ModelField struct {
-	Name string
-	Type string
-	Tags string
+	Name      string
+	Doc       []string
+	Type      string
+	Tags      string
+	ErrorName string // name used in validation errors, it is the same as json name
+	Checks    []ModelFieldCheck
+	Pattern   ModelPattern
+	HasNested bool // field contains models which need to be validated as well
+}
+
+type ModelPattern struct {
+	Name  string
+	Value string
 }
 
 type ModelFields []ModelField
@@ -20,10 +36,19 @@ type ModelFields []ModelField
 func (m *ModelFields) Parse(message *ast.Model, isModelType func(value string) bool) error {
 	*m = sliceutil.Mapper(message.Fields, func(field *ast.Field) ModelField {
 		typ := parseType(field.Type, isModelType)
+		if field.Optional {
+			typ = optionalType(typ)
+		}
+
 		return ModelField{
-			Name: field.Name.String(),
-			Type: typ,
-			Tags: parseModelFieldOptions(field),
+			Name:      field.Name.String(),
+			Doc:       field.Comments.Doc(),
+			Type:      typ,
+			Tags:      parseModelFieldOptions(field),
+			ErrorName: parseModelFieldErrorName(field),
+			Checks:    parseModelFieldChecks(message.Name.String(), field, isModelType),
+			Pattern:   parseModelFieldPattern(message.Name.String(), field),
+			HasNested: hasNestedModel(field.Type, isModelType),
 		}
 	})
 	return nil
@@ -31,17 +56,32 @@ func (m *ModelFields) Parse(message *ast.Model, isModelType func(value string) b
 
 type Model struct {
 	Name   string
+	Doc    []string
 	Fields ModelFields
 }
 
+// Patterns returns all the regular expressions used by the model's fields
+func (m Model) Patterns() []ModelPattern {
+	var patterns []ModelPattern
+
+	for _, field := range m.Fields {
+		if field.Pattern.Value != "" {
+			patterns = append(patterns, field.Pattern)
+		}
+	}
+
+	return patterns
+}
+
 type Models []Model
 
 func (m *Models) Parse(prog *ast.Program) error {
 	isModelType := astutil.CreateIsModelTypeFunc(astutil.GetModels(prog))
 
-	*m = sliceutil.Mapper(astutil.GetModels(prog), func(message *ast.Model) Model {
+	*m = sliceutil.Mapper(astutil.GetLocalModels(prog), func(message *ast.Model) Model {
 		msg := Model{
 			Name: message.Name.String(),
+			Doc:  message.Comments.Doc(),
 		}
 
 		msg.Fields.Parse(message, isModelType)
@@ -84,6 +124,11 @@ func parseModelFieldOptions(field *ast.Field) string {
 		}
 	}
 
+	// optional fields are omitted when they are not set
+	if field.Optional && jsonTagValue != "-" && !strings.HasSuffix(jsonTagValue, ",omitempty") {
+		jsonTagValue += ",omitempty"
+	}
+
 	sb.WriteString(`json:"`)
 	sb.WriteString(jsonTagValue)
 	sb.WriteString(`"`)
@@ -102,9 +147,141 @@ func parseModelFieldOptions(field *ast.Field) string {
 		}
 	}
 
+	if field.Optional && yamlTagValue != "-" {
+		yamlTagValue += ",omitempty"
+	}
+
 	sb.WriteString(` yaml:"`)
 	sb.WriteString(yamlTagValue)
 	sb.WriteString(`"`)
 
 	return sb.String()
 }
+
+func parseModelFieldErrorName(field *ast.Field) string {
+	for _, opt := range field.Options {
+		if strings.ToLower(opt.Name.Token.Literal) != "json" {
+			continue
+		}
+
+		if value, ok := opt.Value.(*ast.ValueString); ok {
+			return value.Token.Literal
+		}
+	}
+
+	return strings.ToLower(strcase.ToSnake(field.Name.String()))
+}
+
+func parseModelFieldPattern(modelName string, field *ast.Field) ModelPattern {
+	validation := astutil.ParseFieldValidation(field.Options)
+	if validation.Pattern == "" {
+		return ModelPattern{}
+	}
+
+	return ModelPattern{
+		Name:  "pattern" + modelName + field.Name.String(),
+		Value: validation.Pattern,
+	}
+}
+
+// parseModelFieldChecks converts the validation options of the field into go
+// expressions, the validator package already made sure the options fit the field's type
+func parseModelFieldChecks(modelName string, field *ast.Field, isModelType func(value string) bool) []ModelFieldCheck {
+	validation := astutil.ParseFieldValidation(field.Options)
+	if validation.IsEmpty() {
+		return nil
+	}
+
+	var checks []ModelFieldCheck
+
+	value := "m." + field.Name.String()
+
+	// optional scalars are pointers, the checks are applied to the value only if it's set
+	typ := parseType(field.Type, isModelType)
+	if field.Optional && optionalType(typ) != typ {
+		value = "*" + value
+	}
+
+	if validation.Required {
+		var cond string
+
+		switch typ := field.Type.(type) {
+		case *ast.String:
+			cond = value + ` == ""`
+		case *ast.Timestamp:
+			cond = value + ".IsZero()"
+		case *ast.Array, *ast.Map:
+			cond = "len(" + value + ") == 0"
+		case *ast.CustomType:
+			if isModelType(typ.String()) {
+				cond = value + " == nil"
+			} else {
+				// the validator makes sure zero is not a key of required enums
+				cond = value + " == 0"
+			}
+		default:
+			cond = value + " == nil"
+		}
+
+		checks = append(checks, ModelFieldCheck{Cond: cond, Msg: validation.RequiredMsg()})
+	}
+
+	if validation.Min != "" {
+		checks = append(checks, ModelFieldCheck{Cond: value + " < " + validation.Min, Msg: validation.MinMsg()})
+	}
+
+	if validation.Max != "" {
+		checks = append(checks, ModelFieldCheck{Cond: value + " > " + validation.Max, Msg: validation.MaxMsg()})
+	}
+
+	length := "len(" + value + ")"
+	if _, ok := field.Type.(*ast.String); ok {
+		length = "utf8.RuneCountInString(" + value + ")"
+	}
+
+	if validation.MinLen != nil {
+		checks = append(checks, ModelFieldCheck{Cond: fmt.Sprintf("%s < %d", length, *validation.MinLen), Msg: validation.MinLenMsg()})
+	}
+
+	if validation.MaxLen != nil {
+		checks = append(checks, ModelFieldCheck{Cond: fmt.Sprintf("%s > %d", length, *validation.MaxLen), Msg: validation.MaxLenMsg()})
+	}
+
+	if validation.Pattern != "" {
+		pattern := parseModelFieldPattern(modelName, field).Name
+
+		var cond string
+		if _, ok := field.Type.(*ast.String); ok {
+			cond = fmt.Sprintf(`%s != "" && !%s.MatchString(%s)`, value, pattern, value)
+		} else {
+			cond = fmt.Sprintf("!matchPatternAll(%s, %s)", pattern, value)
+		}
+
+		checks = append(checks, ModelFieldCheck{Cond: cond, Msg: validation.PatternMsg()})
+	}
+
+	if validation.MaxSize != nil {
+		checks = append(checks, ModelFieldCheck{Cond: fmt.Sprintf("len(%s) > %d", value, *validation.MaxSize), Msg: validation.MaxSizeMsg()})
+	}
+
+	if field.Optional {
+		for i := range checks {
+			checks[i].Cond = fmt.Sprintf("m.%s != nil && (%s)", field.Name, checks[i].Cond)
+		}
+	}
+
+	return checks
+}
+
+func hasNestedModel(typ ast.Type, isModelType func(value string) bool) bool {
+	switch typ := typ.(type) {
+	case *ast.CustomType:
+		return isModelType(typ.String())
+	case *ast.Array:
+		return hasNestedModel(typ.Type, isModelType)
+	case *ast.Map:
+		return hasNestedModel(typ.Value, isModelType)
+	default:
+		return false
+	}
+}
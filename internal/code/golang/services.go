@@ -11,8 +11,11 @@ import (
 )
 
 type MethodArg struct {
-	Name string
-	Type string
+	Name      string
+	Type      string
+	Optional  bool
+	Stream    bool   // stream args are received over WebSocket
+	PathParam string // name of the http path placeholder bound to the arg
 }
 
 type MethodArgs []MethodArg
@@ -44,7 +47,9 @@ func (m MethodReturns) Definitions() string {
 
 type Method struct {
 	Name    string
+	Doc     []string
 	Service string
+	Path    string // http route, which might contain placeholders
 	Options astutil.MethodOptions
 	Args    MethodArgs
 	Returns MethodReturns
@@ -80,26 +85,96 @@ func (m Method) TopicValue() string {
 	return fmt.Sprintf("ella.rpc.%s.%s", strcase.ToSnake(m.Service), strcase.ToSnake(m.Name))
 }
 
+func (m Method) EventTopicName() string {
+	return fmt.Sprintf("TopicEvent%s%s", strcase.ToPascal(m.Service), strcase.ToPascal(m.Name))
+}
+
+func (m Method) EventTopicValue() string {
+	return fmt.Sprintf("ella.event.%s.%s", strcase.ToSnake(m.Service), strcase.ToSnake(m.Name))
+}
+
 func (m Method) PathName() string {
 	return fmt.Sprintf("PathHttp%s%sMethod", strcase.ToPascal(m.Service), strcase.ToPascal(m.Name))
 }
 
 func (m Method) PathValue() string {
-	return fmt.Sprintf("/ella/http/%s/%s", strcase.ToPascal(m.Service), strcase.ToPascal(m.Name))
+	return m.Path
 }
 
-func (m Method) ArgsNames(prefix string) string {
-	return strings.Join(sliceutil.Mapper(sliceutil.Filter(m.Args, func(arg MethodArg) bool {
-		return arg.Type != "func() (string, io.Reader, error)"
+// PathBuilder returns the go expression which creates the http path of the method
+// by replacing placeholders with args' values
+func (m Method) PathBuilder() string {
+	values := sliceutil.Mapper(sliceutil.Filter(m.Args, func(arg MethodArg) bool {
+		return arg.PathParam != ""
 	}), func(arg MethodArg) string {
+		return fmt.Sprintf("%q: %s,", arg.PathParam, arg.Name)
+	})
+
+	if len(values) == 0 {
+		return m.PathName()
+	}
+
+	return fmt.Sprintf("buildHttpPath(%s, map[string]any{%s})", m.PathName(), strings.Join(values, " "))
+}
+
+// structArgs returns the args which are sent as json body or query string,
+// file and stream args are excluded as they are transferred separately
+func (m Method) structArgs() MethodArgs {
+	return sliceutil.Filter(m.Args, func(arg MethodArg) bool {
+		return arg.Type != "func() (string, io.Reader, error)" && !arg.Stream
+	})
+}
+
+func (m Method) ArgsNames(prefix string) string {
+	return strings.Join(sliceutil.Mapper(m.structArgs(), func(arg MethodArg) string {
 		return prefix + strcase.ToPascal(arg.Name) + ","
 	}), "\n")
 }
 
+// CallArgs returns the args passed to the service method in the same order
+// as they are defined, the stream arg is replaced by the stream variable
+func (m Method) CallArgs(prefix string, stream string) string {
+	return strings.Join(sliceutil.Mapper(m.Args, func(arg MethodArg) string {
+		if arg.Stream {
+			return stream + ","
+		}
+		return prefix + strcase.ToPascal(arg.Name) + ","
+	}), " ")
+}
+
+// streamType returns the type of stream args and returns, see Stream in helper template
+func streamType(typ string) string {
+	return "*Stream[" + typ + "]"
+}
+
+func streamItemType(typ string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(typ, "*Stream["), "]")
+}
+
+func (m Method) ArgStreamName() string {
+	for _, arg := range m.Args {
+		if arg.Stream {
+			return arg.Name
+		}
+	}
+
+	return ""
+}
+
+func (m Method) ArgStreamType() string {
+	for _, arg := range m.Args {
+		if arg.Stream {
+			return streamItemType(arg.Type)
+		}
+	}
+
+	return ""
+}
+
 func (m Method) ReturnStreamType() string {
 	for _, ret := range m.Returns {
 		if ret.Stream {
-			return strings.Replace(ret.Type, "<-chan ", "", 1)
+			return streamItemType(ret.Type)
 		}
 	}
 
@@ -107,17 +182,19 @@ func (m Method) ReturnStreamType() string {
 }
 
 func (m Method) ArgsStructDefinitions(pointer bool) string {
-	return strings.Join(sliceutil.Mapper(sliceutil.Filter(m.Args, func(arg MethodArg) bool {
-		return arg.Type != "func() (string, io.Reader, error)"
-	}), func(arg MethodArg) string {
+	return strings.Join(sliceutil.Mapper(m.structArgs(), func(arg MethodArg) string {
+		if arg.PathParam != "" {
+			return fmt.Sprintf("%s %s `json:\"-\" path:\"%s\"`", strcase.ToPascal(arg.Name), arg.Type, arg.PathParam)
+		}
+		if arg.Optional {
+			return fmt.Sprintf("%s %s `json:\"%s,omitempty\"`", strcase.ToPascal(arg.Name), arg.Type, strcase.ToSnake(arg.Name))
+		}
 		return fmt.Sprintf("%s %s `json:\"%s\"`", strcase.ToPascal(arg.Name), arg.Type, strcase.ToSnake(arg.Name))
 	}), "\n")
 }
 
 func (m Method) ArgsNamesValues() string {
-	return strings.Join(sliceutil.Mapper(sliceutil.Filter(m.Args, func(arg MethodArg) bool {
-		return arg.Type != "func() (string, io.Reader, error)"
-	}), func(arg MethodArg) string {
+	return strings.Join(sliceutil.Mapper(m.structArgs(), func(arg MethodArg) string {
 		return strcase.ToPascal(arg.Name) + ":" + arg.Name + ","
 	}), "\n")
 }
@@ -144,6 +221,12 @@ func (m Method) IsStream() bool {
 	return false
 }
 
+// IsBidiStream returns true if the method has a stream arg, such methods
+// are served over WebSocket
+func (m Method) IsBidiStream() bool {
+	return m.ArgStreamName() != ""
+}
+
 func (m Method) IsBinary() bool {
 	for _, ret := range m.Returns {
 		if ret.Type == "io.Reader" {
@@ -170,8 +253,10 @@ func (m Method) IsFileUpload() bool {
 type Methods []Method
 
 type HttpService struct {
-	Name    string
-	Methods Methods
+	Name     string
+	Doc      []string
+	BasePath string
+	Methods  Methods
 }
 
 func (s HttpService) NameImpl() string {
@@ -183,7 +268,7 @@ func (s HttpService) PathName() string {
 }
 
 func (s HttpService) PathValue() string {
-	return fmt.Sprintf("/ella/http/%s/", strcase.ToPascal(s.Name))
+	return s.BasePath + "/"
 }
 
 type HttpServices []HttpService
@@ -197,8 +282,12 @@ func (s *HttpServices) Parse(prog *ast.Program) error {
 		})
 
 		return HttpService{
-			Name: service.Name.String(),
+			Name:     service.Name.String(),
+			Doc:      service.Comments.Doc(),
+			BasePath: astutil.HttpBasePath(service),
 			Methods: sliceutil.Mapper(methods, func(method *ast.Method) Method {
+				path := astutil.HttpPath(service, method)
+				pathParams := astutil.ParseHttpPathParams(path)
 
 				var isBinary bool
 				var isStream bool
@@ -211,7 +300,7 @@ func (s *HttpServices) Parse(prog *ast.Program) error {
 						isStream = true
 					} else if ret.Stream {
 						isStream = true
-						typ = "<-chan " + typ
+						typ = streamType(typ)
 					}
 
 					return MethodReturn{
@@ -231,22 +320,36 @@ func (s *HttpServices) Parse(prog *ast.Program) error {
 					})
 				}
 
+				options := astutil.ParseMethodOptions(method.Options)
+				options.HttpMethod = astutil.HttpMethod(method)
+
 				return Method{
 					Name:    method.Name.String(),
+					Doc:     method.Comments.Doc(),
 					Service: service.Name.String(),
-					Options: astutil.ParseMethodOptions(method.Options),
+					Path:    path,
+					Options: options,
 					Args: sliceutil.Mapper(method.Args, func(arg *ast.Arg) MethodArg {
 						var typ string
 
 						if _, ok := arg.Type.(*ast.File); ok {
 							typ = "func() (string, io.Reader, error)"
+						} else if arg.Stream {
+							typ = streamType(parseType(arg.Type, isModelType))
+						} else if arg.Optional {
+							typ = optionalType(parseType(arg.Type, isModelType))
 						} else {
 							typ = parseType(arg.Type, isModelType)
 						}
 
+						param, _ := astutil.FindHttpPathParam(pathParams, arg)
+
 						return MethodArg{
-							Name: arg.Name.String(),
-							Type: typ,
+							Name:      arg.Name.String(),
+							Type:      typ,
+							Optional:  arg.Optional,
+							Stream:    arg.Stream,
+							PathParam: param.Name,
 						}
 					}),
 					Returns: returns,
@@ -265,6 +368,7 @@ func (s *HttpServices) Parse(prog *ast.Program) error {
 
 type RpcService struct {
 	Name    string
+	Doc     []string
 	Methods Methods
 }
 
@@ -288,20 +392,23 @@ func (s *RpcServices) Parse(prog *ast.Program) error {
 
 		return RpcService{
 			Name: service.Name.String(),
+			Doc:  service.Comments.Doc(),
 			Methods: sliceutil.Mapper(methods, func(method *ast.Method) Method {
 				return Method{
 					Name:    method.Name.String(),
+					Doc:     method.Comments.Doc(),
 					Service: service.Name.String(),
-					Args: sliceutil.Mapper(method.Args, func(arg *ast.Arg) MethodArg {
-						return MethodArg{
-							Name: arg.Name.String(),
-							Type: parseType(arg.Type, isModelType),
-						}
-					}),
+					Args:    parseRpcArgs(method.Args, isModelType),
 					Returns: sliceutil.Mapper(method.Returns, func(ret *ast.Return) MethodReturn {
+						typ := parseType(ret.Type, isModelType)
+						if ret.Stream {
+							typ = streamType(typ)
+						}
+
 						return MethodReturn{
-							Name: ret.Name.String(),
-							Type: parseType(ret.Type, isModelType),
+							Name:   ret.Name.String(),
+							Type:   typ,
+							Stream: ret.Stream,
 						}
 					}),
 				}
@@ -316,3 +423,59 @@ func (s *RpcServices) Parse(prog *ast.Program) error {
 
 	return nil
 }
+
+// parseRpcArgs returns the args of rpc methods and events, which are all sent as json
+func parseRpcArgs(args []*ast.Arg, isModelType func(value string) bool) MethodArgs {
+	return sliceutil.Mapper(args, func(arg *ast.Arg) MethodArg {
+		typ := parseType(arg.Type, isModelType)
+		if arg.Optional {
+			typ = optionalType(typ)
+		}
+
+		return MethodArg{
+			Name:     arg.Name.String(),
+			Type:     typ,
+			Optional: arg.Optional,
+		}
+	})
+}
+
+// EventService holds the events of the service, which are published to all
+// the subscribers without any reply
+type EventService struct {
+	Name   string
+	Doc    []string
+	Events Methods
+}
+
+type EventServices []EventService
+
+func (s *EventServices) Parse(prog *ast.Program) error {
+	isModelType := astutil.CreateIsModelTypeFunc(astutil.GetModels(prog))
+
+	*s = sliceutil.Mapper(astutil.GetServices(prog), func(service *ast.Service) EventService {
+		events := sliceutil.Filter(service.Methods, func(method *ast.Method) bool {
+			return method.Type == ast.MethodEvent
+		})
+
+		return EventService{
+			Name: service.Name.String(),
+			Doc:  service.Comments.Doc(),
+			Events: sliceutil.Mapper(events, func(method *ast.Method) Method {
+				return Method{
+					Name:    method.Name.String(),
+					Doc:     method.Comments.Doc(),
+					Service: service.Name.String(),
+					Args:    parseRpcArgs(method.Args, isModelType),
+				}
+			}),
+		}
+	})
+
+	// we want to make sure that we don't generate services without events
+	*s = sliceutil.Filter(*s, func(service EventService) bool {
+		return len(service.Events) != 0
+	})
+
+	return nil
+}
@@ -2,6 +2,7 @@ package golang
 
 import (
 	"fmt"
+	"strings"
 
 	"compiler.ella.to/internal/ast"
 )
@@ -39,3 +40,13 @@ func parseType(typ ast.Type, isModelType func(value string) bool) string {
 	// This shouldn't happen as the validator should catch this any errors
 	panic(fmt.Sprintf("unknown type: %T", typ))
 }
+
+// optionalType converts the type into a nillable type, so unset values can be told
+// apart from zero values. Models, arrays, maps and any are already nillable.
+func optionalType(typ string) string {
+	if typ == "any" || strings.HasPrefix(typ, "*") || strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "map[") {
+		return typ
+	}
+
+	return "*" + typ
+}
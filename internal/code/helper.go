@@ -2,59 +2,82 @@ package code
 
 import (
 	"embed"
-	"io"
+	"fmt"
 	"io/fs"
+	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 )
 
-func getAllFilenames(fs embed.FS, folder string) ([]string, error) {
-	files, err := fs.ReadDir(folder)
+// getAllFilenames returns the names of the .tmpl files in the folder
+func getAllFilenames(fsys fs.FS, folder string) ([]string, error) {
+	files, err := fs.ReadDir(fsys, folder)
 	if err != nil {
 		return nil, err
 	}
 
 	var filenames []string
 	for _, file := range files {
-		if file.IsDir() {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".tmpl" {
 			continue
 		}
 
-		filenames = append(filenames, filepath.Join(folder, file.Name()))
+		filenames = append(filenames, file.Name())
 	}
 
 	return filenames, nil
 }
 
-func combinedAllFiles(fs fs.FS, filenames []string) (string, error) {
-	var sb strings.Builder
+// readAllFiles reads the .tmpl files of the folder into contents by their names
+func readAllFiles(fsys fs.FS, folder string, contents map[string]string) error {
+	filenames, err := getAllFilenames(fsys, folder)
+	if err != nil {
+		return err
+	}
 
 	for _, filename := range filenames {
-		file, err := fs.Open(filename)
+		content, err := fs.ReadFile(fsys, path.Join(folder, filename))
 		if err != nil {
-			return "", err
+			return err
 		}
 
-		_, err = io.Copy(&sb, file)
-		if err != nil {
-			return "", err
-		}
+		contents[filename] = string(content)
 	}
 
-	return sb.String(), nil
+	return nil
 }
 
-func LoadTemplate(fs embed.FS, folder, name string) (*template.Template, error) {
-	filenames, err := getAllFilenames(fs, folder)
-	if err != nil {
+// LoadTemplate parses all the .tmpl files of the embedded folder as a single template,
+// files are combined in the order of their names, e.g. 000-header.tmpl comes first.
+// If dir is not empty, its .tmpl files replace the embedded files with the same name
+// or they are added to them, so the output can be customized without changing the
+// embedded templates.
+func LoadTemplate(files embed.FS, folder, name, dir string) (*template.Template, error) {
+	contents := make(map[string]string)
+
+	if err := readAllFiles(files, folder, contents); err != nil {
 		return nil, err
 	}
 
-	content, err := combinedAllFiles(fs, filenames)
-	if err != nil {
-		return nil, err
+	if dir != "" {
+		if err := readAllFiles(os.DirFS(dir), ".", contents); err != nil {
+			return nil, fmt.Errorf("failed to load templates from %s: %w", dir, err)
+		}
+	}
+
+	filenames := make([]string, 0, len(contents))
+	for filename := range contents {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	var sb strings.Builder
+	for _, filename := range filenames {
+		sb.WriteString(contents[filename])
 	}
 
-	return template.New(name).Funcs(DefaultFuncsMap).Parse(content)
+	return template.New(name).Funcs(DefaultFuncsMap).Parse(sb.String())
 }
@@ -0,0 +1,68 @@
+package code
+
+import (
+	"embed"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//go:embed testdata/templates/*.tmpl
+var testTemplates embed.FS
+
+func TestLoadTemplate(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Files    map[string]string
+		Expected string
+	}{
+		{
+			Name:     "embedded templates",
+			Expected: "header ELLA\nhello ella\n",
+		},
+		{
+			Name: "override templates",
+			Files: map[string]string{
+				"001-greeting.tmpl": `{{ define "greeting" }}hi{{ end }}`,
+			},
+			Expected: "header ELLA\nhi ella\n",
+		},
+		{
+			Name: "add templates",
+			Files: map[string]string{
+				"003-footer.tmpl": "footer {{ ToSnakeCase .Name }}\n",
+				"README.md":       "not a template\n",
+			},
+			Expected: "header ELLA\nhello ella\nfooter ella\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var dir string
+			if tc.Files != nil {
+				dir = t.TempDir()
+				for name, content := range tc.Files {
+					assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), os.ModePerm))
+				}
+			}
+
+			tmpl, err := LoadTemplate(testTemplates, "testdata/templates", "test", dir)
+			assert.NoError(t, err)
+
+			var sb strings.Builder
+			assert.NoError(t, tmpl.Execute(&sb, struct{ Name string }{Name: "ella"}))
+			assert.Equal(t, tc.Expected, sb.String())
+		})
+	}
+}
+
+func TestLoadTemplateMissingDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing")
+
+	_, err := LoadTemplate(testTemplates, "testdata/templates", "test", dir)
+	assert.ErrorContains(t, err, "failed to load templates from "+dir)
+}
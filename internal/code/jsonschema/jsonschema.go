@@ -0,0 +1,68 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"compiler.ella.to/internal/ast"
+	"compiler.ella.to/internal/ast/astutil"
+	"compiler.ella.to/internal/code"
+	"compiler.ella.to/pkg/orderedmap"
+)
+
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+func init() {
+	code.Register(code.Target{
+		Name:     "jsonschema",
+		Suffixes: []string{".schema.json"},
+		New: func(cfg code.Config) (code.Generator, error) {
+			if cfg.Templates != "" {
+				return nil, fmt.Errorf("jsonschema target doesn't support templates")
+			}
+			return New(cfg.Pkg), nil
+		},
+	})
+}
+
+// New creates a generator which writes all the models and enums of the program as
+// JSON Schema definitions, e.g. a model User can be validated against
+// api.schema.json#/$defs/User
+func New(pkg string) code.Generator {
+	return code.GeneratorFunc(func(outFilename string, prog *ast.Program) error {
+		var buf bytes.Buffer
+
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(Parse(pkg, prog)); err != nil {
+			return err
+		}
+
+		return os.WriteFile(outFilename, buf.Bytes(), os.ModePerm)
+	})
+}
+
+// Parse creates the schema document of the program, models and enums of the
+// imported packages are defined by their qualified names, e.g. common.User
+func Parse(pkg string, prog *ast.Program) *Schema {
+	converter := Converter{RefPrefix: "#/$defs/"}
+
+	defs := orderedmap.New[*Schema]()
+
+	for _, enum := range astutil.GetEnums(prog) {
+		defs.Set(enum.Name.String(), converter.Enum(enum))
+	}
+
+	for _, model := range astutil.GetModels(prog) {
+		defs.Set(model.Name.String(), converter.Model(model))
+	}
+
+	return &Schema{
+		Schema: Draft,
+		Title:  pkg,
+		Defs:   defs,
+	}
+}
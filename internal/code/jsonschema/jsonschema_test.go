@@ -0,0 +1,76 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"compiler.ella.to/internal/code/jsonschema"
+	"compiler.ella.to/internal/parser"
+	"compiler.ella.to/internal/validator"
+)
+
+func TestParse(t *testing.T) {
+	prog, err := parser.ParseProgram(parser.New(`
+enum Level {
+	_
+	Debug
+	Info
+}
+
+model Base {
+	Id: string
+}
+
+# Config is loaded at startup
+model Config {
+	...Base
+	DisplayName: string {
+		Json = "name"
+		MaxLen = 32
+	}
+	Secret: string {
+		Json = false
+	}
+	Level?: Level
+	Tags: map<string, []string> {
+		JsonOmitEmpty = true
+	}
+	Created: timestamp
+	Retries: uint8 {
+		Max = 5
+	}
+}`))
+	assert.NoError(t, err)
+	assert.NoError(t, validator.Validate(prog))
+
+	content, err := json.Marshal(jsonschema.Parse("config", prog))
+	assert.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "config",
+		"$defs": {
+			"Level": {"type": "string", "enum": ["debug", "info"]},
+			"Base": {
+				"type": "object",
+				"properties": {"id": {"type": "string"}},
+				"required": ["id"]
+			},
+			"Config": {
+				"type": "object",
+				"description": "Config is loaded at startup",
+				"properties": {
+					"created": {"type": "string", "format": "date-time"},
+					"name": {"type": "string", "maxLength": 32},
+					"id": {"type": "string"},
+					"level": {"$ref": "#/$defs/Level"},
+					"retries": {"type": "integer", "format": "int32", "minimum": 0, "maximum": 5},
+					"tags": {"type": "object", "additionalProperties": {"type": "array", "items": {"type": "string"}}}
+				},
+				"required": ["created", "name", "id", "retries"]
+			}
+		}
+	}`, string(content))
+}
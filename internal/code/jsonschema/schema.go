@@ -0,0 +1,179 @@
+package jsonschema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"compiler.ella.to/internal/ast"
+	"compiler.ella.to/internal/ast/astutil"
+	"compiler.ella.to/pkg/orderedmap"
+	"compiler.ella.to/pkg/strcase"
+)
+
+// Schema is the subset of JSON Schema 2020-12 which is needed to describe ella's
+// types, https://json-schema.org/draft/2020-12/json-schema-core, it is also used by
+// OpenAPI 3.1 documents
+type Schema struct {
+	Schema               string                   `json:"$schema,omitempty" yaml:"$schema,omitempty"`
+	Ref                  string                   `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Title                string                   `json:"title,omitempty" yaml:"title,omitempty"`
+	Type                 string                   `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string                   `json:"format,omitempty" yaml:"format,omitempty"`
+	ContentEncoding      string                   `json:"contentEncoding,omitempty" yaml:"contentEncoding,omitempty"`
+	Description          string                   `json:"description,omitempty" yaml:"description,omitempty"`
+	Enum                 []string                 `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Properties           *orderedmap.Map[*Schema] `json:"properties,omitempty" yaml:"properties,omitempty"`
+	Required             []string                 `json:"required,omitempty" yaml:"required,omitempty"`
+	AdditionalProperties *Schema                  `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Items                *Schema                  `json:"items,omitempty" yaml:"items,omitempty"`
+	Minimum              *float64                 `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	Maximum              *float64                 `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	MinLength            *int64                   `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	MaxLength            *int64                   `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	MinItems             *int64                   `json:"minItems,omitempty" yaml:"minItems,omitempty"`
+	MaxItems             *int64                   `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	MinProperties        *int64                   `json:"minProperties,omitempty" yaml:"minProperties,omitempty"`
+	MaxProperties        *int64                   `json:"maxProperties,omitempty" yaml:"maxProperties,omitempty"`
+	Pattern              string                   `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	Defs                 *orderedmap.Map[*Schema] `json:"$defs,omitempty" yaml:"$defs,omitempty"`
+}
+
+// Converter creates the schemas of ella's types as they are encoded in json by
+// the generated Go code, models and enums are referred by RefPrefix and their
+// names, e.g. #/$defs/User
+type Converter struct {
+	RefPrefix string
+}
+
+func (c Converter) Ref(name string) *Schema {
+	return &Schema{Ref: c.RefPrefix + name}
+}
+
+func (c Converter) Type(typ ast.Type) *Schema {
+	switch typ := typ.(type) {
+	case *ast.CustomType:
+		return c.Ref(typ.String())
+	case *ast.Any:
+		return &Schema{}
+	case *ast.Int:
+		return &Schema{Type: "integer", Format: intFormat(typ.Size)}
+	case *ast.Uint:
+		return &Schema{Type: "integer", Format: intFormat(typ.Size), Minimum: float64Ptr(0)}
+	case *ast.Byte:
+		return &Schema{Type: "integer", Minimum: float64Ptr(0), Maximum: float64Ptr(255)}
+	case *ast.Float:
+		if typ.Size == 32 {
+			return &Schema{Type: "number", Format: "float"}
+		}
+		return &Schema{Type: "number", Format: "double"}
+	case *ast.String:
+		return &Schema{Type: "string"}
+	case *ast.Bool:
+		return &Schema{Type: "boolean"}
+	case *ast.Timestamp:
+		return &Schema{Type: "string", Format: "date-time"}
+	case *ast.Map:
+		return &Schema{Type: "object", AdditionalProperties: c.Type(typ.Value)}
+	case *ast.Array:
+		// []byte is encoded as base64 string
+		if _, ok := typ.Type.(*ast.Byte); ok {
+			return &Schema{Type: "string", ContentEncoding: "base64"}
+		}
+		return &Schema{Type: "array", Items: c.Type(typ.Type)}
+	case *ast.File:
+		return &Schema{Type: "string", Format: "binary"}
+	}
+
+	// This shouldn't happen as the validator should catch this any errors
+	panic(fmt.Sprintf("unknown type: %T", typ))
+}
+
+// Enum returns the schema of the enum, enums are encoded by the snake case
+// names of their keys
+func (c Converter) Enum(enum *ast.Enum) *Schema {
+	schema := &Schema{
+		Type:        "string",
+		Description: Doc(enum.Comments),
+	}
+
+	for _, set := range enum.Sets {
+		if set.Name.String() == "_" {
+			continue
+		}
+		schema.Enum = append(schema.Enum, strcase.ToSnake(set.Name.String()))
+	}
+
+	return schema
+}
+
+// Model returns the schema of the model, the model must be validated beforehand,
+// so the fields of the extended models are already merged into it
+func (c Converter) Model(model *ast.Model) *Schema {
+	schema := &Schema{
+		Type:        "object",
+		Description: Doc(model.Comments),
+		Properties:  orderedmap.New[*Schema](),
+	}
+
+	for _, field := range model.Fields {
+		name := astutil.JsonFieldName(field)
+		if name == "-" {
+			continue
+		}
+
+		schema.Properties.Set(name, c.field(field))
+		if !astutil.JsonOmitEmpty(field) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema
+}
+
+func (c Converter) field(field *ast.Field) *Schema {
+	schema := c.Type(field.Type)
+	schema.Description = Doc(field.Comments)
+
+	validation := astutil.ParseFieldValidation(field.Options)
+
+	switch field.Type.(type) {
+	case *ast.Array:
+		if schema.Type == "array" {
+			schema.MinItems = validation.MinLen
+			schema.MaxItems = validation.MaxLen
+		}
+	case *ast.Map:
+		schema.MinProperties = validation.MinLen
+		schema.MaxProperties = validation.MaxLen
+	case *ast.String:
+		schema.MinLength = validation.MinLen
+		schema.MaxLength = validation.MaxLen
+		schema.Pattern = validation.Pattern
+	case *ast.Int, *ast.Uint, *ast.Float, *ast.Byte:
+		if value, err := strconv.ParseFloat(validation.Min, 64); err == nil {
+			schema.Minimum = &value
+		}
+		if value, err := strconv.ParseFloat(validation.Max, 64); err == nil {
+			schema.Maximum = &value
+		}
+	}
+
+	return schema
+}
+
+func intFormat(size int) string {
+	if size > 32 {
+		return "int64"
+	}
+	return "int32"
+}
+
+func float64Ptr(value float64) *float64 {
+	return &value
+}
+
+// Doc joins the documentation comments into a description
+func Doc(comments ast.Comments) string {
+	return strings.TrimSpace(strings.Join(comments.Doc(), "\n"))
+}
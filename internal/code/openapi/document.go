@@ -0,0 +1,85 @@
+package openapi
+
+import (
+	"compiler.ella.to/internal/code/jsonschema"
+	"compiler.ella.to/pkg/orderedmap"
+)
+
+// The following types are the subset of OpenAPI 3.1 specification which is
+// needed to describe ella's http services, https://spec.openapis.org/oas/v3.1.0
+
+type Document struct {
+	OpenAPI    string                                       `json:"openapi" yaml:"openapi"`
+	Info       Info                                         `json:"info" yaml:"info"`
+	Tags       []Tag                                        `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Paths      *orderedmap.Map[*orderedmap.Map[*Operation]] `json:"paths" yaml:"paths"`
+	Components Components                                   `json:"components" yaml:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title" yaml:"title"`
+	Version string `json:"version" yaml:"version"`
+}
+
+type Tag struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+type Components struct {
+	Schemas   *orderedmap.Map[*jsonschema.Schema] `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+	Responses *orderedmap.Map[*Response]          `json:"responses,omitempty" yaml:"responses,omitempty"`
+}
+
+type Operation struct {
+	OperationID string                     `json:"operationId" yaml:"operationId"`
+	Tags        []string                   `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Summary     string                     `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string                     `json:"description,omitempty" yaml:"description,omitempty"`
+	Parameters  []*Parameter               `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *RequestBody               `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   *orderedmap.Map[*Response] `json:"responses" yaml:"responses"`
+}
+
+type Parameter struct {
+	Name        string             `json:"name" yaml:"name"`
+	In          string             `json:"in" yaml:"in"`
+	Description string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool               `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema      *jsonschema.Schema `json:"schema" yaml:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                        `json:"required,omitempty" yaml:"required,omitempty"`
+	Content  *orderedmap.Map[*MediaType] `json:"content" yaml:"content"`
+}
+
+type Response struct {
+	Ref         string                      `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Description string                      `json:"description,omitempty" yaml:"description,omitempty"`
+	Headers     *orderedmap.Map[*Header]    `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Content     *orderedmap.Map[*MediaType] `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+type Header struct {
+	Description string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Schema      *jsonschema.Schema `json:"schema" yaml:"schema"`
+}
+
+type MediaType struct {
+	Schema   *jsonschema.Schema         `json:"schema,omitempty" yaml:"schema,omitempty"`
+	Encoding *orderedmap.Map[*Encoding] `json:"encoding,omitempty" yaml:"encoding,omitempty"`
+	Examples *orderedmap.Map[*Example]  `json:"examples,omitempty" yaml:"examples,omitempty"`
+	// Events describes the data of each server-sent event by its name
+	Events *orderedmap.Map[*jsonschema.Schema] `json:"x-events,omitempty" yaml:"x-events,omitempty"`
+}
+
+type Encoding struct {
+	ContentType string `json:"contentType" yaml:"contentType"`
+}
+
+type Example struct {
+	Summary     string `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Value       any    `json:"value" yaml:"value"`
+}
@@ -0,0 +1,126 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"compiler.ella.to/internal/ast"
+	"compiler.ella.to/internal/ast/astutil"
+	"compiler.ella.to/internal/code"
+	"compiler.ella.to/internal/code/jsonschema"
+	"compiler.ella.to/pkg/orderedmap"
+)
+
+// Version is written as the version of the API, as schemas don't have one
+const Version = "0.0.0"
+
+func init() {
+	code.Register(code.Target{
+		Name:     "openapi",
+		Suffixes: []string{".openapi.json", ".openapi.yaml", ".openapi.yml"},
+		New: func(cfg code.Config) (code.Generator, error) {
+			if cfg.Templates != "" {
+				return nil, fmt.Errorf("openapi target doesn't support templates")
+			}
+			return New(cfg.Pkg), nil
+		},
+	})
+}
+
+// New creates a generator which describes all the http methods of the program as
+// an OpenAPI 3.1 document, the document is written as yaml if the output's
+// extension is .yaml or .yml, otherwise as json
+func New(pkg string) code.Generator {
+	return code.GeneratorFunc(func(outFilename string, prog *ast.Program) error {
+		document := Parse(pkg, prog)
+
+		var buf bytes.Buffer
+
+		switch filepath.Ext(outFilename) {
+		case ".yaml", ".yml":
+			enc := yaml.NewEncoder(&buf)
+			enc.SetIndent(2)
+			if err := enc.Encode(document); err != nil {
+				return err
+			}
+		default:
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(document); err != nil {
+				return err
+			}
+		}
+
+		return os.WriteFile(outFilename, buf.Bytes(), os.ModePerm)
+	})
+}
+
+// Parse creates the OpenAPI document of the program, models and enums are added as
+// components' schemas and declared errors are added as components' responses
+func Parse(pkg string, prog *ast.Program) *Document {
+	document := &Document{
+		OpenAPI: "3.1.0",
+		Info: Info{
+			Title:   pkg,
+			Version: Version,
+		},
+		Paths: orderedmap.New[*orderedmap.Map[*Operation]](),
+		Components: Components{
+			Schemas:   orderedmap.New[*jsonschema.Schema](),
+			Responses: orderedmap.New[*Response](),
+		},
+	}
+
+	for _, enum := range astutil.GetEnums(prog) {
+		document.Components.Schemas.Set(enum.Name.String(), converter.Enum(enum))
+	}
+
+	models := astutil.CreateModelTypeMap(astutil.GetModels(prog))
+	for _, model := range astutil.GetModels(prog) {
+		document.Components.Schemas.Set(model.Name.String(), converter.Model(model))
+	}
+
+	document.Components.Schemas.Set(errorSchemaName, errorSchema())
+
+	errResponses := errorResponses(astutil.GetCustomErrors(prog))
+	for _, resp := range errResponses {
+		document.Components.Responses.Set(resp.name, resp.response)
+	}
+
+	for _, service := range astutil.GetServices(prog) {
+		var hasHttpMethods bool
+
+		for _, method := range service.Methods {
+			if method.Type != ast.MethodHTTP {
+				continue
+			}
+			hasHttpMethods = true
+
+			path := operationPath(astutil.HttpPath(service, method))
+			httpMethod, op := operation(service, method, models, errResponses)
+
+			item, ok := document.Paths.Get(path)
+			if !ok {
+				item = orderedmap.New[*Operation]()
+				document.Paths.Set(path, item)
+			}
+			item.Set(strings.ToLower(httpMethod), op)
+		}
+
+		if hasHttpMethods {
+			document.Tags = append(document.Tags, Tag{
+				Name:        service.Name.String(),
+				Description: jsonschema.Doc(service.Comments),
+			})
+		}
+	}
+
+	return document
+}
@@ -0,0 +1,118 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"compiler.ella.to/internal/code/openapi"
+	"compiler.ella.to/internal/parser"
+	"compiler.ella.to/internal/validator"
+)
+
+func TestParse(t *testing.T) {
+	prog, err := parser.ParseProgram(parser.New(`
+error ErrNotFound { Code = 1000 HttpStatus = NotFound Msg = "not found" }
+error ErrGone { Code = 1001 HttpStatus = NotFound Msg = "gone" }
+
+enum Status {
+	_
+	Active
+	Archived
+}
+
+model Filter {
+	Name: string {
+		MaxLen = 10
+	}
+	Status?: Status
+}
+
+service Users {
+	# Search finds users
+	http Search(filter: Filter, limit?: int32) => (names: []string) {
+		HttpMethod = "GET"
+	}
+	http Update(id: string, name: string) => (updated: bool) {
+		HttpMethod = "PUT"
+		Path = "/{id}"
+	}
+	http Watch() => (names: stream string)
+	http Upload(files: file, kind: string) => (size: int64)
+	http Download() => (content: stream []byte) {
+		ContentType = "text/csv"
+	}
+	rpc Ping()
+}`))
+	assert.NoError(t, err)
+	assert.NoError(t, validator.Validate(prog))
+
+	content, err := json.Marshal(openapi.Parse("api", prog))
+	assert.NoError(t, err)
+
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Summary    string `json:"summary"`
+			Parameters []struct {
+				Name     string `json:"name"`
+				In       string `json:"in"`
+				Required bool   `json:"required"`
+			} `json:"parameters"`
+			RequestBody struct {
+				Content map[string]json.RawMessage `json:"content"`
+			} `json:"requestBody"`
+			Responses map[string]struct {
+				Ref     string                     `json:"$ref"`
+				Content map[string]json.RawMessage `json:"content"`
+			} `json:"responses"`
+		} `json:"paths"`
+		Components struct {
+			Schemas   map[string]json.RawMessage `json:"schemas"`
+			Responses map[string]json.RawMessage `json:"responses"`
+		} `json:"components"`
+	}
+	assert.NoError(t, json.Unmarshal(content, &doc))
+
+	assert.Len(t, doc.Paths, 5)
+
+	search := doc.Paths["/ella/http/Users/Search"]["get"]
+	assert.Equal(t, "Search finds users", search.Summary)
+	assert.Len(t, search.Parameters, 3)
+	assert.Equal(t, "filter.name", search.Parameters[0].Name)
+	assert.True(t, search.Parameters[0].Required)
+	assert.Equal(t, "filter.status", search.Parameters[1].Name)
+	assert.False(t, search.Parameters[1].Required)
+	assert.Equal(t, "limit", search.Parameters[2].Name)
+	assert.Equal(t, "#/components/responses/NotFound", search.Responses["404"].Ref)
+
+	update := doc.Paths["/ella/http/Users/{id}"]["put"]
+	assert.Equal(t, "path", update.Parameters[0].In)
+	assert.JSONEq(t, `{"schema": {"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]}}`, string(update.RequestBody.Content["application/json"]))
+
+	assert.Contains(t, doc.Paths["/ella/http/Users/Watch"]["post"].Responses["200"].Content, "text/event-stream")
+	assert.Contains(t, doc.Paths["/ella/http/Users/Upload"]["post"].RequestBody.Content, "multipart/form-data")
+	assert.Contains(t, doc.Paths["/ella/http/Users/Download"]["post"].Responses["200"].Content, "text/csv")
+
+	assert.JSONEq(t, `{"type": "string", "enum": ["active", "archived"]}`, string(doc.Components.Schemas["Status"]))
+	assert.JSONEq(t, `{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string", "maxLength": 10},
+			"status": {"$ref": "#/components/schemas/Status"}
+		},
+		"required": ["name"]
+	}`, string(doc.Components.Schemas["Filter"]))
+	assert.JSONEq(t, `{
+		"description": "Not Found",
+		"content": {
+			"application/json": {
+				"schema": {"$ref": "#/components/schemas/Error"},
+				"examples": {
+					"ErrNotFound": {"summary": "not found", "value": {"code": 1000, "message": "not found"}},
+					"ErrGone": {"summary": "gone", "value": {"code": 1001, "message": "gone"}}
+				}
+			}
+		}
+	}`, string(doc.Components.Responses["NotFound"]))
+}
@@ -0,0 +1,288 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"compiler.ella.to/internal/ast"
+	"compiler.ella.to/internal/ast/astutil"
+	"compiler.ella.to/internal/code/jsonschema"
+	"compiler.ella.to/pkg/orderedmap"
+	"compiler.ella.to/pkg/strcase"
+)
+
+// operationPath converts the route of the method into OpenAPI's path template,
+// wildcards such as {path...} are not supported, so they are written as {path}
+func operationPath(path string) string {
+	for _, param := range astutil.ParseHttpPathParams(path) {
+		if param.Wildcard {
+			path = strings.Replace(path, "{"+param.Name+"...}", "{"+param.Name+"}", 1)
+		}
+	}
+
+	return path
+}
+
+func operation(service *ast.Service, method *ast.Method, models map[string]*ast.Model, errorResponses []errorResponse) (httpMethod string, op *Operation) {
+	op = &Operation{
+		OperationID: strcase.ToPascal(service.Name.String()) + "_" + strcase.ToPascal(method.Name.String()),
+		Tags:        []string{service.Name.String()},
+		Responses:   orderedmap.New[*Response](),
+	}
+
+	if lines := method.Comments.Doc(); len(lines) > 0 {
+		op.Summary = strings.TrimSpace(lines[0])
+		op.Description = strings.TrimSpace(strings.Join(lines[1:], "\n"))
+	}
+
+	httpMethod = astutil.HttpMethod(method)
+	pathParams := astutil.ParseHttpPathParams(astutil.HttpPath(service, method))
+
+	var isUpload bool
+	payload := &jsonschema.Schema{Type: "object", Properties: orderedmap.New[*jsonschema.Schema]()}
+
+	for _, arg := range method.Args {
+		if _, ok := arg.Type.(*ast.File); ok {
+			isUpload = true
+			continue
+		}
+
+		// stream args are sent as WebSocket messages
+		if arg.Stream {
+			continue
+		}
+
+		if param, ok := astutil.FindHttpPathParam(pathParams, arg); ok {
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name:     param.Name,
+				In:       "path",
+				Required: true,
+				Schema:   converter.Type(arg.Type),
+			})
+			continue
+		}
+
+		name := strcase.ToSnake(arg.Name.String())
+
+		if httpMethod == http.MethodGet {
+			op.Parameters = append(op.Parameters, queryParameters(name, arg.Type, !arg.Optional, models, map[string]bool{})...)
+			continue
+		}
+
+		payload.Properties.Set(name, converter.Type(arg.Type))
+		if !arg.Optional {
+			payload.Required = append(payload.Required, name)
+		}
+	}
+
+	switch {
+	case isUpload:
+		// upload methods are always served as POST, args are sent as json in the
+		// first part and then all the files are sent as parts named files
+		httpMethod = http.MethodPost
+
+		properties := orderedmap.New[*jsonschema.Schema]()
+		properties.Set("payload", payload)
+		properties.Set("files", &jsonschema.Schema{Type: "array", Items: &jsonschema.Schema{Type: "string", Format: "binary"}})
+
+		encoding := orderedmap.New[*Encoding]()
+		encoding.Set("payload", &Encoding{ContentType: "application/json"})
+
+		content := orderedmap.New[*MediaType]()
+		content.Set("multipart/form-data", &MediaType{
+			Schema:   &jsonschema.Schema{Type: "object", Properties: properties, Required: []string{"payload"}},
+			Encoding: encoding,
+		})
+
+		op.RequestBody = &RequestBody{Required: true, Content: content}
+	case payload.Properties.Len() > 0:
+		content := orderedmap.New[*MediaType]()
+		content.Set("application/json", &MediaType{Schema: payload})
+
+		op.RequestBody = &RequestBody{Required: len(payload.Required) > 0, Content: content}
+	}
+
+	switch streamArg, streamRet := astutil.StreamArg(method), streamReturn(method); {
+	case streamArg != nil:
+		op.Responses.Set("101", websocketResponse(streamArg, streamRet))
+	case streamRet != nil && isArrayOf[*ast.Byte](streamRet.Type):
+		op.Responses.Set("200", binaryResponse(method))
+	case streamRet != nil:
+		op.Responses.Set("200", eventStreamResponse(streamRet))
+	default:
+		op.Responses.Set("200", jsonResponse(method))
+	}
+
+	for _, resp := range errorResponses {
+		op.Responses.Set(strconv.Itoa(resp.status), &Response{Ref: "#/components/responses/" + resp.name})
+	}
+
+	op.Responses.Set("default", errorContent("Unexpected error, e.g. validation of args failed"))
+
+	return httpMethod, op
+}
+
+// queryParameters returns the parameters of the arg when it is sent as query string,
+// models' fields are flattened into separate parameters by their json path, e.g. filter.name
+func queryParameters(name string, typ ast.Type, required bool, models map[string]*ast.Model, visited map[string]bool) []*Parameter {
+	customType, ok := typ.(*ast.CustomType)
+	if !ok {
+		return []*Parameter{{Name: name, In: "query", Required: required, Schema: converter.Type(typ)}}
+	}
+
+	model, ok := models[customType.String()]
+	if !ok || visited[model.Name.String()] {
+		return []*Parameter{{Name: name, In: "query", Required: required, Schema: converter.Type(typ)}}
+	}
+
+	visited[model.Name.String()] = true
+	defer delete(visited, model.Name.String())
+
+	var params []*Parameter
+	for _, field := range model.Fields {
+		fieldName := astutil.JsonFieldName(field)
+		if fieldName == "-" {
+			continue
+		}
+
+		fieldParams := queryParameters(name+"."+fieldName, field.Type, required && !field.Optional, models, visited)
+		if len(fieldParams) == 1 && fieldParams[0].Description == "" {
+			fieldParams[0].Description = jsonschema.Doc(field.Comments)
+		}
+
+		params = append(params, fieldParams...)
+	}
+
+	return params
+}
+
+func streamReturn(method *ast.Method) *ast.Return {
+	for _, ret := range method.Returns {
+		if ret.Stream {
+			return ret
+		}
+	}
+
+	return nil
+}
+
+func jsonResponse(method *ast.Method) *Response {
+	schema := &jsonschema.Schema{Type: "object", Properties: orderedmap.New[*jsonschema.Schema]()}
+	for _, ret := range method.Returns {
+		name := strcase.ToSnake(ret.Name.String())
+		schema.Properties.Set(name, converter.Type(ret.Type))
+		schema.Required = append(schema.Required, name)
+	}
+
+	content := orderedmap.New[*MediaType]()
+	content.Set("application/json", &MediaType{Schema: schema})
+
+	return &Response{Description: "OK", Content: content}
+}
+
+func eventStreamResponse(ret *ast.Return) *Response {
+	name := strcase.ToCamel(ret.Name.String())
+
+	events := orderedmap.New[*jsonschema.Schema]()
+	events.Set(name, converter.Type(ret.Type))
+	events.Set("done", &jsonschema.Schema{Type: "object"})
+	events.Set("error", converter.Ref(errorSchemaName))
+
+	content := orderedmap.New[*MediaType]()
+	content.Set("text/event-stream", &MediaType{
+		Schema: &jsonschema.Schema{Type: "string"},
+		Events: events,
+	})
+
+	return &Response{
+		Description: fmt.Sprintf("Server-Sent Events, each %s event carries an item as json data, "+
+			"the stream ends with either a done event or an error event which carries an Error", name),
+		Content: content,
+	}
+}
+
+func binaryResponse(method *ast.Method) *Response {
+	headers := orderedmap.New[*Header]()
+	headers.Set("Content-Disposition", &Header{
+		Description: "the name of the file, e.g. attachment; filename=report.pdf",
+		Schema:      &jsonschema.Schema{Type: "string"},
+	})
+
+	content := orderedmap.New[*MediaType]()
+	content.Set(astutil.ParseMethodOptions(method.Options).ContentType, &MediaType{
+		Schema: &jsonschema.Schema{Type: "string", Format: "binary"},
+	})
+
+	return &Response{Description: "OK", Headers: headers, Content: content}
+}
+
+func websocketResponse(arg *ast.Arg, ret *ast.Return) *Response {
+	description := fmt.Sprintf("Switching Protocols, the connection is upgraded to WebSocket and "+
+		"the client sends each item of %s as a json message (%s)", arg.Name, arg.Type)
+	if ret != nil {
+		description += fmt.Sprintf(", the server sends each item of %s as a json message (%s)", ret.Name, ret.Type)
+	}
+
+	return &Response{Description: description}
+}
+
+func errorContent(description string) *Response {
+	content := orderedmap.New[*MediaType]()
+	content.Set("application/json", &MediaType{Schema: converter.Ref(errorSchemaName)})
+
+	return &Response{Description: description, Content: content}
+}
+
+// errorResponse groups the declared errors which share the same http status, so
+// they can be documented as a single response with an example for each error
+type errorResponse struct {
+	name     string
+	status   int
+	response *Response
+}
+
+func errorResponses(customErrors []*ast.CustomError) []errorResponse {
+	sort.SliceStable(customErrors, func(i, j int) bool {
+		return customErrors[i].HttpStatus < customErrors[j].HttpStatus
+	})
+
+	var responses []errorResponse
+	for _, customError := range customErrors {
+		if len(responses) == 0 || responses[len(responses)-1].status != customError.HttpStatus {
+			resp := errorContent(http.StatusText(customError.HttpStatus))
+			content, _ := resp.Content.Get("application/json")
+			content.Examples = orderedmap.New[*Example]()
+
+			responses = append(responses, errorResponse{
+				name:     ast.HttpStatusCode2String[customError.HttpStatus],
+				status:   customError.HttpStatus,
+				response: resp,
+			})
+		}
+
+		content, _ := responses[len(responses)-1].response.Content.Get("application/json")
+		content.Examples.Set(customError.Name.String(), &Example{
+			Summary:     customError.Msg.Value,
+			Description: jsonschema.Doc(customError.Comments),
+			Value: map[string]any{
+				"code":    customError.Code,
+				"message": customError.Msg.Value,
+			},
+		})
+	}
+
+	return responses
+}
+
+func isArrayOf[T ast.Type](typ ast.Type) bool {
+	arr, ok := typ.(*ast.Array)
+	if !ok {
+		return false
+	}
+
+	_, ok = arr.Type.(T)
+	return ok
+}
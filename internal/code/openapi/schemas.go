@@ -0,0 +1,37 @@
+package openapi
+
+import (
+	"compiler.ella.to/internal/code/jsonschema"
+	"compiler.ella.to/pkg/orderedmap"
+)
+
+// errorSchemaName is the name of the schema which describes the json body of
+// all the errors returned by the server
+const errorSchemaName = "Error"
+
+var converter = jsonschema.Converter{RefPrefix: "#/components/schemas/"}
+
+func errorSchema() *jsonschema.Schema {
+	fields := orderedmap.New[*jsonschema.Schema]()
+	fields.Set("field", &jsonschema.Schema{Type: "string", Description: "json path of the invalid field, e.g. address.street or tags[1]"})
+	fields.Set("message", &jsonschema.Schema{Type: "string"})
+
+	properties := orderedmap.New[*jsonschema.Schema]()
+	properties.Set("code", &jsonschema.Schema{Type: "integer", Format: "int64", Description: "code of the error, declared errors have positive codes and built-in errors have negative codes"})
+	properties.Set("message", &jsonschema.Schema{Type: "string"})
+	properties.Set("fields", &jsonschema.Schema{
+		Type: "array",
+		Items: &jsonschema.Schema{
+			Type:       "object",
+			Properties: fields,
+			Required:   []string{"field", "message"},
+		},
+	})
+
+	return &jsonschema.Schema{
+		Type:        "object",
+		Description: "Error is returned by all the methods when the call fails",
+		Properties:  properties,
+		Required:    []string{"code", "message"},
+	}
+}
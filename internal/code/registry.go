@@ -0,0 +1,85 @@
+package code
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Import is a package imported by the generated package, Out is the output
+// file which the imported package is generated into
+type Import struct {
+	Name string
+	Out  string
+}
+
+// Config is used by the targets to create the generator of a package
+type Config struct {
+	Pkg     string // name of the package
+	Out     string // output file of the package
+	Imports []Import
+	// Templates is the directory of .tmpl files which replace the embedded
+	// templates with the same name or are added to them, see LoadTemplate
+	Templates string
+}
+
+// Target is a registered code generator, it is selected either by its name or
+// by the suffix of the output file
+type Target struct {
+	Name string
+	// Suffixes of the output files which are generated by the target, e.g. .go
+	Suffixes []string
+	// PackageSuffix is the suffix of the files which imported packages are generated
+	// into, e.g. .gen.go, the targets without it write a single file which already
+	// includes the declarations of imported packages
+	PackageSuffix string
+	New           func(cfg Config) (Generator, error)
+}
+
+var targets = make(map[string]Target)
+
+// Register adds the target to the registry, usually called from the init function
+// of the generator's package, it panics if the name is already registered
+func Register(target Target) {
+	if _, ok := targets[target.Name]; ok {
+		panic(fmt.Sprintf("target %s is already registered", target.Name))
+	}
+
+	targets[target.Name] = target
+}
+
+// LookupTarget returns the target registered by the name
+func LookupTarget(name string) (Target, bool) {
+	target, ok := targets[name]
+	return target, ok
+}
+
+// MatchTarget returns the target which generates the output file, if many targets
+// generate the same extension, the longest suffix wins, e.g. .openapi.json
+// is preferred over .json
+func MatchTarget(out string) (Target, bool) {
+	var result Target
+	var length int
+
+	for _, target := range targets {
+		for _, suffix := range target.Suffixes {
+			if strings.HasSuffix(out, suffix) && len(suffix) > length {
+				result = target
+				length = len(suffix)
+			}
+		}
+	}
+
+	return result, length > 0
+}
+
+// TargetNames returns the sorted names of all the registered targets
+func TargetNames() []string {
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
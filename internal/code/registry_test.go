@@ -0,0 +1,77 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// withTargets replaces the registered targets during the test
+func withTargets(t *testing.T, registered ...Target) {
+	t.Helper()
+
+	saved := targets
+	t.Cleanup(func() { targets = saved })
+
+	targets = make(map[string]Target)
+	for _, target := range registered {
+		Register(target)
+	}
+}
+
+func TestMatchTarget(t *testing.T) {
+	withTargets(t,
+		Target{Name: "go", Suffixes: []string{".go"}},
+		Target{Name: "jsonschema", Suffixes: []string{".json"}},
+		Target{Name: "openapi", Suffixes: []string{".openapi.json", ".openapi.yaml"}},
+	)
+
+	testCases := []struct {
+		Out    string
+		Target string
+	}{
+		{Out: "./api/api.gen.go", Target: "go"},
+		{Out: "./api/api.schema.json", Target: "jsonschema"},
+		{Out: "./api/api.openapi.json", Target: "openapi"},
+		{Out: "./api/api.openapi.yaml", Target: "openapi"},
+		{Out: "./api/api.yaml"},
+		{Out: "./api/api.ts"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Out, func(t *testing.T) {
+			target, ok := MatchTarget(tc.Out)
+			assert.Equal(t, tc.Target != "", ok)
+			assert.Equal(t, tc.Target, target.Name)
+		})
+	}
+}
+
+func TestLookupTarget(t *testing.T) {
+	withTargets(t,
+		Target{Name: "go", Suffixes: []string{".go"}},
+		Target{Name: "ts", Suffixes: []string{".ts"}},
+	)
+
+	target, ok := LookupTarget("ts")
+	assert.True(t, ok)
+	assert.Equal(t, []string{".ts"}, target.Suffixes)
+
+	_, ok = LookupTarget(".ts")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"go", "ts"}, TargetNames())
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	withTargets(t, Target{Name: "go", Suffixes: []string{".go"}})
+
+	assert.PanicsWithValue(t, "target go is already registered", func() {
+		Register(Target{Name: "go", Suffixes: []string{".gen.go"}})
+	})
+
+	// the registered target is kept
+	target, ok := LookupTarget("go")
+	assert.True(t, ok)
+	assert.Equal(t, []string{".go"}, target.Suffixes)
+}
@@ -9,6 +9,7 @@ import (
 type Constant struct {
 	Name  string
 	Value string
+	Doc   []string
 }
 
 type Constants []Constant
@@ -18,6 +19,7 @@ func (c *Constants) Parse(prog *ast.Program) error {
 		return Constant{
 			Name:  constant.Name.String(),
 			Value: getValue(constant.Value),
+			Doc:   constant.Comments.Doc(),
 		}
 	})
 
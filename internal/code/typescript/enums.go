@@ -10,17 +10,19 @@ import (
 type EnumKeyValue struct {
 	Name  string
 	Value string
+	Doc   []string
 }
 
 type Enum struct {
 	Name string
 	Keys []EnumKeyValue
+	Doc  []string
 }
 
 type Enums []Enum
 
 func (e *Enums) Parse(prog *ast.Program) error {
-	*e = sliceutil.Mapper(astutil.GetEnums(prog), func(enum *ast.Enum) Enum {
+	*e = sliceutil.Mapper(astutil.GetLocalEnums(prog), func(enum *ast.Enum) Enum {
 		return Enum{
 			Name: enum.Name.String(),
 			Keys: sliceutil.Mapper(sliceutil.Filter(enum.Sets, func(set *ast.EnumSet) bool {
@@ -29,8 +31,10 @@ func (e *Enums) Parse(prog *ast.Program) error {
 				return EnumKeyValue{
 					Name:  set.Name.String(),
 					Value: strcase.ToSnake(set.Name.String()),
+					Doc:   set.Comments.Doc(),
 				}
 			}),
+			Doc: enum.Comments.Doc(),
 		}
 	})
 
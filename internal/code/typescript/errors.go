@@ -11,6 +11,7 @@ import (
 type CustomError struct {
 	Name string
 	Code int64
+	Doc  []string
 }
 
 type CustomErrors []CustomError
@@ -20,6 +21,7 @@ func (c *CustomErrors) Parse(prog *ast.Program) error {
 		return CustomError{
 			Name: customError.Name.String(),
 			Code: customError.Code,
+			Doc:  customError.Comments.Doc(),
 		}
 	})
 
@@ -1,20 +1,38 @@
 package typescript
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"compiler.ella.to/internal/ast"
 	"compiler.ella.to/internal/ast/astutil"
 	"compiler.ella.to/pkg/sliceutil"
 	"compiler.ella.to/pkg/strcase"
 )
 
+type ModelFieldCheck struct {
+	Cond string // typescript expression which is true when the value is invalid
+	Msg  string
+}
+
 type ModelField struct {
-	Name string
-	Type string
+	Name     string
+	Type     string
+	Optional bool
+	Checks   []ModelFieldCheck
+	Pattern  ModelPattern
+	Nested   string // typescript function which validates the nested models of the field
+	Doc      []string
+}
+
+type ModelPattern struct {
+	Name  string
+	Value string
 }
 
 type ModelFields []ModelField
 
-func (m *ModelFields) Parse(message *ast.Model) error {
+func (m *ModelFields) Parse(message *ast.Model, isModelType func(value string) bool) error {
 	*m = sliceutil.Filter(sliceutil.Mapper(message.Fields, func(field *ast.Field) ModelField {
 		name := strcase.ToSnake(field.Name.String())
 		for _, opt := range field.Options {
@@ -32,8 +50,13 @@ func (m *ModelFields) Parse(message *ast.Model) error {
 		}
 
 		return ModelField{
-			Name: name,
-			Type: parseType(field.Type),
+			Name:     name,
+			Type:     parseType(field.Type),
+			Optional: field.Optional,
+			Checks:   parseModelFieldChecks(message.Name.String(), name, field, isModelType),
+			Pattern:  parseModelFieldPattern(message.Name.String(), field),
+			Nested:   parseModelFieldNested(field.Type, isModelType),
+			Doc:      field.Comments.Doc(),
 		}
 	}), func(field ModelField) bool {
 		return field.Name != ""
@@ -44,20 +67,169 @@ func (m *ModelFields) Parse(message *ast.Model) error {
 type Model struct {
 	Name   string
 	Fields ModelFields
+	Doc    []string
+}
+
+// Patterns returns all the regular expressions used by the model's fields
+func (m Model) Patterns() []ModelPattern {
+	var patterns []ModelPattern
+
+	for _, field := range m.Fields {
+		if field.Pattern.Value != "" {
+			patterns = append(patterns, field.Pattern)
+		}
+	}
+
+	return patterns
 }
 
 type Models []Model
 
 func (m *Models) Parse(prog *ast.Program) error {
-	*m = sliceutil.Mapper(astutil.GetModels(prog), func(message *ast.Model) Model {
+	isModelType := astutil.CreateIsModelTypeFunc(astutil.GetModels(prog))
+
+	*m = sliceutil.Mapper(astutil.GetLocalModels(prog), func(message *ast.Model) Model {
 		msg := Model{
 			Name: message.Name.String(),
+			Doc:  message.Comments.Doc(),
 		}
 
-		msg.Fields.Parse(message)
+		msg.Fields.Parse(message, isModelType)
 
 		return msg
 	})
 
 	return nil
 }
+
+func parseModelFieldPattern(modelName string, field *ast.Field) ModelPattern {
+	validation := astutil.ParseFieldValidation(field.Options)
+	if validation.Pattern == "" {
+		return ModelPattern{}
+	}
+
+	// json string is a valid typescript string literal
+	value, _ := json.Marshal(validation.Pattern)
+
+	return ModelPattern{
+		Name:  "pattern" + modelName + field.Name.String(),
+		Value: string(value),
+	}
+}
+
+// parseModelFieldChecks converts the validation options of the field into typescript
+// expressions, the messages are the same as the ones generated for Go, so both client
+// and server report the same validation errors
+func parseModelFieldChecks(modelName string, name string, field *ast.Field, isModelType func(value string) bool) []ModelFieldCheck {
+	validation := astutil.ParseFieldValidation(field.Options)
+	if validation.IsEmpty() {
+		return nil
+	}
+
+	var checks []ModelFieldCheck
+
+	value := "m." + name
+
+	if validation.Required {
+		var cond string
+
+		switch field.Type.(type) {
+		case *ast.Array:
+			cond = fmt.Sprintf("(%s ?? []).length === 0", value)
+		case *ast.Map:
+			cond = fmt.Sprintf("Object.keys(%s ?? {}).length === 0", value)
+		case *ast.String, *ast.Timestamp:
+			cond = "!" + value
+		default:
+			// models and enums, the zero value of required enums is not one of their keys,
+			// so it's never encoded and the same as an absent value in Go
+			cond = fmt.Sprintf("%s === undefined || %s === null", value, value)
+		}
+
+		checks = append(checks, ModelFieldCheck{Cond: cond, Msg: validation.RequiredMsg()})
+	}
+
+	if validation.Min != "" {
+		checks = append(checks, ModelFieldCheck{Cond: value + " < " + validation.Min, Msg: validation.MinMsg()})
+	}
+
+	if validation.Max != "" {
+		checks = append(checks, ModelFieldCheck{Cond: value + " > " + validation.Max, Msg: validation.MaxMsg()})
+	}
+
+	var length string
+	switch field.Type.(type) {
+	case *ast.String:
+		// counts unicode code points, the same as utf8.RuneCountInString in Go
+		length = fmt.Sprintf("[...(%s ?? \"\")].length", value)
+	case *ast.Map:
+		length = fmt.Sprintf("Object.keys(%s ?? {}).length", value)
+	default:
+		length = fmt.Sprintf("(%s ?? []).length", value)
+	}
+
+	if validation.MinLen != nil {
+		checks = append(checks, ModelFieldCheck{Cond: fmt.Sprintf("%s < %d", length, *validation.MinLen), Msg: validation.MinLenMsg()})
+	}
+
+	if validation.MaxLen != nil {
+		checks = append(checks, ModelFieldCheck{Cond: fmt.Sprintf("%s > %d", length, *validation.MaxLen), Msg: validation.MaxLenMsg()})
+	}
+
+	if validation.Pattern != "" {
+		pattern := parseModelFieldPattern(modelName, field).Name
+
+		var cond string
+		if _, ok := field.Type.(*ast.String); ok {
+			cond = fmt.Sprintf("!!%s && !%s.test(%s)", value, pattern, value)
+		} else {
+			cond = fmt.Sprintf("!(%s ?? []).every((item: string) => %s.test(item))", value, pattern)
+		}
+
+		checks = append(checks, ModelFieldCheck{Cond: cond, Msg: validation.PatternMsg()})
+	}
+
+	if validation.MaxSize != nil {
+		var size string
+		if _, ok := field.Type.(*ast.String); ok {
+			size = fmt.Sprintf("utf8Size(%s)", value)
+		} else {
+			size = fmt.Sprintf("bytesSize(%s)", value)
+		}
+
+		checks = append(checks, ModelFieldCheck{Cond: fmt.Sprintf("%s > %d", size, *validation.MaxSize), Msg: validation.MaxSizeMsg()})
+	}
+
+	if field.Optional {
+		for i := range checks {
+			checks[i].Cond = fmt.Sprintf("%s !== undefined && %s !== null && (%s)", value, value, checks[i].Cond)
+		}
+	}
+
+	return checks
+}
+
+// parseModelFieldNested returns a typescript function which validates the models
+// stored in the field, it returns an empty string if the field has no models
+func parseModelFieldNested(typ ast.Type, isModelType func(value string) bool) string {
+	switch typ := typ.(type) {
+	case *ast.CustomType:
+		if isModelType(typ.String()) {
+			// validators of imported models are exported by their own module, e.g. common.validateUser
+			if pkg, name := astutil.SplitQualifiedName(typ.String()); pkg != "" {
+				return pkg + ".validate" + name
+			}
+			return "validate" + typ.String()
+		}
+	case *ast.Array:
+		if inner := parseModelFieldNested(typ.Type, isModelType); inner != "" {
+			return "validateArrayOf(" + inner + ")"
+		}
+	case *ast.Map:
+		if inner := parseModelFieldNested(typ.Value, isModelType); inner != "" {
+			return "validateMapOf(" + inner + ")"
+		}
+	}
+
+	return ""
+}
@@ -2,6 +2,7 @@ package typescript
 
 import (
 	"fmt"
+	"strings"
 
 	"compiler.ella.to/internal/ast"
 	"compiler.ella.to/internal/ast/astutil"
@@ -10,8 +11,9 @@ import (
 )
 
 type Arg struct {
-	Name string
-	Type string
+	Name     string
+	Type     string
+	Optional bool
 }
 
 type Return struct {
@@ -22,14 +24,17 @@ type Return struct {
 type Method struct {
 	Name        string
 	ServiceName string
+	Path        string // http route, placeholders are named after args, e.g. /users/{user_id}
 	Options     astutil.MethodOptions
-	Type        string // normal, binary, stream, fileupload
+	Type        string // normal, binary, stream, bidistream, fileupload
 	Args        []Arg
 	Returns     []Return
+	StreamArg   Arg // the arg which is sent over WebSocket in bidistream methods
+	Doc         []string
 }
 
 func (m Method) PathValue() string {
-	return fmt.Sprintf("/ella/http/%s/%s", strcase.ToPascal(m.ServiceName), strcase.ToPascal(m.Name))
+	return m.Path
 }
 
 func (m Method) ArgsName() string {
@@ -42,19 +47,31 @@ func (m Method) ReturnsName() string {
 		return "Blob"
 	case "stream":
 		return "Subscription<" + m.Returns[0].Type + ">"
+	case "bidistream":
+		return "BidiStream<" + m.StreamArg.Type + ", " + m.Returns[0].Type + ">"
 	default:
 		return fmt.Sprintf("Service%s%sReturns", m.ServiceName, strcase.ToPascal(m.Name))
 	}
 }
 
+// StreamEventName returns the name of the events sent by the server, which
+// is the name of the stream return in camelCase, the same as Go's server
+func (m Method) StreamEventName() string {
+	return strcase.ToCamel(m.Returns[0].Name)
+}
+
 func (m Method) HasReturn() bool {
-	return !(m.IsBinaryStream() || m.IsStream())
+	return !(m.IsBinaryStream() || m.IsStream() || m.IsBidiStream())
 }
 
 func (m Method) IsStream() bool {
 	return m.Type == "stream"
 }
 
+func (m Method) IsBidiStream() bool {
+	return m.Type == "bidistream"
+}
+
 func (m Method) IsBinaryStream() bool {
 	return m.Type == "binary"
 }
@@ -64,12 +81,13 @@ func (m Method) IsFileUpload() bool {
 }
 
 func (m Method) NeedReturnInterface() bool {
-	return !(m.IsStream() || m.IsBinaryStream())
+	return !(m.IsStream() || m.IsBinaryStream() || m.IsBidiStream())
 }
 
 type HttpService struct {
 	Name    string
 	Methods []Method
+	Doc     []string
 }
 
 type HttpServices []HttpService
@@ -86,7 +104,10 @@ func (s *HttpServices) Parse(prog *ast.Program) error {
 
 				m.ServiceName = service.Name.String()
 				m.Name = strcase.ToCamel(method.Name.String())
+				m.Doc = method.Comments.Doc()
+				m.Path = parseMethodPath(service, method)
 				m.Options = astutil.ParseMethodOptions(method.Options)
+				m.Options.HttpMethod = astutil.HttpMethod(method)
 
 				m.Args = sliceutil.Mapper(sliceutil.Filter(
 					method.Args,
@@ -96,17 +117,28 @@ func (s *HttpServices) Parse(prog *ast.Program) error {
 							return false
 						}
 
+						if arg.Stream {
+							m.StreamArg = Arg{
+								Name: strcase.ToSnake(arg.Name.String()),
+								Type: parseType(arg.Type),
+							}
+							return false
+						}
+
 						return arg.Type.String() != "file"
 					},
 				), func(arg *ast.Arg) Arg {
 					return Arg{
-						Name: strcase.ToSnake(arg.Name.String()),
-						Type: parseType(arg.Type),
+						Name:     strcase.ToSnake(arg.Name.String()),
+						Type:     parseType(arg.Type),
+						Optional: arg.Optional,
 					}
 				})
 				m.Returns = sliceutil.Mapper(method.Returns, func(ret *ast.Return) Return {
 					typ := parseType(ret.Type)
-					if ret.Stream && typ == "byte[]" {
+					if m.StreamArg.Name != "" {
+						m.Type = "bidistream"
+					} else if ret.Stream && typ == "byte[]" {
 						m.Type = "binary"
 					} else if ret.Stream {
 						m.Type = "stream"
@@ -122,8 +154,32 @@ func (s *HttpServices) Parse(prog *ast.Program) error {
 
 				return m
 			}),
+			Doc: service.Comments.Doc(),
 		}
 	})
 
 	return nil
 }
+
+// parseMethodPath renames the placeholders of the method's http path to the
+// name of args, so the client can replace them with args' values
+func parseMethodPath(service *ast.Service, method *ast.Method) string {
+	path := astutil.HttpPath(service, method)
+	params := astutil.ParseHttpPathParams(path)
+
+	for _, arg := range method.Args {
+		param, ok := astutil.FindHttpPathParam(params, arg)
+		if !ok {
+			continue
+		}
+
+		suffix := "}"
+		if param.Wildcard {
+			suffix = "...}"
+		}
+
+		path = strings.Replace(path, "{"+param.Name+suffix, "{"+strcase.ToSnake(arg.Name.String())+suffix, 1)
+	}
+
+	return path
+}
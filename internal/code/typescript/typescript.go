@@ -3,6 +3,8 @@ package typescript
 import (
 	"embed"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"compiler.ella.to/internal/ast"
 	"compiler.ella.to/internal/code"
@@ -11,7 +13,14 @@ import (
 //go:embed templates/*.tmpl
 var files embed.FS
 
+// Import is a generated module of an imported schema package
+type Import struct {
+	Name string
+	Path string
+}
+
 type Typescript struct {
+	Imports      []Import
 	Constants    Constants
 	Enums        Enums
 	Models       Models
@@ -30,15 +39,39 @@ func (t *Typescript) Parse(prog *ast.Program) error {
 	)
 }
 
-func New() code.Generator {
+func init() {
+	code.Register(code.Target{
+		Name:          "ts",
+		Suffixes:      []string{".ts"},
+		PackageSuffix: ".ts",
+		New: func(cfg code.Config) (code.Generator, error) {
+			imports := make([]Import, 0, len(cfg.Imports))
+			for _, dep := range cfg.Imports {
+				path, err := importPath(filepath.Dir(cfg.Out), dep.Out)
+				if err != nil {
+					return nil, err
+				}
+				imports = append(imports, Import{Name: dep.Name, Path: path})
+			}
+
+			return New(cfg.Templates, imports...), nil
+		},
+	})
+}
+
+// New creates the generator of the Typescript module, templates is the directory
+// which overrides the embedded templates, see code.LoadTemplate
+func New(templates string, imports ...Import) code.Generator {
 	return code.GeneratorFunc(func(outFilename string, prog *ast.Program) error {
-		typescript := Typescript{}
+		typescript := Typescript{
+			Imports: imports,
+		}
 
 		if err := typescript.Parse(prog); err != nil {
 			return err
 		}
 
-		tmpl, err := code.LoadTemplate(files, "templates", "typescript")
+		tmpl, err := code.LoadTemplate(files, "templates", "typescript", templates)
 		if err != nil {
 			return err
 		}
@@ -56,3 +89,18 @@ func New() code.Generator {
 		return nil
 	})
 }
+
+// importPath returns the relative path of the module which is used in import statement
+func importPath(fromDir, filename string) (string, error) {
+	rel, err := filepath.Rel(fromDir, strings.TrimSuffix(filename, ".ts"))
+	if err != nil {
+		return "", err
+	}
+
+	rel = filepath.ToSlash(rel)
+	if !strings.HasPrefix(rel, ".") {
+		rel = "./" + rel
+	}
+
+	return rel, nil
+}
@@ -0,0 +1,121 @@
+package typescript_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"compiler.ella.to/internal/code/typescript"
+	"compiler.ella.to/internal/parser"
+	"compiler.ella.to/internal/validator"
+)
+
+func TestModelValidators(t *testing.T) {
+	prog, err := parser.ParseProgram(parser.New(`
+enum Role {
+	_
+	Admin
+	User
+}
+
+model Address {
+	Street: string {
+		Required
+	}
+}
+
+model User {
+	Name: string {
+		Required
+		Pattern = "^[a-z]+$"
+	}
+	Age: int8 {
+		Min = 18
+		Max = 120
+	}
+	Role: Role {
+		Required
+	}
+	Tags: []string {
+		MaxLen = 2
+		Pattern = "^#"
+	}
+	Nick?: string {
+		MinLen = 2
+	}
+	Address: Address
+	Friends: map<string, User>
+}`))
+	assert.NoError(t, err)
+	assert.NoError(t, validator.Validate(prog))
+
+	out := filepath.Join(t.TempDir(), "user.ts")
+	assert.NoError(t, typescript.New("").Generate(out, prog))
+
+	content, err := os.ReadFile(out)
+	assert.NoError(t, err)
+
+	assert.Contains(t, string(content), `
+export function validateAddress(m: Address): FieldError[] {
+	if (m === undefined || m === null) {
+		return [];
+	}
+
+	const errs: FieldError[] = [];
+
+	if (!m.street) {
+		errs.push({ field: "street", message: "is required" });
+	}
+
+	return errs;
+}`)
+
+	assert.Contains(t, string(content), `
+const patternUserName = new RegExp("^[a-z]+$");
+
+const patternUserTags = new RegExp("^#");
+`)
+
+	assert.Contains(t, string(content), `
+export function validateUser(m: User): FieldError[] {
+	if (m === undefined || m === null) {
+		return [];
+	}
+
+	const errs: FieldError[] = [];
+
+	if (!m.name) {
+		errs.push({ field: "name", message: "is required" });
+	} else if (!!m.name && !patternUserName.test(m.name)) {
+		errs.push({ field: "name", message: "must match pattern ^[a-z]+$" });
+	}
+
+	if (m.age < 18) {
+		errs.push({ field: "age", message: "must be greater than or equal to 18" });
+	} else if (m.age > 120) {
+		errs.push({ field: "age", message: "must be less than or equal to 120" });
+	}
+
+	if (m.role === undefined || m.role === null) {
+		errs.push({ field: "role", message: "is required" });
+	}
+
+	if ((m.tags ?? []).length > 2) {
+		errs.push({ field: "tags", message: "length must be at most 2" });
+	} else if (!(m.tags ?? []).every((item: string) => patternUserTags.test(item))) {
+		errs.push({ field: "tags", message: "must match pattern ^#" });
+	}
+
+	if (m.nick !== undefined && m.nick !== null && ([...(m.nick ?? "")].length < 2)) {
+		errs.push({ field: "nick", message: "length must be at least 2" });
+	}
+
+	errs.push(...prefixFieldErrors("address", validateAddress(m.address)));
+
+	errs.push(...prefixFieldErrors("friends", validateMapOf(validateUser)(m.friends)));
+
+	return errs;
+}`)
+}
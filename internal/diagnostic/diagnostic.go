@@ -0,0 +1,159 @@
+package diagnostic
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"compiler.ella.to/internal/token"
+)
+
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Warning:
+		return "warning"
+	default:
+		return "error"
+	}
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Span is the byte offsets of the source which the diagnostic refers to
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Diagnostic is a problem found in the schema, Line and Column are 1-based and
+// they are zero if the problem is not related to a specific position
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Filename string   `json:"file,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Span     Span     `json:"span"`
+	Message  string   `json:"message"`
+}
+
+// Errorf creates an error diagnostic at the token's position
+func Errorf(tok *token.Token, format string, args ...any) *Diagnostic {
+	return New(Error, tok, fmt.Sprintf(format, args...))
+}
+
+func New(severity Severity, tok *token.Token, msg string) *Diagnostic {
+	d := &Diagnostic{
+		Severity: severity,
+		Message:  msg,
+	}
+
+	if tok != nil {
+		d.Filename = tok.Filename
+		d.Line = tok.Line
+		d.Column = tok.Column
+		d.Span = Span{Start: tok.Start, End: tok.End}
+	}
+
+	return d
+}
+
+// Position returns the position in file:line:column format, parts which
+// are not known are omitted
+func (d *Diagnostic) Position() string {
+	var parts []string
+
+	if d.Filename != "" {
+		parts = append(parts, d.Filename)
+	}
+
+	if d.Line > 0 {
+		parts = append(parts, fmt.Sprintf("%d:%d", d.Line, d.Column))
+	}
+
+	return strings.Join(parts, ":")
+}
+
+// Error returns the diagnostic in compiler style, e.g. api.ella:3:5: error: unknown type Foo
+func (d *Diagnostic) Error() string {
+	if pos := d.Position(); pos != "" {
+		return fmt.Sprintf("%s: %s: %s", pos, d.Severity, d.Message)
+	}
+
+	return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+}
+
+type Diagnostics []*Diagnostic
+
+func (d Diagnostics) Error() string {
+	lines := make([]string, 0, len(d))
+	for _, diag := range d {
+		lines = append(lines, diag.Error())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Add appends the diagnostics of the error, see From
+func (d *Diagnostics) Add(err error) {
+	*d = append(*d, From(err)...)
+}
+
+// Err returns nil if there is no diagnostic, so it can be returned as an error
+func (d Diagnostics) Err() error {
+	if len(d) == 0 {
+		return nil
+	}
+
+	return d
+}
+
+// Sort orders the diagnostics by their file and position, diagnostics
+// without position come first
+func (d Diagnostics) Sort() {
+	sort.SliceStable(d, func(i, j int) bool {
+		if d[i].Filename != d[j].Filename {
+			return d[i].Filename < d[j].Filename
+		}
+		return d[i].Span.Start < d[j].Span.Start
+	})
+}
+
+// Diagnoser is implemented by the errors which can be converted into a diagnostic
+type Diagnoser interface {
+	Diagnostic() *Diagnostic
+}
+
+// From converts the error into diagnostics, errors which are not diagnostics
+// are converted into a single diagnostic without position
+func From(err error) Diagnostics {
+	if err == nil {
+		return nil
+	}
+
+	var diags Diagnostics
+	if errors.As(err, &diags) {
+		return diags
+	}
+
+	var diag *Diagnostic
+	if errors.As(err, &diag) {
+		return Diagnostics{diag}
+	}
+
+	var diagnoser Diagnoser
+	if errors.As(err, &diagnoser) {
+		return Diagnostics{diagnoser.Diagnostic()}
+	}
+
+	return Diagnostics{New(Error, nil, err.Error())}
+}
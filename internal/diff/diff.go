@@ -0,0 +1,381 @@
+package diff
+
+import (
+	"fmt"
+	"strconv"
+
+	"compiler.ella.to/internal/ast"
+	"compiler.ella.to/internal/ast/astutil"
+)
+
+type Kind int
+
+const (
+	Compatible Kind = iota
+	Breaking
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Breaking:
+		return "breaking"
+	default:
+		return "compatible"
+	}
+}
+
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Change is a difference between two versions of a schema, Subject is the
+// declaration which is changed, e.g. message User or service Users
+type Change struct {
+	Kind    Kind   `json:"kind"`
+	Subject string `json:"subject"`
+	Message string `json:"message"`
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s: %s", c.Kind, c.Subject, c.Message)
+}
+
+type Changes []Change
+
+// Breaking returns the changes which break the existing clients or servers
+func (c Changes) Breaking() Changes {
+	var breaking Changes
+	for _, change := range c {
+		if change.Kind == Breaking {
+			breaking = append(breaking, change)
+		}
+	}
+	return breaking
+}
+
+type changes struct {
+	list    Changes
+	subject string
+}
+
+func (c *changes) add(kind Kind, format string, args ...any) {
+	c.list = append(c.list, Change{Kind: kind, Subject: c.subject, Message: fmt.Sprintf(format, args...)})
+}
+
+// Compare returns the changes from old to new program, both programs must be validated,
+// so the fields of extended messages are merged and constants are resolved. Changes
+// are ordered by the declarations of the old program, followed by the new declarations.
+// Comments and formatting are ignored.
+func Compare(old, new *ast.Program) Changes {
+	c := &changes{}
+
+	compareConstants(c, astutil.GetConstants(old), astutil.GetConstants(new))
+	compareEnums(c, astutil.GetEnums(old), astutil.GetEnums(new))
+	compareModels(c, astutil.GetModels(old), astutil.GetModels(new))
+	compareServices(c, astutil.GetServices(old), astutil.GetServices(new))
+	compareErrors(c, astutil.GetCustomErrors(old), astutil.GetCustomErrors(new))
+
+	return c.list
+}
+
+// compareNamed calls fn for every pair of nodes with the same name, removed and added
+// nodes are passed with nil counterpart
+func compareNamed[T any](old, new []T, name func(T) string, fn func(oldNode, newNode T, oldOk, newOk bool)) {
+	var zero T
+
+	newMap := make(map[string]T, len(new))
+	for _, node := range new {
+		newMap[name(node)] = node
+	}
+
+	oldMap := make(map[string]T, len(old))
+	for _, node := range old {
+		oldMap[name(node)] = node
+		newNode, ok := newMap[name(node)]
+		fn(node, newNode, true, ok)
+	}
+
+	for _, node := range new {
+		if _, ok := oldMap[name(node)]; !ok {
+			fn(zero, node, false, true)
+		}
+	}
+}
+
+func compareConstants(c *changes, old, new []*ast.Const) {
+	compareNamed(old, new, func(constant *ast.Const) string { return constant.Name.String() }, func(oldConst, newConst *ast.Const, oldOk, newOk bool) {
+		switch {
+		case !newOk:
+			c.subject = "const " + oldConst.Name.String()
+			c.add(Breaking, "const is removed")
+		case !oldOk:
+			c.subject = "const " + newConst.Name.String()
+			c.add(Compatible, "const is added")
+		case oldConst.Value.String() != newConst.Value.String():
+			c.subject = "const " + newConst.Name.String()
+			c.add(Compatible, "value is changed from %s to %s", oldConst.Value, newConst.Value)
+		}
+	})
+}
+
+func compareEnums(c *changes, old, new []*ast.Enum) {
+	compareNamed(old, new, func(enum *ast.Enum) string { return enum.Name.String() }, func(oldEnum, newEnum *ast.Enum, oldOk, newOk bool) {
+		switch {
+		case !newOk:
+			c.subject = "enum " + oldEnum.Name.String()
+			c.add(Breaking, "enum is removed")
+			return
+		case !oldOk:
+			c.subject = "enum " + newEnum.Name.String()
+			c.add(Compatible, "enum is added")
+			return
+		}
+
+		c.subject = "enum " + newEnum.Name.String()
+
+		if oldEnum.Size != newEnum.Size {
+			c.add(Breaking, "size is changed from int%d to int%d", oldEnum.Size, newEnum.Size)
+		}
+
+		compareNamed(oldEnum.Sets, newEnum.Sets, func(set *ast.EnumSet) string { return set.Name.String() }, func(oldSet, newSet *ast.EnumSet, oldOk, newOk bool) {
+			switch {
+			case !newOk:
+				c.add(Breaking, "key %s is removed", oldSet.Name)
+			case !oldOk:
+				c.add(Compatible, "key %s is added", newSet.Name)
+			case oldSet.Value.Value != newSet.Value.Value:
+				c.add(Breaking, "value of key %s is changed from %d to %d", newSet.Name, oldSet.Value.Value, newSet.Value.Value)
+			}
+		})
+	})
+}
+
+func compareModels(c *changes, old, new []*ast.Model) {
+	compareNamed(old, new, func(model *ast.Model) string { return model.Name.String() }, func(oldModel, newModel *ast.Model, oldOk, newOk bool) {
+		switch {
+		case !newOk:
+			c.subject = "message " + oldModel.Name.String()
+			c.add(Breaking, "message is removed")
+			return
+		case !oldOk:
+			c.subject = "message " + newModel.Name.String()
+			c.add(Compatible, "message is added")
+			return
+		}
+
+		c.subject = "message " + newModel.Name.String()
+
+		compareNamed(oldModel.Fields, newModel.Fields, func(field *ast.Field) string { return field.Name.String() }, func(oldField, newField *ast.Field, oldOk, newOk bool) {
+			switch {
+			case !newOk:
+				c.add(Breaking, "field %s is removed", oldField.Name)
+				return
+			case !oldOk && newField.Optional:
+				c.add(Compatible, "optional field %s is added", newField.Name)
+				return
+			case !oldOk:
+				c.add(Breaking, "required field %s is added", newField.Name)
+				return
+			}
+
+			if oldField.Type.String() != newField.Type.String() {
+				c.add(Breaking, "type of field %s is changed from %s to %s", newField.Name, oldField.Type, newField.Type)
+			}
+
+			if oldField.Optional != newField.Optional {
+				c.add(Breaking, "field %s is changed from %s to %s", newField.Name, optionality(oldField.Optional), optionality(newField.Optional))
+			}
+
+			if oldTag, newTag := astutil.JsonFieldName(oldField), astutil.JsonFieldName(newField); oldTag != newTag {
+				c.add(Breaking, "json name of field %s is changed from %q to %q", newField.Name, oldTag, newTag)
+			}
+
+			compareValidation(c, newField.Name.String(), astutil.ParseFieldValidation(oldField.Options), astutil.ParseFieldValidation(newField.Options))
+		})
+	})
+}
+
+func optionality(optional bool) string {
+	if optional {
+		return "optional"
+	}
+	return "required"
+}
+
+// compareValidation reports the validation rules which reject values accepted before
+// as breaking, and loosened rules as compatible
+func compareValidation(c *changes, field string, old, new astutil.FieldValidation) {
+	if old.Required != new.Required {
+		c.add(kindOf(new.Required), "Required of field %s is changed from %t to %t", field, old.Required, new.Required)
+	}
+
+	compareBound := func(name, old, new string, stricter func(old, new float64) bool) {
+		if old == new {
+			return
+		}
+
+		kind := Compatible
+		if old == "" {
+			kind = Breaking
+		} else if new != "" {
+			oldValue, _ := strconv.ParseFloat(old, 64)
+			newValue, _ := strconv.ParseFloat(new, 64)
+			kind = kindOf(stricter(oldValue, newValue))
+		}
+
+		c.add(kind, "%s of field %s is changed from %s to %s", name, field, boundLiteral(old), boundLiteral(new))
+	}
+
+	lower := func(old, new float64) bool { return new > old }
+	upper := func(old, new float64) bool { return new < old }
+
+	compareBound("Min", old.Min, new.Min, lower)
+	compareBound("Max", old.Max, new.Max, upper)
+	compareBound("MinLen", int64Literal(old.MinLen), int64Literal(new.MinLen), lower)
+	compareBound("MaxLen", int64Literal(old.MaxLen), int64Literal(new.MaxLen), upper)
+	compareBound("MaxSize", int64Literal(old.MaxSize), int64Literal(new.MaxSize), upper)
+
+	if old.Pattern != new.Pattern {
+		c.add(kindOf(new.Pattern != ""), "Pattern of field %s is changed from %s to %s", field, boundLiteral(strconv.Quote(old.Pattern)), boundLiteral(strconv.Quote(new.Pattern)))
+	}
+}
+
+func kindOf(breaking bool) Kind {
+	if breaking {
+		return Breaking
+	}
+	return Compatible
+}
+
+func int64Literal(value *int64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatInt(*value, 10)
+}
+
+func boundLiteral(value string) string {
+	if value == "" || value == `""` {
+		return "none"
+	}
+	return value
+}
+
+func compareServices(c *changes, old, new []*ast.Service) {
+	compareNamed(old, new, func(service *ast.Service) string { return service.Name.String() }, func(oldService, newService *ast.Service, oldOk, newOk bool) {
+		switch {
+		case !newOk:
+			c.subject = "service " + oldService.Name.String()
+			c.add(Breaking, "service is removed")
+			return
+		case !oldOk:
+			c.subject = "service " + newService.Name.String()
+			c.add(Compatible, "service is added")
+			return
+		}
+
+		c.subject = "service " + newService.Name.String()
+
+		compareNamed(oldService.Methods, newService.Methods, func(method *ast.Method) string { return method.Name.String() }, func(oldMethod, newMethod *ast.Method, oldOk, newOk bool) {
+			switch {
+			case !newOk:
+				c.add(Breaking, "method %s is removed", oldMethod.Name)
+				return
+			case !oldOk:
+				c.add(Compatible, "method %s is added", newMethod.Name)
+				return
+			}
+
+			if oldMethod.Type != newMethod.Type {
+				c.add(Breaking, "method %s is changed from %s to %s", newMethod.Name, oldMethod.Type, newMethod.Type)
+				return
+			}
+
+			if newMethod.Type == ast.MethodHTTP {
+				if oldHttpMethod, newHttpMethod := astutil.HttpMethod(oldMethod), astutil.HttpMethod(newMethod); oldHttpMethod != newHttpMethod {
+					c.add(Breaking, "http method of %s is changed from %s to %s", newMethod.Name, oldHttpMethod, newHttpMethod)
+				}
+
+				if oldPath, newPath := astutil.HttpPath(oldService, oldMethod), astutil.HttpPath(newService, newMethod); oldPath != newPath {
+					c.add(Breaking, "path of %s is changed from %s to %s", newMethod.Name, oldPath, newPath)
+				}
+			}
+
+			compareArgs(c, newMethod.Name.String(), oldMethod.Args, newMethod.Args)
+			compareReturns(c, newMethod.Name.String(), oldMethod.Returns, newMethod.Returns)
+		})
+	})
+}
+
+func compareArgs(c *changes, method string, old, new ast.Args) {
+	compareNamed(old, new, func(arg *ast.Arg) string { return arg.Name.String() }, func(oldArg, newArg *ast.Arg, oldOk, newOk bool) {
+		switch {
+		case !newOk:
+			c.add(Breaking, "arg %s of method %s is removed", oldArg.Name, method)
+			return
+		case !oldOk && newArg.Optional:
+			c.add(Compatible, "optional arg %s of method %s is added", newArg.Name, method)
+			return
+		case !oldOk:
+			c.add(Breaking, "required arg %s of method %s is added", newArg.Name, method)
+			return
+		}
+
+		if oldArg.Type.String() != newArg.Type.String() || oldArg.Stream != newArg.Stream {
+			c.add(Breaking, "type of arg %s of method %s is changed from %s to %s", newArg.Name, method, argType(oldArg.Type, oldArg.Stream), argType(newArg.Type, newArg.Stream))
+		}
+
+		if oldArg.Optional != newArg.Optional {
+			c.add(kindOf(!newArg.Optional), "arg %s of method %s is changed from %s to %s", newArg.Name, method, optionality(oldArg.Optional), optionality(newArg.Optional))
+		}
+	})
+}
+
+func compareReturns(c *changes, method string, old, new ast.Returns) {
+	compareNamed(old, new, func(ret *ast.Return) string { return ret.Name.String() }, func(oldRet, newRet *ast.Return, oldOk, newOk bool) {
+		switch {
+		case !newOk:
+			c.add(Breaking, "return %s of method %s is removed", oldRet.Name, method)
+		case !oldOk:
+			c.add(Compatible, "return %s of method %s is added", newRet.Name, method)
+		case oldRet.Type.String() != newRet.Type.String() || oldRet.Stream != newRet.Stream:
+			c.add(Breaking, "type of return %s of method %s is changed from %s to %s", newRet.Name, method, argType(oldRet.Type, oldRet.Stream), argType(newRet.Type, newRet.Stream))
+		}
+	})
+}
+
+func argType(typ ast.Type, stream bool) string {
+	if stream {
+		return "stream " + typ.String()
+	}
+	return typ.String()
+}
+
+func compareErrors(c *changes, old, new []*ast.CustomError) {
+	compareNamed(old, new, func(customError *ast.CustomError) string { return customError.Name.String() }, func(oldError, newError *ast.CustomError, oldOk, newOk bool) {
+		switch {
+		case !newOk:
+			c.subject = "error " + oldError.Name.String()
+			c.add(Breaking, "error is removed")
+			return
+		case !oldOk:
+			c.subject = "error " + newError.Name.String()
+			c.add(Compatible, "error is added")
+			return
+		}
+
+		c.subject = "error " + newError.Name.String()
+
+		if oldError.Code != newError.Code {
+			c.add(Breaking, "code is changed from %d to %d", oldError.Code, newError.Code)
+		}
+
+		if oldError.HttpStatus != newError.HttpStatus {
+			c.add(Breaking, "http status is changed from %s to %s", ast.HttpStatusCode2String[oldError.HttpStatus], ast.HttpStatusCode2String[newError.HttpStatus])
+		}
+
+		if oldError.Msg.Value != newError.Msg.Value {
+			c.add(Compatible, "message is changed from %q to %q", oldError.Msg.Value, newError.Msg.Value)
+		}
+	})
+}
@@ -0,0 +1,214 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"compiler.ella.to/internal/ast"
+	"compiler.ella.to/internal/diff"
+	"compiler.ella.to/internal/parser"
+	"compiler.ella.to/internal/validator"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	prog, err := parser.ParseProgram(parser.New(input))
+	assert.NoError(t, err)
+	assert.NoError(t, validator.Validate(prog))
+	return prog
+}
+
+func TestCompare(t *testing.T) {
+	testCases := []struct {
+		Name    string
+		Old     string
+		New     string
+		Changes []string
+	}{
+		{
+			Name: "fields",
+			Old: `
+model User {
+	Id: string
+	Name: string
+	Age: int32
+	Email: string
+}`,
+			New: `
+model User {
+	Id: string
+	Name: string {
+		Json = "full_name"
+	}
+	Age: int64
+	Nick?: string
+	Phone: string
+}`,
+			Changes: []string{
+				`breaking: message User: json name of field Name is changed from "name" to "full_name"`,
+				`breaking: message User: type of field Age is changed from int32 to int64`,
+				`breaking: message User: field Email is removed`,
+				`compatible: message User: optional field Nick is added`,
+				`breaking: message User: required field Phone is added`,
+			},
+		},
+		{
+			Name: "extended fields",
+			Old: `
+model Base {
+	Id: string
+}
+
+model User {
+	...Base
+	Name: string
+}`,
+			New: `
+model User {
+	Id: string
+	Name: string
+}`,
+			Changes: []string{
+				`breaking: message Base: message is removed`,
+			},
+		},
+		{
+			Name: "validation",
+			Old: `
+model User {
+	Name: string {
+		MinLen = 2
+		MaxLen = 10
+	}
+	Age: int8 {
+		Max = 100
+	}
+}`,
+			New: `
+model User {
+	Name: string {
+		MinLen = 1
+		MaxLen = 5
+		Pattern = "^[a-z]+$"
+	}
+	Age: int8
+}`,
+			Changes: []string{
+				`compatible: message User: MinLen of field Name is changed from 2 to 1`,
+				`breaking: message User: MaxLen of field Name is changed from 10 to 5`,
+				`breaking: message User: Pattern of field Name is changed from none to "^[a-z]+$"`,
+				`compatible: message User: Max of field Age is changed from 100 to none`,
+			},
+		},
+		{
+			Name: "enums",
+			Old: `
+enum Status {
+	Active
+	Deleted
+	Banned
+}`,
+			New: `
+enum Status {
+	Pending
+	Active
+	Deleted
+}`,
+			Changes: []string{
+				`breaking: enum Status: value of key Active is changed from 0 to 1`,
+				`breaking: enum Status: value of key Deleted is changed from 1 to 2`,
+				`breaking: enum Status: key Banned is removed`,
+				`compatible: enum Status: key Pending is added`,
+			},
+		},
+		{
+			Name: "services",
+			Old: `
+service Users {
+	http Get(id: string) => (name: string)
+	http List() => (names: []string) {
+		HttpMethod = "GET"
+	}
+	rpc Delete(id: string)
+}`,
+			New: `
+service Users {
+	http Get(id: string, full?: bool, lang: string) => (name: string, age: int32) {
+		HttpMethod = "GET"
+	}
+	http List() => (names: []string) {
+		HttpMethod = "GET"
+		Path = "/users"
+	}
+	rpc Create(name: string)
+}`,
+			Changes: []string{
+				`breaking: service Users: http method of Get is changed from POST to GET`,
+				`compatible: service Users: optional arg full of method Get is added`,
+				`breaking: service Users: required arg lang of method Get is added`,
+				`compatible: service Users: return age of method Get is added`,
+				`breaking: service Users: path of List is changed from /ella/http/Users/List to /ella/http/Users/users`,
+				`breaking: service Users: method Delete is removed`,
+				`compatible: service Users: method Create is added`,
+			},
+		},
+		{
+			Name: "errors",
+			Old: `
+error ErrNotFound { Code = 1000 HttpStatus = NotFound Msg = "not found" }
+error ErrConflict { Code = 1001 HttpStatus = Conflict Msg = "conflict" }`,
+			New: `
+error ErrNotFound { Code = 1002 HttpStatus = Gone Msg = "missing" }`,
+			Changes: []string{
+				`breaking: error ErrNotFound: code is changed from 1000 to 1002`,
+				`breaking: error ErrNotFound: http status is changed from NotFound to Gone`,
+				`compatible: error ErrNotFound: message is changed from "not found" to "missing"`,
+				`breaking: error ErrConflict: error is removed`,
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			var changes []string
+			for _, change := range diff.Compare(parse(t, testCase.Old), parse(t, testCase.New)) {
+				changes = append(changes, change.String())
+			}
+
+			assert.Equal(t, testCase.Changes, changes)
+		})
+	}
+}
+
+func TestCompareSnapshot(t *testing.T) {
+	prog := parse(t, `
+const Max = 10
+
+enum Status {
+	Active
+	Deleted
+}
+
+model User {
+	Name: string {
+		MaxLen = Max
+	}
+	Tags: map<string, []Status>
+	Age?: int32
+}
+
+service Users {
+	http Get(id: string) => (user: User, events: stream Status)
+}
+
+error ErrNotFound { Code = 1000 HttpStatus = NotFound Msg = "not found" }`)
+
+	text, err := prog.MarshalText()
+	assert.NoError(t, err)
+
+	var snapshot ast.Program
+	assert.NoError(t, snapshot.UnmarshalText(text))
+
+	assert.Equal(t, prog.String(), snapshot.String())
+	assert.Empty(t, diff.Compare(&snapshot, prog))
+}
@@ -0,0 +1,408 @@
+package loader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"compiler.ella.to/internal/ast"
+	"compiler.ella.to/internal/ast/astutil"
+	"compiler.ella.to/internal/diagnostic"
+	"compiler.ella.to/internal/parser"
+	"compiler.ella.to/internal/scanner"
+	"compiler.ella.to/internal/validator"
+)
+
+// Package is a set of ella files which are generated together, every imported
+// package consists of the imported files of a single directory and it is named
+// after that directory
+type Package struct {
+	Name    string
+	Dir     string // empty for the root package, as its files can be spread in many directories
+	Files   []string
+	Imports []*Package
+	Program *ast.Program
+}
+
+var packageNameRegex = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Load parses the files of the root package and all the packages imported by them,
+// imported declarations are linked under qualified names, e.g. common.User, and each
+// package is validated. Packages are returned in dependency order, which means
+// the root package is always the last one. Problems of the schema are returned as
+// diagnostic.Diagnostics, all the files are parsed before the first one is reported.
+func Load(name string, filenames []string) ([]*Package, error) {
+	return LoadOverlay(name, filenames, nil)
+}
+
+// Overlay maps the absolute path of files to their content, which is used
+// instead of the content on disk, e.g. unsaved files of an editor
+type Overlay map[string][]byte
+
+func (o Overlay) readFile(filename string) ([]byte, error) {
+	if abs, err := filepath.Abs(filename); err == nil {
+		if content, ok := o[abs]; ok {
+			return content, nil
+		}
+	}
+
+	return os.ReadFile(filename)
+}
+
+func (o Overlay) stat(filename string) error {
+	if abs, err := filepath.Abs(filename); err == nil {
+		if _, ok := o[abs]; ok {
+			return nil
+		}
+	}
+
+	_, err := os.Stat(filename)
+	return err
+}
+
+// LoadOverlay is similar to Load, but the files in overlay are read from it
+func LoadOverlay(name string, filenames []string, overlay Overlay) ([]*Package, error) {
+	root := &Package{
+		Name:  name,
+		Files: filenames,
+	}
+
+	rootDirs := make(map[string]struct{})
+	for _, filename := range filenames {
+		abs, err := filepath.Abs(filename)
+		if err != nil {
+			return nil, err
+		}
+		rootDirs[filepath.Dir(abs)] = struct{}{}
+	}
+
+	pkgsByDir := make(map[string]*Package)
+	pkgsByName := map[string]*Package{name: root}
+
+	type pendingFile struct {
+		pkg      *Package
+		filename string
+	}
+
+	var pending []pendingFile
+	for _, filename := range filenames {
+		pending = append(pending, pendingFile{pkg: root, filename: filename})
+	}
+
+	var diags diagnostic.Diagnostics
+
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+
+		prog, err := parseFile(overlay, current.filename)
+		if err != nil {
+			diags.Add(err)
+			continue
+		}
+
+		for _, imp := range astutil.GetImports(prog) {
+			path := imp.Path.Value
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(filepath.Dir(current.filename), path)
+			}
+
+			if err := overlay.stat(path); err != nil {
+				diags = append(diags, diagnostic.Errorf(imp.Path.Token, "failed to import %s: %s", imp.Path.Value, err))
+				continue
+			}
+
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				return nil, err
+			}
+
+			dir := filepath.Dir(abs)
+			if _, ok := rootDirs[dir]; (ok && current.pkg == root) || dir == current.pkg.Dir {
+				diags = append(diags, diagnostic.Errorf(imp.Path.Token, "can't import %s which belongs to the same package", imp.Path.Value))
+				continue
+			} else if ok {
+				diags = append(diags, diagnostic.Errorf(imp.Path.Token, "can't import %s, package %s is part of an import cycle", imp.Path.Value, current.pkg.Name))
+				continue
+			}
+
+			pkg, ok := pkgsByDir[dir]
+			if !ok {
+				pkg = &Package{
+					Name: filepath.Base(dir),
+					Dir:  dir,
+				}
+
+				if !packageNameRegex.MatchString(pkg.Name) {
+					diags = append(diags, diagnostic.Errorf(imp.Path.Token, "package name %s of imported file %s must be lowercase letters, digits and underscores", pkg.Name, imp.Path.Value))
+					continue
+				}
+
+				if other, ok := pkgsByName[pkg.Name]; ok && other == root {
+					diags = append(diags, diagnostic.Errorf(imp.Path.Token, "package %s of imported file %s has the same name as the root package", pkg.Name, imp.Path.Value))
+					continue
+				} else if ok {
+					diags = append(diags, diagnostic.Errorf(imp.Path.Token, "package %s is defined by both %s and %s directories", pkg.Name, other.Dir, pkg.Dir))
+					continue
+				}
+
+				pkgsByDir[dir] = pkg
+				pkgsByName[pkg.Name] = pkg
+			}
+
+			if !containsFile(pkg.Files, abs) {
+				pkg.Files = append(pkg.Files, abs)
+				pending = append(pending, pendingFile{pkg: pkg, filename: abs})
+			}
+
+			if !containsPackage(current.pkg.Imports, pkg) {
+				current.pkg.Imports = append(current.pkg.Imports, pkg)
+			}
+		}
+	}
+
+	if len(diags) > 0 {
+		return nil, diags
+	}
+
+	pkgs, err := sortPackages(root)
+	if err != nil {
+		return nil, diagnostic.From(err)
+	}
+
+	// packages depending on a broken package are not linked, as their
+	// diagnostics would be caused by the missing declarations
+	for _, pkg := range pkgs {
+		if err := linkPackage(overlay, pkg); err != nil {
+			return nil, diagnostic.From(err)
+		}
+	}
+
+	return pkgs, nil
+}
+
+// sortPackages returns the packages in dependency order and makes sure
+// there is no cycle between the packages
+func sortPackages(root *Package) ([]*Package, error) {
+	var sorted []*Package
+
+	visited := make(map[*Package]bool)
+	recStack := make(map[*Package]bool)
+
+	var visit func(pkg *Package) error
+	visit = func(pkg *Package) error {
+		if recStack[pkg] {
+			return fmt.Errorf("package %s is part of an import cycle", pkg.Name)
+		}
+		if visited[pkg] {
+			return nil
+		}
+
+		visited[pkg] = true
+		recStack[pkg] = true
+
+		for _, dep := range pkg.Imports {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+
+		recStack[pkg] = false
+		sorted = append(sorted, pkg)
+
+		return nil
+	}
+
+	if err := visit(root); err != nil {
+		return nil, err
+	}
+
+	return sorted, nil
+}
+
+// linkPackage parses all the files of the package as a single program and appends
+// the declarations of the imported packages, which must have been linked already
+func linkPackage(overlay Overlay, pkg *Package) error {
+	sort.Strings(pkg.Files)
+
+	files, err := readFiles(overlay, pkg.Files...)
+	if err != nil {
+		return err
+	}
+
+	prog, err := parser.ParseProgram(parser.NewFiles(files...))
+	if err != nil {
+		return err
+	}
+
+	imported := make(map[string]struct{})
+	for _, dep := range pkg.Imports {
+		for _, stmt := range astutil.ImportedStatements(dep.Name, dep.Program) {
+			name := statementName(stmt)
+			if _, ok := imported[name]; ok {
+				continue
+			}
+			imported[name] = struct{}{}
+			prog.Statements = append(prog.Statements, stmt)
+		}
+	}
+
+	// unknown names are reported by the validator as well, so it only runs
+	// if all the qualified names are valid
+	if err := checkQualifiedNames(pkg, prog, imported); err != nil {
+		return err
+	}
+
+	if err := validator.Validate(prog); err != nil {
+		return err
+	}
+
+	pkg.Program = prog
+
+	return nil
+}
+
+// checkQualifiedNames makes sure the package only refers to the existing declarations of
+// the packages which it has imported directly, declarations of indirect imports are only
+// linked to describe the fields of the imported models
+func checkQualifiedNames(pkg *Package, prog *ast.Program, imported map[string]struct{}) error {
+	var diags diagnostic.Diagnostics
+
+	check := func(name string) error {
+		qualifier, _ := astutil.SplitQualifiedName(name)
+		if qualifier == "" {
+			return nil
+		}
+
+		if !containsPackageName(pkg.Imports, qualifier) {
+			return fmt.Errorf("%s refers to package %s which has not been imported", name, qualifier)
+		}
+
+		if _, ok := imported[name]; !ok {
+			return fmt.Errorf("%s is not defined in package %s", name, qualifier)
+		}
+
+		return nil
+	}
+
+	for _, model := range astutil.GetLocalModels(prog) {
+		for _, extend := range model.Extends {
+			if err := check(extend.String()); err != nil {
+				diags = append(diags, diagnostic.Errorf(extend.Token, "message %s: %s", model.Name, err))
+			}
+		}
+
+		for _, field := range model.Fields {
+			walkCustomTypes(field.Type, func(typ *ast.CustomType) {
+				if err := check(typ.String()); err != nil {
+					diags = append(diags, diagnostic.Errorf(typ.Token, "message %s has a field %s: %s", model.Name, field.Name, err))
+				}
+			})
+		}
+	}
+
+	for _, service := range astutil.GetServices(prog) {
+		for _, method := range service.Methods {
+			for _, arg := range method.Args {
+				walkCustomTypes(arg.Type, func(typ *ast.CustomType) {
+					if err := check(typ.String()); err != nil {
+						diags = append(diags, diagnostic.Errorf(typ.Token, "service %s has a method %s: %s", service.Name, method.Name, err))
+					}
+				})
+			}
+
+			for _, ret := range method.Returns {
+				walkCustomTypes(ret.Type, func(typ *ast.CustomType) {
+					if err := check(typ.String()); err != nil {
+						diags = append(diags, diagnostic.Errorf(typ.Token, "service %s has a method %s: %s", service.Name, method.Name, err))
+					}
+				})
+			}
+		}
+	}
+
+	return diags.Err()
+}
+
+func walkCustomTypes(typ ast.Type, fn func(typ *ast.CustomType)) {
+	switch typ := typ.(type) {
+	case *ast.CustomType:
+		fn(typ)
+	case *ast.Array:
+		walkCustomTypes(typ.Type, fn)
+	case *ast.Map:
+		walkCustomTypes(typ.Key, fn)
+		walkCustomTypes(typ.Value, fn)
+	}
+}
+
+func statementName(stmt ast.Statement) string {
+	switch stmt := stmt.(type) {
+	case *ast.Model:
+		return stmt.Name.String()
+	case *ast.Enum:
+		return stmt.Name.String()
+	default:
+		return ""
+	}
+}
+
+func parseFile(overlay Overlay, filename string) (*ast.Program, error) {
+	content, err := overlay.readFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return parser.ParseProgram(parser.NewFiles(scanner.File{Name: filename, Content: string(content)}))
+}
+
+func containsFile(files []string, filename string) bool {
+	for _, file := range files {
+		if file == filename {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPackageName(pkgs []*Package, name string) bool {
+	for _, p := range pkgs {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPackage(pkgs []*Package, pkg *Package) bool {
+	for _, p := range pkgs {
+		if p == pkg {
+			return true
+		}
+	}
+	return false
+}
+
+// readFiles is a helper function to read multiple files for the parser and returns
+// an error if any of the files have an invalid extension or file cannot be read.
+func readFiles(overlay Overlay, filenames ...string) ([]scanner.File, error) {
+	files := make([]scanner.File, 0, len(filenames))
+
+	for _, filename := range filenames {
+		if !strings.HasSuffix(filename, ".ella") {
+			return nil, fmt.Errorf("invalid file extension %s", filename)
+		}
+
+		content, err := overlay.readFile(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, scanner.File{Name: filename, Content: string(content)})
+	}
+
+	return files, nil
+}
@@ -0,0 +1,83 @@
+package loader_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"compiler.ella.to/internal/diagnostic"
+	"compiler.ella.to/internal/loader"
+)
+
+func TestLoadPackageNames(t *testing.T) {
+	testCases := []struct {
+		Name  string
+		Files map[string]string
+		Diags []string
+	}{
+		{
+			Name: "imported packages",
+			Files: map[string]string{
+				"api/api.ella": `import "../common/common.ella"
+model Team {
+	Owner: common.User
+}`,
+				"common/common.ella": `model User {
+	Name: string
+}`,
+			},
+		},
+		{
+			Name: "two packages with the same name",
+			Files: map[string]string{
+				"api/api.ella": `import "../v1/common/common.ella"
+import "../v2/common/common.ella"`,
+				"v1/common/common.ella": `model User {}`,
+				"v2/common/common.ella": `model Team {}`,
+			},
+			Diags: []string{
+				"{dir}/api/api.ella:2:9: error: package common is defined by both {dir}/v1/common and {dir}/v2/common directories",
+			},
+		},
+		{
+			Name: "package with the same name as the root package",
+			Files: map[string]string{
+				"api/api.ella":    `import "../v1/api/api.ella"`,
+				"v1/api/api.ella": `model User {}`,
+			},
+			Diags: []string{
+				"{dir}/api/api.ella:1:9: error: package api of imported file ../v1/api/api.ella has the same name as the root package",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			overlay := make(loader.Overlay)
+			for name, content := range tc.Files {
+				overlay[filepath.Join(dir, name)] = []byte(content)
+			}
+
+			pkgs, err := loader.LoadOverlay("api", []string{filepath.Join(dir, "api/api.ella")}, overlay)
+
+			var diags []string
+			for _, diag := range diagnostic.From(err) {
+				diags = append(diags, diag.Error())
+			}
+
+			var expected []string
+			for _, diag := range tc.Diags {
+				expected = append(expected, strings.ReplaceAll(diag, "{dir}", dir))
+			}
+
+			assert.Equal(t, expected, diags)
+			if len(expected) == 0 {
+				assert.Len(t, pkgs, 2)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"compiler.ella.to/internal/ast"
+	"compiler.ella.to/internal/parser"
+	"compiler.ella.to/internal/scanner"
+	"compiler.ella.to/internal/token"
+)
+
+// document is an ella file which is opened by the editor
+type document struct {
+	uri      string
+	filename string
+	text     string
+
+	// prog is the last successfully parsed program of the document and source is the
+	// text it was parsed from, it is used while the document has a syntax error
+	prog   *ast.Program
+	source string
+	err    error
+}
+
+func newDocument(uri, text string) *document {
+	doc := &document{
+		uri:      uri,
+		filename: uriToFilename(uri),
+	}
+	doc.update(text)
+
+	return doc
+}
+
+func (d *document) update(text string) {
+	d.text = text
+
+	prog, err := parser.ParseProgram(parser.New(text))
+	d.err = err
+	if err == nil {
+		d.prog = prog
+		d.source = text
+	}
+}
+
+// tokenAt returns the token of the current text which contains the offset, comments are
+// ignored and nil is returned if there is no such token
+func (d *document) tokenAt(offset int) *token.Token {
+	for _, tok := range scan(d.text) {
+		if tok.Start <= offset && offset <= tok.End && tok.Type != token.EOF {
+			return tok
+		}
+		if tok.Start > offset {
+			break
+		}
+	}
+
+	return nil
+}
+
+// tokenBefore returns the last token which ends before the offset
+func (d *document) tokenBefore(offset int) *token.Token {
+	var result *token.Token
+
+	for _, tok := range scan(d.text) {
+		if tok.End > offset || tok.Type == token.EOF {
+			break
+		}
+		if tok.Type != token.TopComment && tok.Type != token.RightComment {
+			result = tok
+		}
+	}
+
+	return result
+}
+
+// scan returns all the tokens of the text, it stops at the first error token
+func scan(text string) []*token.Token {
+	var tokens []*token.Token
+
+	scanner.Start(token.EmitterFunc(func(tok *token.Token) {
+		tokens = append(tokens, tok)
+	}), scanner.Lex, text)
+
+	return tokens
+}
+
+// offsetOf converts the position into the byte offset of the text
+func offsetOf(text string, pos Position) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i == -1 {
+			return len(text)
+		}
+		offset += i + 1
+	}
+
+	for units := 0; units < pos.Character && offset < len(text); {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		if r == '\n' {
+			break
+		}
+		units += len(utf16.Encode([]rune{r}))
+		offset += size
+	}
+
+	return offset
+}
+
+// positionOf converts the byte offset of the text into position
+func positionOf(text string, offset int) Position {
+	if offset > len(text) {
+		offset = len(text)
+	}
+
+	line := strings.Count(text[:offset], "\n")
+	lineStart := strings.LastIndexByte(text[:offset], '\n') + 1
+
+	return Position{
+		Line:      line,
+		Character: len(utf16.Encode([]rune(text[lineStart:offset]))),
+	}
+}
+
+func rangeOf(text string, tok *token.Token) Range {
+	return Range{
+		Start: positionOf(text, tok.Start),
+		End:   positionOf(text, tok.End),
+	}
+}
+
+func uriToFilename(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+
+	return filepath.FromSlash(u.Path)
+}
+
+func filenameToURI(filename string) string {
+	u := url.URL{
+		Scheme: "file",
+		Path:   filepath.ToSlash(filename),
+	}
+
+	return u.String()
+}
@@ -0,0 +1,209 @@
+package lsp
+
+import (
+	"sort"
+	"strings"
+
+	"compiler.ella.to/internal/parser"
+	"compiler.ella.to/internal/token"
+)
+
+var keywords = []string{
+	"import", "const", "enum", "model", "service", "error", "http", "rpc", "event", "stream", "true", "false", "null",
+}
+
+var builtinTypes = []string{
+	"bool", "byte", "int8", "int16", "int32", "int64", "uint8", "uint16", "uint32", "uint64",
+	"float32", "float64", "timestamp", "string", "any", "file", "map<K, V>",
+}
+
+// options are the known option names and the place they can be used
+var options = []struct {
+	Name   string
+	Detail string
+}{
+	{"Required", "field option"},
+	{"Min", "field option"},
+	{"Max", "field option"},
+	{"MinLen", "field option"},
+	{"MaxLen", "field option"},
+	{"Pattern", "field option"},
+	{"MaxSize", "field option"},
+	{"Json", "field option"},
+	{"Yaml", "field option"},
+	{"BasePath", "service option"},
+	{"HttpMethod", "method option"},
+	{"Path", "method option"},
+	{"ContentType", "method option"},
+	{"MaxUploadSize", "method option"},
+	{"RawControl", "method option"},
+	{"Code", "error option"},
+	{"HttpStatus", "error option"},
+	{"Msg", "error option"},
+}
+
+func (s *Server) definition(params TextDocumentPositionParams) (*Location, error) {
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	tok := doc.tokenAt(offsetOf(doc.text, params.Position))
+
+	sym := resolve(s.symbols(doc), doc, tok)
+	if sym == nil {
+		return nil, nil
+	}
+
+	location := sym.location()
+	return &location, nil
+}
+
+func (s *Server) hover(params TextDocumentPositionParams) (*Hover, error) {
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	tok := doc.tokenAt(offsetOf(doc.text, params.Position))
+
+	sym := resolve(s.symbols(doc), doc, tok)
+	if sym == nil {
+		return nil, nil
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("```ella\n")
+	sb.WriteString(describe(sym))
+	sb.WriteString("\n```")
+
+	if len(sym.doc) > 0 {
+		sb.WriteString("\n\n")
+		sb.WriteString(strings.Join(sym.doc, "\n"))
+	}
+
+	r := rangeOf(doc.text, tok)
+
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: sb.String()},
+		Range:    &r,
+	}, nil
+}
+
+// completion suggests the items based on the token before the word under the cursor,
+// types are suggested after ':', values after '=' and everything else otherwise
+func (s *Server) completion(params TextDocumentPositionParams) (*CompletionList, error) {
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := offsetOf(doc.text, params.Position)
+	for offset > 0 && isWordChar(doc.text[offset-1]) {
+		offset--
+	}
+
+	var prev token.Type = token.EOF
+	if tok := doc.tokenBefore(offset); tok != nil {
+		prev = tok.Type
+	}
+
+	symbols := s.symbols(doc)
+	items := []CompletionItem{}
+
+	addTypes := func() {
+		for _, name := range builtinTypes {
+			items = append(items, CompletionItem{Label: name, Kind: CompletionKindKeyword, Detail: "builtin type"})
+		}
+		for _, sym := range symbols {
+			switch sym.kind {
+			case symbolModel:
+				items = append(items, symbolCompletion(sym, CompletionKindStruct, "model"))
+			case symbolEnum:
+				items = append(items, symbolCompletion(sym, CompletionKindEnum, "enum"))
+			}
+		}
+	}
+
+	addValues := func() {
+		for _, name := range []string{"true", "false", "null"} {
+			items = append(items, CompletionItem{Label: name, Kind: CompletionKindKeyword})
+		}
+		for _, sym := range symbols {
+			switch sym.kind {
+			case symbolConst:
+				items = append(items, symbolCompletion(sym, CompletionKindConstant, describe(sym)))
+			case symbolEnumKey:
+				items = append(items, symbolCompletion(sym, CompletionKindEnumKey, describe(sym)))
+			}
+		}
+	}
+
+	switch prev {
+	case token.Colon, token.Array, token.OpenAngle, token.Stream, token.Extend:
+		addTypes()
+	case token.Assign:
+		addValues()
+	default:
+		for _, keyword := range keywords {
+			items = append(items, CompletionItem{Label: keyword, Kind: CompletionKindKeyword})
+		}
+		for _, option := range options {
+			items = append(items, CompletionItem{Label: option.Name, Kind: CompletionKindProperty, Detail: option.Detail})
+		}
+		addTypes()
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Label < items[j].Label
+	})
+
+	return &CompletionList{Items: items}, nil
+}
+
+func symbolCompletion(sym *symbol, kind int, detail string) CompletionItem {
+	item := CompletionItem{
+		Label:  sym.name,
+		Kind:   kind,
+		Detail: detail,
+	}
+
+	if len(sym.doc) > 0 {
+		item.Documentation = &MarkupContent{Kind: "markdown", Value: strings.Join(sym.doc, "\n")}
+	}
+
+	return item
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '.' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// formatting replaces the whole document with its canonical format
+func (s *Server) formatting(params DocumentFormattingParams) ([]TextEdit, error) {
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	formatted, err := parser.Format(doc.text)
+	if err != nil {
+		msg, _, _ := strings.Cut(err.Error(), "\n")
+		return nil, &responseError{Code: codeRequestFailed, Message: msg}
+	}
+
+	if formatted == doc.text {
+		return []TextEdit{}, nil
+	}
+
+	return []TextEdit{
+		{
+			Range: Range{
+				Start: Position{},
+				End:   positionOf(doc.text, len(doc.text)),
+			},
+			NewText: formatted,
+		},
+	}, nil
+}
@@ -0,0 +1,259 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"compiler.ella.to/internal/diagnostic"
+	"compiler.ella.to/internal/loader"
+)
+
+// Server is a language server for ella files which speaks the Language Server
+// Protocol, the documents are synced fully on each change and a package is
+// all the ella files of a directory, similar to the imported packages
+type Server struct {
+	conn     *conn
+	docs     map[string]*document
+	shutdown bool
+}
+
+func New(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		conn: &conn{r: bufio.NewReader(in), w: out},
+		docs: make(map[string]*document),
+	}
+}
+
+// Run handles the incoming messages until the client sends exit notification
+// or closes the input, an error is returned if exit is not requested after shutdown
+func (s *Server) Run() error {
+	for {
+		msg, err := s.conn.read()
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("connection is closed before shutdown")
+		} else if respErr, ok := err.(*responseError); ok {
+			// id of the message is unknown, so it is null
+			id := json.RawMessage("null")
+			if err = s.conn.reply(&id, nil, respErr); err != nil {
+				return err
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("exit is requested before shutdown")
+			}
+			return nil
+		}
+
+		if msg.Method == "" {
+			// responses of the client are ignored as server doesn't send any request
+			continue
+		}
+
+		result, err := s.handle(msg.Method, msg.Params)
+		if msg.Id == nil {
+			// errors of notifications can't be reported back to the client
+			continue
+		}
+
+		if err = s.conn.reply(msg.Id, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "initialize":
+		return InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync:           1,
+				DefinitionProvider:         true,
+				HoverProvider:              true,
+				CompletionProvider:         CompletionOptions{TriggerCharacters: []string{".", ":", "="}},
+				DocumentFormattingProvider: true,
+			},
+			ServerInfo: ServerInfo{Name: "ella"},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var p DidOpenTextDocumentParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		s.docs[p.TextDocument.URI] = newDocument(p.TextDocument.URI, p.TextDocument.Text)
+		return nil, s.publishDiagnostics(s.docs[p.TextDocument.URI])
+	case "textDocument/didChange":
+		var p DidChangeTextDocumentParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(p.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		text := doc.text
+		for _, change := range p.Changes {
+			if change.Range == nil {
+				text = change.Text
+			} else {
+				text = text[:offsetOf(text, change.Range.Start)] + change.Text + text[offsetOf(text, change.Range.End):]
+			}
+		}
+		doc.update(text)
+		return nil, s.publishDiagnostics(doc)
+	case "textDocument/didClose":
+		var p DidCloseTextDocumentParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(p.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		delete(s.docs, doc.uri)
+		if err = s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: doc.uri, Diagnostics: []Diagnostic{}}); err != nil {
+			return nil, err
+		}
+		return nil, s.publishDiagnostics(doc)
+	case "textDocument/definition":
+		var p TextDocumentPositionParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		return s.definition(p)
+	case "textDocument/hover":
+		var p TextDocumentPositionParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		return s.hover(p)
+	case "textDocument/completion":
+		var p TextDocumentPositionParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		return s.completion(p)
+	case "textDocument/formatting":
+		var p DocumentFormattingParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		return s.formatting(p)
+	default:
+		return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %s is not supported", method)}
+	}
+}
+
+func (s *Server) document(uri string) (*document, error) {
+	doc, ok := s.docs[uri]
+	if !ok {
+		return nil, &responseError{Code: codeInvalidParams, Message: fmt.Sprintf("document %s is not open", uri)}
+	}
+	return doc, nil
+}
+
+// publishDiagnostics reports the diagnostics of all the open documents which belong to
+// the same package as doc, as a change in one file can affect the other files
+func (s *Server) publishDiagnostics(doc *document) error {
+	dir := filepath.Dir(doc.filename)
+
+	uris := make([]string, 0, len(s.docs))
+	for uri, other := range s.docs {
+		if filepath.Dir(other.filename) == dir {
+			uris = append(uris, uri)
+		}
+	}
+	sort.Strings(uris)
+
+	for _, uri := range uris {
+		err := s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI:         uri,
+			Diagnostics: s.diagnostics(s.docs[uri]),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// diagnostics returns the syntax errors of the document, or the errors of its package
+// which are reported by the loader and validator. Errors of the files which are not
+// open are reported at the beginning of the document, as the editor can't show them.
+func (s *Server) diagnostics(doc *document) []Diagnostic {
+	diagnostics := []Diagnostic{}
+
+	if doc.err != nil {
+		for _, d := range diagnostic.From(doc.err) {
+			diagnostics = append(diagnostics, newDiagnostic(diagnosticRange(doc.text, d), d.Message))
+		}
+		return diagnostics
+	}
+
+	overlay := make(loader.Overlay)
+	for _, other := range s.docs {
+		overlay[other.filename] = []byte(other.text)
+	}
+
+	filenames := s.packageFilenames(doc)
+	sort.Strings(filenames)
+
+	_, err := loader.LoadOverlay(filepath.Base(filepath.Dir(doc.filename)), filenames, overlay)
+	for _, d := range diagnostic.From(err) {
+		switch {
+		case d.Filename == doc.filename:
+			diagnostics = append(diagnostics, newDiagnostic(diagnosticRange(doc.text, d), d.Message))
+		case d.Filename == "" || overlay[d.Filename] == nil:
+			// the context of syntax errors is not needed, as the position is included
+			msg, _, _ := strings.Cut(d.Error(), "\n")
+			diagnostics = append(diagnostics, newDiagnostic(Range{}, msg))
+		}
+	}
+
+	return diagnostics
+}
+
+// diagnosticRange returns the range of the diagnostic in the text, diagnostics
+// without position are placed at the beginning of the text
+func diagnosticRange(text string, d *diagnostic.Diagnostic) Range {
+	if d.Line == 0 {
+		return Range{}
+	}
+
+	return Range{
+		Start: positionOf(text, d.Span.Start),
+		End:   positionOf(text, d.Span.End),
+	}
+}
+
+func newDiagnostic(r Range, msg string) Diagnostic {
+	return Diagnostic{
+		Range:    r,
+		Severity: SeverityError,
+		Source:   "ella",
+		Message:  msg,
+	}
+}
+
+func unmarshalParams(params json.RawMessage, v any) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
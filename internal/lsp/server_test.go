@@ -0,0 +1,257 @@
+package lsp_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"compiler.ella.to/internal/lsp"
+)
+
+type client struct {
+	t   *testing.T
+	w   io.Writer
+	r   *bufio.Reader
+	ids int
+}
+
+type message struct {
+	Id     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (c *client) send(id *int, method string, params any) {
+	body, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  method,
+		"params":  params,
+	})
+	assert.NoError(c.t, err)
+
+	_, err = fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	assert.NoError(c.t, err)
+}
+
+func (c *client) read() *message {
+	length := 0
+	for {
+		line, err := c.r.ReadString('\n')
+		assert.NoError(c.t, err)
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		length, err = strconv.Atoi(strings.TrimPrefix(line, "Content-Length: "))
+		assert.NoError(c.t, err)
+	}
+
+	body := make([]byte, length)
+	_, err := io.ReadFull(c.r, body)
+	assert.NoError(c.t, err)
+
+	var msg message
+	assert.NoError(c.t, json.Unmarshal(body, &msg))
+
+	return &msg
+}
+
+// call sends a request and decodes the result of its response into result
+func (c *client) call(method string, params any, result any) *message {
+	c.ids++
+	id := c.ids
+	c.send(&id, method, params)
+
+	msg := c.read()
+	assert.Equal(c.t, id, *msg.Id)
+	if msg.Error == nil && result != nil {
+		assert.NoError(c.t, json.Unmarshal(msg.Result, result))
+	}
+
+	return msg
+}
+
+// notify sends a notification and returns the published diagnostics of the given uris
+func (c *client) notify(method string, params any, uris ...string) map[string][]lsp.Diagnostic {
+	c.send(nil, method, params)
+
+	diagnostics := make(map[string][]lsp.Diagnostic)
+	for range uris {
+		msg := c.read()
+		assert.Equal(c.t, "textDocument/publishDiagnostics", msg.Method)
+
+		var p lsp.PublishDiagnosticsParams
+		assert.NoError(c.t, json.Unmarshal(msg.Params, &p))
+		diagnostics[p.URI] = p.Diagnostics
+	}
+
+	return diagnostics
+}
+
+func startServer(t *testing.T) (*client, chan error) {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- lsp.New(inR, outW).Run()
+	}()
+
+	return &client{t: t, w: inW, r: bufio.NewReader(outR)}, done
+}
+
+func writeFile(t *testing.T, filename, content string) string {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(filename), os.ModePerm))
+	assert.NoError(t, os.WriteFile(filename, []byte(content), os.ModePerm))
+
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(filename)}
+	return u.String()
+}
+
+func position(content, prefix string) lsp.Position {
+	offset := strings.Index(content, prefix) + len(prefix)
+	line := strings.Count(content[:offset], "\n")
+	return lsp.Position{Line: line, Character: offset - strings.LastIndex(content[:offset], "\n") - 1}
+}
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+
+	common := `# Role is the access level of a user
+enum Role {
+	Admin
+	Member
+}
+
+model User {
+	Name: string
+	Role: Role
+}`
+
+	api := `import "../common/common.ella"
+
+# MaxMembers is the maximum number of members
+const MaxMembers = 10
+
+model Team {
+	Owner: common.User
+	Members: []common.User {
+		MaxLen = MaxMembers
+	}
+}`
+
+	commonURI := writeFile(t, filepath.Join(dir, "common", "common.ella"), common)
+	apiURI := writeFile(t, filepath.Join(dir, "api", "api.ella"), api)
+
+	c, done := startServer(t)
+
+	var initResult lsp.InitializeResult
+	c.call("initialize", map[string]any{}, &initResult)
+	assert.True(t, initResult.Capabilities.DefinitionProvider)
+	c.send(nil, "initialized", map[string]any{})
+
+	diagnostics := c.notify("textDocument/didOpen", lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{URI: apiURI, LanguageID: "ella", Version: 1, Text: api},
+	}, apiURI)
+	assert.Empty(t, diagnostics[apiURI])
+
+	t.Run("definition of imported type", func(t *testing.T) {
+		var location lsp.Location
+		c.call("textDocument/definition", lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: apiURI},
+			Position:     position(api, "Owner: comm"),
+		}, &location)
+		assert.Equal(t, commonURI, location.URI)
+		assert.Equal(t, lsp.Position{Line: 6, Character: 6}, location.Range.Start)
+	})
+
+	t.Run("definition of constant", func(t *testing.T) {
+		var location lsp.Location
+		c.call("textDocument/definition", lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: apiURI},
+			Position:     position(api, "MaxLen = Max"),
+		}, &location)
+		assert.Equal(t, apiURI, location.URI)
+		assert.Equal(t, lsp.Range{Start: lsp.Position{Line: 3, Character: 6}, End: lsp.Position{Line: 3, Character: 16}}, location.Range)
+	})
+
+	t.Run("hover of constant", func(t *testing.T) {
+		var hover lsp.Hover
+		c.call("textDocument/hover", lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: apiURI},
+			Position:     position(api, "MaxLen = Max"),
+		}, &hover)
+		assert.Equal(t, "```ella\nconst MaxMembers = 10\n```\n\nMaxMembers is the maximum number of members", hover.Contents.Value)
+	})
+
+	t.Run("completion of types", func(t *testing.T) {
+		var list lsp.CompletionList
+		c.call("textDocument/completion", lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: apiURI},
+			Position:     position(api, "Owner: "),
+		}, &list)
+
+		var labels []string
+		for _, item := range list.Items {
+			labels = append(labels, item.Label)
+		}
+		assert.Contains(t, labels, "common.User")
+		assert.Contains(t, labels, "common.Role")
+		assert.Contains(t, labels, "string")
+		assert.NotContains(t, labels, "model")
+	})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		broken := strings.Replace(api, "Owner: common.User", "Owner: common.Team", 1)
+		diagnostics := c.notify("textDocument/didChange", lsp.DidChangeTextDocumentParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: apiURI},
+			Changes:      []lsp.TextDocumentContentChangeEvent{{Text: broken}},
+		}, apiURI)
+		assert.Len(t, diagnostics[apiURI], 1)
+		assert.Equal(t, "message Team has a field Owner: common.Team is not defined in package common", diagnostics[apiURI][0].Message)
+
+		broken = strings.Replace(api, "Owner: common.User", "Owner common.User", 1)
+		diagnostics = c.notify("textDocument/didChange", lsp.DidChangeTextDocumentParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: apiURI},
+			Changes:      []lsp.TextDocumentContentChangeEvent{{Text: broken}},
+		}, apiURI)
+		assert.Len(t, diagnostics[apiURI], 1)
+		assert.Equal(t, lsp.Position{Line: 6, Character: 7}, diagnostics[apiURI][0].Range.Start)
+	})
+
+	t.Run("formatting", func(t *testing.T) {
+		c.notify("textDocument/didChange", lsp.DidChangeTextDocumentParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: apiURI},
+			Changes:      []lsp.TextDocumentContentChangeEvent{{Text: "const   A = 1"}},
+		}, apiURI)
+
+		var edits []lsp.TextEdit
+		c.call("textDocument/formatting", lsp.DocumentFormattingParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: apiURI},
+		}, &edits)
+		assert.Equal(t, []lsp.TextEdit{{
+			Range:   lsp.Range{End: lsp.Position{Line: 0, Character: 13}},
+			NewText: "const A = 1\n",
+		}}, edits)
+	})
+
+	c.call("shutdown", nil, nil)
+	c.send(nil, "exit", nil)
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,293 @@
+package lsp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"compiler.ella.to/internal/ast"
+	"compiler.ella.to/internal/ast/astutil"
+	"compiler.ella.to/internal/parser"
+	"compiler.ella.to/internal/token"
+)
+
+type symbolKind int
+
+const (
+	symbolConst symbolKind = iota
+	symbolEnum
+	symbolEnumKey
+	symbolModel
+	symbolField
+	symbolService
+	symbolMethod
+	symbolError
+)
+
+// symbol is a declaration of a package or its imported packages, imported
+// symbols are named by their qualified names, e.g. common.User
+type symbol struct {
+	name string
+	kind symbolKind
+	node any // the declaration, e.g. *ast.Model, *ast.Field or *ast.Method
+	doc  []string
+
+	// token is the name of the declaration, its offsets refer to text
+	token *token.Token
+	text  string
+	uri   string
+}
+
+func (s *symbol) location() Location {
+	return Location{
+		URI:   s.uri,
+		Range: rangeOf(s.text, s.token),
+	}
+}
+
+// source is a parsed ella file
+type source struct {
+	filename string
+	uri      string
+	text     string
+	prog     *ast.Program
+}
+
+// packageSources returns the parsed files which are in the same directory as the document,
+// open documents are used instead of their content on disk and files with syntax errors
+// are ignored
+func (s *Server) packageSources(doc *document) []*source {
+	var sources []*source
+
+	for _, filename := range s.packageFilenames(doc) {
+		if src := s.loadSource(filename); src != nil {
+			sources = append(sources, src)
+		}
+	}
+
+	return sources
+}
+
+// packageFilenames returns all the ella files of the document's package, including the
+// open documents which have not been saved yet
+func (s *Server) packageFilenames(doc *document) []string {
+	dir := filepath.Dir(doc.filename)
+
+	filenames, _ := filepath.Glob(filepath.Join(dir, "*.ella"))
+	for _, other := range s.docs {
+		if filepath.Dir(other.filename) == dir && !containsString(filenames, other.filename) {
+			filenames = append(filenames, other.filename)
+		}
+	}
+
+	return filenames
+}
+
+func (s *Server) loadSource(filename string) *source {
+	uri := filenameToURI(filename)
+
+	if doc, ok := s.docs[uri]; ok {
+		if doc.prog == nil {
+			return nil
+		}
+		return &source{filename: filename, uri: uri, text: doc.source, prog: doc.prog}
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil
+	}
+
+	prog, err := parser.ParseProgram(parser.New(string(content)))
+	if err != nil {
+		return nil
+	}
+
+	return &source{filename: filename, uri: uri, text: string(content), prog: prog}
+}
+
+// symbols returns the declarations which are visible in the document's package
+func (s *Server) symbols(doc *document) []*symbol {
+	var symbols []*symbol
+
+	imported := make(map[string]struct{})
+
+	for _, src := range s.packageSources(doc) {
+		symbols = append(symbols, sourceSymbols(src, "")...)
+
+		for _, imp := range astutil.GetImports(src.prog) {
+			path := imp.Path.Value
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(filepath.Dir(src.filename), path)
+			}
+
+			path, err := filepath.Abs(path)
+			if err != nil {
+				continue
+			}
+
+			if _, ok := imported[path]; ok {
+				continue
+			}
+			imported[path] = struct{}{}
+
+			if dep := s.loadSource(path); dep != nil {
+				symbols = append(symbols, sourceSymbols(dep, filepath.Base(filepath.Dir(path)))...)
+			}
+		}
+	}
+
+	return symbols
+}
+
+// sourceSymbols returns the declarations of the file, if pkg is not empty, only
+// exported declarations are returned and they are qualified by the package name
+func sourceSymbols(src *source, pkg string) []*symbol {
+	var symbols []*symbol
+
+	add := func(name string, kind symbolKind, node any, ident *ast.Identifier, comments ast.Comments) {
+		if pkg != "" {
+			name = astutil.QualifyName(pkg, name)
+		}
+
+		symbols = append(symbols, &symbol{
+			name:  name,
+			kind:  kind,
+			node:  node,
+			doc:   comments.Doc(),
+			token: ident.Token,
+			text:  src.text,
+			uri:   src.uri,
+		})
+	}
+
+	for _, stmt := range src.prog.Statements {
+		switch stmt := stmt.(type) {
+		case *ast.Enum:
+			add(stmt.Name.String(), symbolEnum, stmt, stmt.Name, stmt.Comments)
+			for _, set := range stmt.Sets {
+				add(stmt.Name.String()+"."+set.Name.String(), symbolEnumKey, set, set.Name, set.Comments)
+			}
+		case *ast.Model:
+			add(stmt.Name.String(), symbolModel, stmt, stmt.Name, stmt.Comments)
+			for _, field := range stmt.Fields {
+				add(stmt.Name.String()+"."+field.Name.String(), symbolField, field, field.Name, field.Comments)
+			}
+		}
+
+		if pkg != "" {
+			// constants, services and errors are not exported
+			continue
+		}
+
+		switch stmt := stmt.(type) {
+		case *ast.Const:
+			add(stmt.Name.String(), symbolConst, stmt, stmt.Name, stmt.Comments)
+		case *ast.Service:
+			add(stmt.Name.String(), symbolService, stmt, stmt.Name, stmt.Comments)
+			for _, method := range stmt.Methods {
+				add(stmt.Name.String()+"."+method.Name.String(), symbolMethod, method, method.Name, method.Comments)
+			}
+		case *ast.CustomError:
+			add(stmt.Name.String(), symbolError, stmt, stmt.Name, stmt.Comments)
+		}
+	}
+
+	return symbols
+}
+
+// resolve finds the declaration which the token refers to, the token can be the name
+// of a declaration, a custom type, a constant or an enum key, e.g. Admin, Role.Admin
+// or common.Role.Admin
+func resolve(symbols []*symbol, doc *document, tok *token.Token) *symbol {
+	if tok == nil || tok.Type != token.Identifier {
+		return nil
+	}
+
+	for _, sym := range symbols {
+		if sym.uri == doc.uri && sym.text == doc.text && sym.token.Start == tok.Start {
+			return sym
+		}
+	}
+
+	for _, sym := range symbols {
+		if sym.name != tok.Literal {
+			continue
+		}
+
+		switch sym.kind {
+		case symbolConst, symbolEnum, symbolEnumKey, symbolModel, symbolService, symbolError:
+			return sym
+		}
+	}
+
+	// a bare enum key is resolved only if it belongs to a single enum
+	var found *symbol
+	for _, sym := range symbols {
+		if sym.kind != symbolEnumKey || !strings.HasSuffix(sym.name, "."+tok.Literal) {
+			continue
+		}
+
+		if found != nil {
+			return nil
+		}
+		found = sym
+	}
+
+	return found
+}
+
+// describe returns the definition of the symbol in ella syntax without its documentation,
+// the values of enum keys and the types of fields are resolved
+func describe(sym *symbol) string {
+	var node fmt.Stringer
+
+	// shallow copies are used to remove the documentation
+	switch n := sym.node.(type) {
+	case *ast.EnumSet:
+		return fmt.Sprintf("%s = %d", sym.name, n.Value.Value)
+	case *ast.Field:
+		if n.Optional {
+			return fmt.Sprintf("%s?: %s", n.Name, n.Type)
+		}
+		return fmt.Sprintf("%s: %s", n.Name, n.Type)
+	case *ast.Method:
+		method := *n
+		method.Comments = ast.Comments{End: n.Comments.End}
+		node = ast.Methods{&method}
+	case *ast.Const:
+		constant := *n
+		constant.Comments = ast.Comments{}
+		node = &constant
+	case *ast.Enum:
+		enum := *n
+		enum.Comments = ast.Comments{End: n.Comments.End}
+		node = &enum
+	case *ast.Model:
+		model := *n
+		model.Comments = ast.Comments{End: n.Comments.End}
+		node = &model
+	case *ast.Service:
+		service := *n
+		service.Comments = ast.Comments{End: n.Comments.End}
+		node = &service
+	case *ast.CustomError:
+		customError := *n
+		customError.Comments = ast.Comments{}
+		node = &customError
+	default:
+		return ""
+	}
+
+	return strings.TrimSpace(node.String())
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
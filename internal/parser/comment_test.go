@@ -0,0 +1,107 @@
+package parser_test
+
+import (
+	"testing"
+
+	"compiler.ella.to/internal/ast"
+	"compiler.ella.to/internal/parser"
+)
+
+func TestParseComments(t *testing.T) {
+	runTests(t, func(p *parser.Parser) (ast.Node, error) {
+		return parser.ParseProgram(p)
+	}, TestCases{
+		{
+			Input: `
+# MaxSize is the maximum size
+const MaxSize = 100 # in bytes
+			`,
+			Output: `
+# MaxSize is the maximum size
+const MaxSize = 100 # in bytes
+			`,
+		},
+		{
+			Input: `
+# Status of a user
+enum Status {
+	# Active user
+	Active
+	Deleted # soft deleted
+	# no more keys
+}
+			`,
+			Output: `
+# Status of a user
+enum Status {
+	# Active user
+	Active
+	Deleted # soft deleted
+	# no more keys
+}
+			`,
+		},
+		{
+			Input: `
+# User is a user
+model User {
+	# Id is unique
+	Id: string # uuid
+	Name: string {
+		# must be set
+		Required
+		MaxLen = 10 # bytes
+		# end of options
+	}
+	# end of fields
+}
+			`,
+			Output: `
+# User is a user
+model User {
+	# Id is unique
+	Id: string # uuid
+	Name: string {
+		# must be set
+		Required
+		MaxLen = 10 # bytes
+		# end of options
+	}
+	# end of fields
+}
+			`,
+		},
+		{
+			Input: `
+# UserService manages users
+service UserService {
+	# GetUser returns a user
+	http GetUser(id: string) => (user: User) # by id
+	# end of methods
+}
+			`,
+			Output: `
+# UserService manages users
+service UserService {
+	# GetUser returns a user
+	http GetUser(id: string) => (user: User) # by id
+	# end of methods
+}
+			`,
+		},
+		{
+			Input: `
+# ErrNotFound is returned when a user is not found
+error ErrNotFound { Code = 1000 HttpStatus = NotFound Msg = "not found" } # 404
+
+# end of file
+			`,
+			Output: `
+# ErrNotFound is returned when a user is not found
+error ErrNotFound { Code = 1000 HttpStatus = NotFound Msg = "not found" } # 404
+
+# end of file
+			`,
+		},
+	})
+}
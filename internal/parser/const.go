@@ -11,6 +11,8 @@ func ParseConst(p *Parser) (*ast.Const, error) {
 		return nil, p.WithError(p.Peek(), "expected 'const' keyword")
 	}
 
+	comments := ast.Comments{Top: p.TopComments()}
+
 	tok := p.Next()
 
 	if p.Peek().Type != token.Identifier {
@@ -34,9 +36,12 @@ func ParseConst(p *Parser) (*ast.Const, error) {
 		return nil, err
 	}
 
+	comments.Right = p.RightComment()
+
 	return &ast.Const{
-		Token: tok,
-		Name:  &ast.Identifier{Token: nameTok},
-		Value: value,
+		Token:    tok,
+		Name:     &ast.Identifier{Token: nameTok},
+		Value:    value,
+		Comments: comments,
 	}, nil
 }
@@ -14,6 +14,8 @@ func ParseEnum(p *Parser) (enum *ast.Enum, err error) {
 		return nil, p.WithError(p.Peek(), "expected 'enum' keyword")
 	}
 
+	comments := ast.Comments{Top: p.TopComments()}
+
 	enum = &ast.Enum{Token: p.Next()}
 
 	if p.Peek().Type != token.Identifier {
@@ -43,8 +45,13 @@ func ParseEnum(p *Parser) (enum *ast.Enum, err error) {
 		enum.Sets = append(enum.Sets, set)
 	}
 
+	comments.End = p.pendingComments()
+
 	p.Next() // skip '}'
 
+	comments.Right = p.RightComment()
+	enum.Comments = comments
+
 	// we corrected the values
 
 	var next int64
@@ -83,6 +90,8 @@ func parseEnumSet(p *Parser) (*ast.EnumSet, error) {
 		return nil, p.WithError(p.Peek(), "expected identifier for defining an enum constant")
 	}
 
+	comments := ast.Comments{Top: p.TopComments()}
+
 	nameTok := p.Next()
 
 	if nameTok.Literal != "_" && !strcase.IsPascal(nameTok.Literal) {
@@ -90,11 +99,14 @@ func parseEnumSet(p *Parser) (*ast.EnumSet, error) {
 	}
 
 	if p.Peek().Type != token.Assign {
+		comments.Right = p.RightComment()
+
 		return &ast.EnumSet{
 			Name: &ast.Identifier{Token: nameTok},
 			Value: &ast.ValueInt{
 				Value: 0,
 			},
+			Comments: comments,
 		}, nil
 	}
 
@@ -110,6 +122,8 @@ func parseEnumSet(p *Parser) (*ast.EnumSet, error) {
 		return nil, p.WithError(valueTok, "invalid integer value for defining an enum constant value: ", err)
 	}
 
+	comments.Right = p.RightComment()
+
 	return &ast.EnumSet{
 		Name: &ast.Identifier{Token: nameTok},
 		Value: &ast.ValueInt{
@@ -117,6 +131,7 @@ func parseEnumSet(p *Parser) (*ast.EnumSet, error) {
 			Value:   value,
 			Defined: true,
 		},
-		Defined: true,
+		Defined:  true,
+		Comments: comments,
 	}, nil
 }
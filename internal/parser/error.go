@@ -11,6 +11,8 @@ func ParseCustomError(p *Parser) (customError *ast.CustomError, err error) {
 		return nil, p.WithError(p.Peek(), "expected 'error' keyword")
 	}
 
+	comments := ast.Comments{Top: p.TopComments()}
+
 	customError = &ast.CustomError{Token: p.Next()}
 
 	if p.Peek().Type != token.Identifier {
@@ -42,6 +44,12 @@ func ParseCustomError(p *Parser) (customError *ast.CustomError, err error) {
 
 	p.Next() // skip '}'
 
+	// custom error is written in a single line, so comments written
+	// inside the curly braces are kept on top of it
+	comments.Top = append(comments.Top, p.pendingComments()...)
+	comments.Right = p.RightComment()
+	customError.Comments = comments
+
 	// automatically assign error code if not defined
 	// if customError.Code == 0 {
 	// 	customError.Code = p.getNextErrorCode()
@@ -100,7 +108,7 @@ func parseCustomErrorCode(p *Parser, customError *ast.CustomError) (err error) {
 
 	customError.Code = codeValue.(*ast.ValueInt).Value
 	if err = p.setErrorCodeValue(customError.Code); err != nil {
-		return err
+		return p.WithError(p.Current(), err)
 	}
 
 	return nil
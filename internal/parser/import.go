@@ -0,0 +1,41 @@
+package parser
+
+import (
+	"strings"
+
+	"compiler.ella.to/internal/ast"
+	"compiler.ella.to/internal/token"
+)
+
+func ParseImport(p *Parser) (*ast.Import, error) {
+	if p.Peek().Type != token.Import {
+		return nil, p.WithError(p.Peek(), "expected 'import' keyword")
+	}
+
+	comments := ast.Comments{Top: p.TopComments()}
+
+	tok := p.Next()
+
+	switch p.Peek().Type {
+	case token.ConstStringSingleQuote, token.ConstStringDoubleQuote, token.ConstStringBacktickQoute:
+	default:
+		return nil, p.WithError(p.Peek(), "expected string path after 'import' keyword")
+	}
+
+	pathTok := p.Next()
+
+	if !strings.HasSuffix(pathTok.Literal, ".ella") {
+		return nil, p.WithError(pathTok, "import path must be an ella file")
+	}
+
+	comments.Right = p.RightComment()
+
+	return &ast.Import{
+		Token: tok,
+		Path: &ast.ValueString{
+			Token: pathTok,
+			Value: pathTok.Literal,
+		},
+		Comments: comments,
+	}, nil
+}
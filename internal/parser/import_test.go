@@ -0,0 +1,37 @@
+package parser_test
+
+import (
+	"testing"
+
+	"compiler.ella.to/internal/ast"
+	"compiler.ella.to/internal/parser"
+)
+
+func TestParseImport(t *testing.T) {
+	runTests(t, func(p *parser.Parser) (ast.Node, error) {
+		return parser.ParseImport(p)
+	}, TestCases{
+		{
+			Input:  `import "../common/types.ella"`,
+			Output: `import "../common/types.ella"`,
+		},
+		{
+			Input:  `import 'common/types.ella'`,
+			Output: `import 'common/types.ella'`,
+		},
+		{
+			Input: `import common`,
+			Error: `
+expected string path after 'import' keyword: ->common<-
+import common
+			`,
+		},
+		{
+			Input: `import "common/types.go"`,
+			Error: `
+import path must be an ella file: ->common/types.go<-
+import "common/types.go
+			`,
+		},
+	})
+}
@@ -11,6 +11,8 @@ func ParseModel(p *Parser) (*ast.Model, error) {
 		return nil, p.WithError(p.Peek(), "expected 'message' keyword")
 	}
 
+	comments := ast.Comments{Top: p.TopComments()}
+
 	message := &ast.Model{Token: p.Next()}
 
 	if p.Peek().Type != token.Identifier {
@@ -47,8 +49,13 @@ func ParseModel(p *Parser) (*ast.Model, error) {
 		}
 	}
 
+	comments.End = p.pendingComments()
+
 	p.Next() // skip '}'
 
+	comments.Right = p.RightComment()
+	message.Comments = comments
+
 	return message, nil
 }
 
@@ -65,7 +72,7 @@ func ParseExtend(p *Parser) (*ast.Identifier, error) {
 
 	nameTok := p.Next()
 
-	if !strcase.IsPascal(nameTok.Literal) {
+	if !isTypeName(nameTok.Literal) {
 		return nil, p.WithError(nameTok, "extend message name must be in PascalCase format")
 	}
 
@@ -77,6 +84,8 @@ func ParseModelField(p *Parser) (field *ast.Field, err error) {
 		return nil, p.WithError(p.Peek(), "expected identifier for defining a message field")
 	}
 
+	comments := ast.Comments{Top: p.TopComments()}
+
 	nameTok := p.Next()
 
 	if !strcase.IsPascal(nameTok.Literal) {
@@ -88,6 +97,11 @@ func ParseModelField(p *Parser) (field *ast.Field, err error) {
 		Options: make([]*ast.Option, 0),
 	}
 
+	if p.Peek().Type == token.Optional {
+		field.Optional = true
+		p.Next() // skip '?'
+	}
+
 	if p.Peek().Type != token.Colon {
 		return nil, p.WithError(p.Peek(), "expected ':' after message field name")
 	}
@@ -99,14 +113,15 @@ func ParseModelField(p *Parser) (field *ast.Field, err error) {
 		return nil, err
 	}
 
-	if p.Peek().Type != token.OpenCurly {
-		return field, nil
+	if p.Peek().Type == token.OpenCurly {
+		field.Options, comments.End, err = ParseOptions(p)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	field.Options, err = ParseOptions(p)
-	if err != nil {
-		return nil, err
-	}
+	comments.Right = p.RightComment()
+	field.Comments = comments
 
 	return field, nil
 }
@@ -22,6 +22,22 @@ model Foo {
 			Input:  `model Foo {}`,
 			Output: `model Foo {}`,
 		},
+		{
+			Input: `model Foo {
+				Nickname?: string
+				Age?: int8 {
+					Min = 1
+				}
+			}`,
+			Output: `
+model Foo {
+	Nickname?: string
+	Age?: int8 {
+		Min = 1
+	}
+}
+`,
+		},
 		{
 			Input: `model Foo {
 				FirstName: string {
@@ -10,6 +10,8 @@ func ParseOption(p *Parser) (option *ast.Option, err error) {
 		return nil, p.WithError(p.Peek(), "expected identifier for defining a message field option")
 	}
 
+	comments := ast.Comments{Top: p.TopComments()}
+
 	nameTok := p.Next()
 
 	option = &ast.Option{
@@ -22,21 +24,24 @@ func ParseOption(p *Parser) (option *ast.Option, err error) {
 			Value:   true,
 			Defined: false,
 		}
+	} else {
+		p.Next() // skip '='
 
-		return option, nil
+		option.Value, err = ParseValue(p)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	p.Next() // skip '='
-
-	option.Value, err = ParseValue(p)
-	if err != nil {
-		return nil, err
-	}
+	comments.Right = p.RightComment()
+	option.Comments = comments
 
 	return option, nil
 }
 
-func ParseOptions(p *Parser) (ast.Options, error) {
+// ParseOptions parses the options defined in curly braces, it also returns the
+// comments written after the last option
+func ParseOptions(p *Parser) (ast.Options, []*ast.Comment, error) {
 	options := make([]*ast.Option, 0)
 
 	p.Next() // skip '{'
@@ -44,13 +49,15 @@ func ParseOptions(p *Parser) (ast.Options, error) {
 	for p.Peek().Type != token.CloseCurly {
 		option, err := ParseOption(p)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		options = append(options, option)
 	}
 
+	comments := p.pendingComments()
+
 	p.Next() // skip '}'
 
-	return options, nil
+	return options, comments, nil
 }
@@ -5,16 +5,28 @@ import (
 	"os"
 	"strings"
 
+	"compiler.ella.to/internal/ast"
+	"compiler.ella.to/internal/diagnostic"
 	"compiler.ella.to/internal/scanner"
 	"compiler.ella.to/internal/token"
 )
 
 type Parser struct {
 	input   string
+	files   map[string]string // content of the files by their names, see NewFiles
 	tokens  token.Iterator
 	nextTok *token.Token
 	currTok *token.Token
 
+	// comments are collected while looking for the next token, and they
+	// are attached to the nodes by TopComments and RightComment
+	comments []*ast.Comment
+
+	// done is set once the scanner has emitted its last token, which is
+	// returned from then on
+	done bool
+	last *token.Token
+
 	errorCodesMap  map[int64]struct{}
 	errorCodeValue int64
 }
@@ -47,7 +59,7 @@ func (p *Parser) Next() *token.Token {
 		p.currTok = p.nextTok
 		p.nextTok = nil
 	} else {
-		p.currTok = p.tokens.NextToken()
+		p.currTok = p.nextToken()
 	}
 
 	return p.currTok
@@ -55,12 +67,98 @@ func (p *Parser) Next() *token.Token {
 
 func (p *Parser) Peek() *token.Token {
 	if p.nextTok == nil {
-		p.nextTok = p.tokens.NextToken()
+		p.nextTok = p.nextToken()
 	}
 
 	return p.nextTok
 }
 
+// nextToken returns the next token which is not a comment
+func (p *Parser) nextToken() *token.Token {
+	for {
+		if p.done {
+			return p.last
+		}
+
+		tok := p.tokens.NextToken()
+		if tok.Type == token.EOF || tok.Type == token.Error {
+			p.done = true
+			p.last = tok
+		}
+
+		if tok.Type != token.TopComment && tok.Type != token.RightComment {
+			return tok
+		}
+
+		p.comments = append(p.comments, &ast.Comment{Token: tok})
+	}
+}
+
+// drain consumes the remaining tokens, so the scanner's goroutine is not blocked
+// forever when parsing stops early because of an error
+func (p *Parser) drain() {
+	for !p.done {
+		p.nextToken()
+	}
+}
+
+// TopComments returns all the comments written before the next token, it should be
+// called before parsing a node to collect the node's documentation
+func (p *Parser) TopComments() []*ast.Comment {
+	p.Peek()
+
+	comments := p.comments
+	p.comments = nil
+
+	return comments
+}
+
+// pendingComments returns the comments which have been collected so far, it is used
+// to keep the comments written inside a node which has no place for them
+func (p *Parser) pendingComments() []*ast.Comment {
+	comments := p.comments
+	p.comments = nil
+
+	return comments
+}
+
+// RightComment returns the comment which is written right after the current token
+// in the same line, it should be called after parsing a node
+func (p *Parser) RightComment() *ast.Comment {
+	p.Peek()
+
+	if len(p.comments) == 0 || p.comments[0].Token.Type != token.RightComment {
+		return nil
+	}
+
+	comment := p.comments[0]
+	p.comments = p.comments[1:]
+
+	return comment
+}
+
+// Error is returned when the input can't be parsed, it keeps the token
+// which caused the error, so its position can be reported
+type Error struct {
+	Token   *token.Token
+	Msg     string
+	Context string // the lines around the token
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: ->%s<-\n%s", e.Msg, e.Token.Literal, e.Context)
+}
+
+// Diagnostic returns the error at the token's position, errors of the scanner
+// are reported by their own message
+func (e *Error) Diagnostic() *diagnostic.Diagnostic {
+	if e.Token.Type == token.Error {
+		return diagnostic.Errorf(e.Token, "%s", e.Token.Literal)
+	}
+
+	return diagnostic.Errorf(e.Token, "%s", e.Msg)
+}
+
 func (p *Parser) WithError(token *token.Token, args ...any) error {
 	var sb strings.Builder
 	for i, arg := range args {
@@ -70,13 +168,21 @@ func (p *Parser) WithError(token *token.Token, args ...any) error {
 		}
 	}
 
-	return fmt.Errorf("%s: ->%s<-\n%s", sb.String(), token.Literal, p.showContext(token, 5))
+	return &Error{
+		Token:   token,
+		Msg:     sb.String(),
+		Context: p.showContext(token, 5),
+	}
 }
 
 func (p *Parser) showContext(token *token.Token, lines int) string {
 	start := token.Start
 	end := token.End
 
+	if content, ok := p.files[token.Filename]; ok {
+		return showContext(content, start, end, lines)
+	}
+
 	if p.input == "" {
 		content, err := os.ReadFile(token.Filename)
 		if err != nil {
@@ -85,24 +191,28 @@ func (p *Parser) showContext(token *token.Token, lines int) string {
 		p.input = string(content)
 	}
 
+	return showContext(p.input, start, end, lines)
+}
+
+func showContext(input string, start, end, lines int) string {
 	// going backwards n lines
 	for i := 0; i < lines; i++ {
 		if start == -1 {
 			break
 		}
 
-		start = strings.LastIndex(p.input[:start], "\n")
+		start = strings.LastIndex(input[:start], "\n")
 	}
 
 	for i := 0; i < lines; i++ {
 		if start > 0 {
-			start = strings.LastIndex(p.input[:start], "\n")
+			start = strings.LastIndex(input[:start], "\n")
 		}
 
-		if end < len(p.input)-1 {
-			end = strings.Index(p.input[end:], "\n") + end + 1
+		if end < len(input)-1 {
+			end = strings.Index(input[end:], "\n") + end + 1
 			if end == -1 {
-				end = len(p.input)
+				end = len(input)
 			}
 		}
 	}
@@ -111,7 +221,7 @@ func (p *Parser) showContext(token *token.Token, lines int) string {
 		start = 0
 	}
 
-	return p.input[start:end] + "\n"
+	return input[start:end] + "\n"
 }
 
 func New(input string) *Parser {
@@ -120,6 +230,20 @@ func New(input string) *Parser {
 	return &Parser{input: input, tokens: tokenEmitter, errorCodesMap: make(map[int64]struct{}), errorCodeValue: 1000}
 }
 
+// NewFiles creates a parser for the files as a single input, so their declarations
+// form a single program, tokens keep the name of their file
+func NewFiles(files ...scanner.File) *Parser {
+	tokenEmitter := token.NewEmitterIterator()
+	go scanner.StartWithFiles(tokenEmitter, scanner.Lex, files...)
+
+	contents := make(map[string]string, len(files))
+	for _, file := range files {
+		contents[file.Name] = file.Content
+	}
+
+	return &Parser{files: contents, tokens: tokenEmitter, errorCodesMap: make(map[int64]struct{}), errorCodeValue: 1000}
+}
+
 func NewFilenames(filenames ...string) *Parser {
 	tokenEmitter := token.NewEmitterIterator()
 	go scanner.StartWithFilenames(tokenEmitter, scanner.Lex, filenames...)
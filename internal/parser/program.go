@@ -4,20 +4,33 @@ import (
 	"sort"
 
 	"compiler.ella.to/internal/ast"
+	"compiler.ella.to/internal/diagnostic"
 	"compiler.ella.to/internal/token"
 )
 
-func ParseProgram(p *Parser) (prog *ast.Program, err error) {
-	prog = &ast.Program{
+// ParseProgram parses all the statements, when a statement can't be parsed, the
+// error is collected and parsing continues from the next statement, so all the
+// errors are returned together as diagnostic.Diagnostics
+func ParseProgram(p *Parser) (*ast.Program, error) {
+	defer p.drain()
+
+	prog := &ast.Program{
 		Statements: make([]ast.Statement, 0),
 	}
 
 	customErrors := make([]*ast.CustomError, 0)
 
+	var diags diagnostic.Diagnostics
+
 	for p.Peek().Type != token.EOF {
 		var stmt ast.Statement
+		var err error
+
+		start := p.Peek()
 
 		switch p.Peek().Type {
+		case token.Import:
+			stmt, err = ParseImport(p)
 		case token.Const:
 			stmt, err = ParseConst(p)
 		case token.Identifier:
@@ -29,19 +42,35 @@ func ParseProgram(p *Parser) (prog *ast.Program, err error) {
 		case token.Service:
 			stmt, err = ParseService(p)
 		case token.CustomError:
-			stmt, err = ParseCustomError(p)
-			customErrors = append(customErrors, stmt.(*ast.CustomError))
+			var customError *ast.CustomError
+			customError, err = ParseCustomError(p)
+			if err == nil {
+				customErrors = append(customErrors, customError)
+			}
+			stmt = customError
 		default:
-			return nil, p.WithError(p.Peek(), "unexpected token")
+			err = p.WithError(p.Peek(), "unexpected token")
 		}
 
 		if err != nil {
-			return nil, err
+			diags.Add(err)
+			if p.done && p.last.Type == token.Error {
+				// scanner stops at its first error, so there is nothing left to parse
+				break
+			}
+			p.skipStatement(start)
+			continue
 		}
 
 		prog.Statements = append(prog.Statements, stmt)
 	}
 
+	if len(diags) > 0 {
+		return nil, diags
+	}
+
+	prog.Comments = p.TopComments()
+
 	sort.Slice(customErrors, func(i, j int) bool {
 		return customErrors[i].Name.TokenLiteral() < customErrors[j].Name.TokenLiteral()
 	})
@@ -55,3 +84,37 @@ func ParseProgram(p *Parser) (prog *ast.Program, err error) {
 
 	return prog, nil
 }
+
+// skipStatement skips the tokens until the beginning of the next statement, it is used
+// to recover from an error. If parsing has failed at the start token, it is skipped, so
+// the parser doesn't get stuck at the same token.
+func (p *Parser) skipStatement(start *token.Token) {
+	if p.Peek() == start {
+		p.Next()
+	}
+
+	for {
+		switch p.Peek().Type {
+		case token.EOF, token.Error, token.Import, token.Const, token.Enum, token.Model, token.Service, token.CustomError:
+			p.comments = nil
+			return
+		}
+		p.Next()
+	}
+}
+
+// Format parses the input and returns it in the canonical format,
+// which always ends with a new line
+func Format(input string) (string, error) {
+	prog, err := ParseProgram(New(input))
+	if err != nil {
+		return "", err
+	}
+
+	formatted := prog.String()
+	if formatted != "" {
+		formatted += "\n"
+	}
+
+	return formatted, nil
+}
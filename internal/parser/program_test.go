@@ -0,0 +1,49 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"compiler.ella.to/internal/diagnostic"
+	"compiler.ella.to/internal/parser"
+	"compiler.ella.to/internal/scanner"
+)
+
+func TestParseProgramErrors(t *testing.T) {
+	p := parser.NewFiles(
+		scanner.File{
+			Name: "a.ella",
+			Content: `model User {
+	Name string
+}
+
+const = 1
+`,
+		},
+		scanner.File{
+			Name: "b.ella",
+			Content: `enum Status {
+	Active
+}
+
+model Team {
+	Owner User
+}
+`,
+		},
+	)
+
+	_, err := parser.ParseProgram(p)
+
+	var diags diagnostic.Diagnostics
+	assert.ErrorAs(t, err, &diags)
+
+	var positions []string
+	for _, d := range diags {
+		positions = append(positions, d.Position())
+	}
+
+	assert.Equal(t, []string{"a.ella:2:7", "a.ella:5:7", "b.ella:6:8"}, positions)
+	assert.Equal(t, "expected ':' after message field name", diags[0].Message)
+}
@@ -11,6 +11,8 @@ func ParseService(p *Parser) (service *ast.Service, err error) {
 		return nil, p.WithError(p.Peek(), "expected service keyword")
 	}
 
+	comments := ast.Comments{Top: p.TopComments()}
+
 	service = &ast.Service{Token: p.Next()}
 
 	if p.Peek().Type != token.Identifier {
@@ -32,6 +34,17 @@ func ParseService(p *Parser) (service *ast.Service, err error) {
 	p.Next() // skip '{'
 
 	for p.Peek().Type != token.CloseCurly {
+		// service options are defined in the body of service, e.g. BasePath = "/api"
+		if p.Peek().Type == token.Identifier {
+			option, err := ParseOption(p)
+			if err != nil {
+				return nil, err
+			}
+
+			service.Options = append(service.Options, option)
+			continue
+		}
+
 		methods, err := ParseServiceMethod(p)
 		if err != nil {
 			return nil, err
@@ -40,8 +53,13 @@ func ParseService(p *Parser) (service *ast.Service, err error) {
 		service.Methods = append(service.Methods, methods...)
 	}
 
+	comments.End = p.pendingComments()
+
 	p.Next() // skip '}'
 
+	comments.Right = p.RightComment()
+	service.Comments = comments
+
 	return service, nil
 }
 
@@ -56,9 +74,23 @@ func ParseMethodTypes(p *Parser) (methodTypes []ast.MethodType, err error) {
 		case token.Rpc:
 			methodTypes = append(methodTypes, ast.MethodRPC)
 			p.Next() // skip rpc
+		case token.Event:
+			// events are one-way messages, so they can't be served as methods
+			if len(methodTypes) != 0 {
+				return nil, p.WithError(p.Peek(), "event can't be combined with other method types")
+			}
+
+			methodTypes = append(methodTypes, ast.MethodEvent)
+			p.Next() // skip event
+
+			if p.Peek().Type == token.Comma {
+				return nil, p.WithError(p.Peek(), "event can't be combined with other method types")
+			}
+
+			done = true
 		case token.Comma:
 			if len(methodTypes) == 0 {
-				return nil, p.WithError(p.Peek(), "expected 'http' or 'rpc' keyword")
+				return nil, p.WithError(p.Peek(), "expected 'http', 'rpc' or 'event' keyword")
 			} else if len(methodTypes) == 2 {
 				return nil, p.WithError(p.Peek(), "there should be only two method types")
 			}
@@ -67,7 +99,7 @@ func ParseMethodTypes(p *Parser) (methodTypes []ast.MethodType, err error) {
 			continue
 		default:
 			if len(methodTypes) == 0 {
-				return nil, p.WithError(p.Peek(), "expected 'http' or 'rpc' keyword")
+				return nil, p.WithError(p.Peek(), "expected 'http', 'rpc' or 'event' keyword")
 			} else if len(methodTypes) > 0 {
 				done = true
 			}
@@ -78,6 +110,8 @@ func ParseMethodTypes(p *Parser) (methodTypes []ast.MethodType, err error) {
 }
 
 func ParseServiceMethod(p *Parser) (methods []*ast.Method, err error) {
+	comments := ast.Comments{Top: p.TopComments()}
+
 	methodTypes, err := ParseMethodTypes(p)
 	if err != nil {
 		return nil, err
@@ -120,6 +154,9 @@ func ParseServiceMethod(p *Parser) (methods []*ast.Method, err error) {
 
 	p.Next() // skip ')'
 
+	// comments written between the args are kept on top of the method
+	comments.Top = append(comments.Top, p.pendingComments()...)
+
 	if p.Peek().Type == token.Return {
 		p.Next() // skip =>
 
@@ -139,24 +176,29 @@ func ParseServiceMethod(p *Parser) (methods []*ast.Method, err error) {
 		}
 
 		p.Next() // skip ')'
+
+		comments.Top = append(comments.Top, p.pendingComments()...)
 	}
 
 	// we return early if there are no options
 	// as options are defined by curly braces
 	if p.Peek().Type == token.OpenCurly {
-		method.Options, err = ParseOptions(p)
+		method.Options, comments.End, err = ParseOptions(p)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	comments.Right = p.RightComment()
+
 	for _, methodType := range methodTypes {
 		methods = append(methods, &ast.Method{
-			Type:    methodType,
-			Name:    method.Name,
-			Args:    method.Args,
-			Returns: method.Returns,
-			Options: method.Options,
+			Type:     methodType,
+			Name:     method.Name,
+			Args:     method.Args,
+			Returns:  method.Returns,
+			Options:  method.Options,
+			Comments: comments,
 		})
 	}
 
@@ -176,12 +218,22 @@ func ParseServiceMethodArgument(p *Parser) (arg *ast.Arg, err error) {
 
 	arg = &ast.Arg{Name: &ast.Identifier{Token: nameTok}}
 
+	if p.Peek().Type == token.Optional {
+		arg.Optional = true
+		p.Next() // skip '?'
+	}
+
 	if p.Peek().Type != token.Colon {
 		return nil, p.WithError(p.Peek(), "expected ':' after service method argument name")
 	}
 
 	p.Next() // skip ':'
 
+	if p.Peek().Type == token.Stream {
+		arg.Stream = true
+		p.Next() // skip 'stream'
+	}
+
 	arg.Type, err = ParseType(p)
 	if err != nil {
 		return nil, err
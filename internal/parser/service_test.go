@@ -120,6 +120,95 @@ service Foo {
 service Foo {
 	rpc GetFoo() => (value: stream int64)
 }
+`,
+		},
+		{
+			Input: `
+service Foo {
+	BasePath = "/api/foo"
+	http GetFoo(id: string) => (value: int64) {
+		HttpMethod = "GET"
+		Path = "/{id}"
+	}
+}
+`,
+			Output: `
+service Foo {
+	BasePath = "/api/foo"
+
+	http GetFoo(id: string) => (value: int64) {
+		HttpMethod = "GET"
+		Path = "/{id}"
+	}
+}
+`,
+		},
+		{
+			Input: `
+service Foo {
+	http Chat(room: string, msgs: stream string) => (replies: stream string)
+}
+`,
+			Output: `
+service Foo {
+	http Chat(room: string, msgs: stream string) => (replies: stream string)
+}
+`,
+		},
+		{
+			Input: `
+service Foo {
+	rpc GetFoo(id?: int64, name: string) => (value: int64)
+}
+`,
+			Output: `
+service Foo {
+	rpc GetFoo(id?: int64, name: string) => (value: int64)
+}
+`,
+		},
+		{
+			Input: `
+service Foo {
+	http DeleteFoo(id: string) {
+		HttpMethod = "DELETE"
+	}
+}
+`,
+			Output: `
+service Foo {
+	http DeleteFoo(id: string) {
+		HttpMethod = "DELETE"
+	}
+}
+`,
+		},
+		{
+			Input: `
+service Foo {
+	# sent once a user is created
+	event UserCreated(user: User, source?: string)
+}
+`,
+			Output: `
+service Foo {
+	# sent once a user is created
+	event UserCreated(user: User, source?: string)
+}
+`,
+		},
+		{
+			Input: `
+service Foo {
+	rpc, event UserCreated(user: User)
+}
+`,
+			Error: `
+event can't be combined with other method types: ->event<-
+
+service Foo {
+	rpc, event UserCreated(user: User)
+}
 `,
 		},
 	}
@@ -2,8 +2,10 @@ package parser
 
 import (
 	"strconv"
+	"strings"
 
 	"compiler.ella.to/internal/ast"
+	"compiler.ella.to/internal/ast/astutil"
 	"compiler.ella.to/internal/token"
 	"compiler.ella.to/pkg/strcase"
 )
@@ -47,7 +49,7 @@ func ParseType(p *Parser) (ast.Type, error) {
 	case token.Identifier:
 		nameTok := p.Next()
 
-		if !strcase.IsPascal(nameTok.Literal) {
+		if !isTypeName(nameTok.Literal) {
 			return nil, p.WithError(nameTok, "custom type name must be in PascalCase format")
 		}
 
@@ -139,3 +141,14 @@ func extractTypeBits(prefix string, value string) int {
 	result, _ := strconv.ParseInt(value[len(prefix):], 10, 64)
 	return int(result)
 }
+
+// isTypeName reports whether the name refers to a model or an enum, the name can be
+// qualified by the name of an imported package, e.g. common.User
+func isTypeName(name string) bool {
+	pkg, name := astutil.SplitQualifiedName(name)
+	if strings.Contains(pkg, ".") {
+		return false
+	}
+
+	return strcase.IsPascal(name)
+}
@@ -16,6 +16,12 @@ type Lexer struct {
 	start   int
 	pos     int
 	width   int
+
+	// line is the current line number and lineStart is its offset, newlines
+	// are counted up to scanned offset
+	line      int
+	lineStart int
+	scanned   int
 }
 
 func (l *Lexer) Current() string {
@@ -23,16 +29,32 @@ func (l *Lexer) Current() string {
 }
 
 func (l *Lexer) Emit(typ token.Type) {
+	line, column := l.position(l.start)
 	token := &token.Token{
 		Type:    typ,
 		Literal: l.input[l.start:l.pos],
 		Start:   l.start,
 		End:     l.pos,
+		Line:    line,
+		Column:  column,
 	}
 	l.emitter.Emit(token)
 	l.start = l.pos
 }
 
+// position returns the line and column of the offset, offsets must
+// be requested in increasing order as tokens are emitted
+func (l *Lexer) position(offset int) (line, column int) {
+	for ; l.scanned < offset && l.scanned < len(l.input); l.scanned++ {
+		if l.input[l.scanned] == '\n' {
+			l.line++
+			l.lineStart = l.scanned + 1
+		}
+	}
+
+	return l.line, offset - l.lineStart + 1
+}
+
 func (l *Lexer) Next() rune {
 	if l.pos >= len(l.input) {
 		l.width = 0
@@ -106,11 +128,14 @@ func (l *Lexer) AcceptRunUntil(invalid string) {
 }
 
 func (l *Lexer) Errorf(format string, args ...interface{}) {
+	line, column := l.position(l.start)
 	l.emitter.Emit(&token.Token{
 		Type:    token.Error,
 		Literal: fmt.Sprintf(format, args...),
 		Start:   l.start,
 		End:     l.pos,
+		Line:    line,
+		Column:  column,
 	})
 }
 
@@ -126,14 +151,22 @@ func Start(emitter token.Emitter, inital State, input string) {
 	lexer := &Lexer{
 		emitter: emitter,
 		input:   input,
+		line:    1,
 	}
 	for state := inital; state != nil; {
 		state = state(lexer)
 	}
 }
 
+// File is the content of a file which is scanned by StartWithFiles
+type File struct {
+	Name    string
+	Content string
+}
+
 func StartWithFilenames(emitter token.Emitter, inital State, filenames ...string) {
-	for i, filename := range filenames {
+	files := make([]File, 0, len(filenames))
+	for _, filename := range filenames {
 		b, err := os.ReadFile(filename)
 		if err != nil {
 			emitter.Emit(&token.Token{
@@ -143,17 +176,35 @@ func StartWithFilenames(emitter token.Emitter, inital State, filenames ...string
 			})
 			return
 		}
+		files = append(files, File{Name: filename, Content: string(b)})
+	}
+
+	StartWithFiles(emitter, inital, files...)
+}
+
+// StartWithFiles scans the files one after another, as if they are a single input,
+// but tokens keep the name of their file and their positions are relative to it
+func StartWithFiles(emitter token.Emitter, inital State, files ...File) {
+	failed := false
+
+	for i, file := range files {
 		Start(token.EmitterFunc(func(tok *token.Token) {
-			if tok.Type == token.EOF && i != len(filenames)-1 {
+			if tok.Type == token.EOF && i != len(files)-1 {
 				// because we havn't process all the files,
 				// we simply ignore EOF of previous procceed files
 				return
 			}
 
-			tok.Filename = filename
+			failed = tok.Type == token.Error
+			tok.Filename = file.Name
 			emitter.Emit(tok)
 
-		}), inital, string(b))
+		}), inital, file.Content)
+
+		if failed {
+			// scanning stops at the first error, same as a single file
+			return
+		}
 	}
 }
 
@@ -30,6 +30,10 @@ func Lex(l *Lexer) State {
 		l.Next()
 		l.Emit(token.Comma)
 		return Lex
+	case '?':
+		l.Next()
+		l.Emit(token.Optional)
+		return Lex
 	case '.':
 		l.Next()
 		if l.Next() != '.' {
@@ -76,14 +80,16 @@ func Lex(l *Lexer) State {
 		l.Emit(token.Array)
 		return Lex
 	case '#':
+		// a comment is a top comment if nothing is written before it in the same line
+		lineStart := strings.LastIndexAny(l.input[:l.start], "\n\r") + 1
+		topComment := newLine || strings.TrimSpace(l.input[lineStart:l.start]) == ""
+
 		l.Next()
 		l.Ignore()
 		l.AcceptRunUntil("\n\r")
-		if newLine {
-			l.Ignore()
-			// l.Emit(token.TopComment)
+		if topComment {
+			l.Emit(token.TopComment)
 		} else {
-			l.Ignore()
 			l.Emit(token.RightComment)
 		}
 	case '\'':
@@ -128,6 +134,12 @@ func Lex(l *Lexer) State {
 		}
 
 		l.AcceptRunUntil("=,.:?{}()<>[]# \t\n\r")
+		// qualified identifiers, e.g. common.User, are emitted as a single
+		// identifier, a dot followed by another dot is an extend (...)
+		for l.Current() != "" && l.Peek() == '.' && l.PeekN(2) != ".." {
+			l.Next()
+			l.AcceptRunUntil("=,.:?{}()<>[]# \t\n\r")
+		}
 		if l.Current() == "" {
 			l.Errorf("expect something but got nothing")
 			return nil
@@ -272,9 +284,15 @@ func reservedKeywrod(l *Lexer) bool {
 	case "rpc":
 		l.Emit(token.Rpc)
 		return true
+	case "event":
+		l.Emit(token.Event)
+		return true
 	case "service":
 		l.Emit(token.Service)
 		return true
+	case "import":
+		l.Emit(token.Import)
+		return true
 	case "byte":
 		l.Emit(token.Byte)
 		return true
@@ -3,6 +3,8 @@ package scanner_test
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+
 	"compiler.ella.to/internal/scanner"
 	"compiler.ella.to/internal/token"
 )
@@ -34,6 +36,33 @@ func TestLex(t *testing.T) {
 				{Type: token.EOF, Start: 75, End: 75, Literal: ""},
 			},
 		},
+		{
+			input: `rpc Foo(id?: int64)`,
+			output: Tokens{
+				{Type: token.Rpc, Start: 0, End: 3, Literal: "rpc"},
+				{Type: token.Identifier, Start: 4, End: 7, Literal: "Foo"},
+				{Type: token.OpenParen, Start: 7, End: 8, Literal: "("},
+				{Type: token.Identifier, Start: 8, End: 10, Literal: "id"},
+				{Type: token.Optional, Start: 10, End: 11, Literal: "?"},
+				{Type: token.Colon, Start: 11, End: 12, Literal: ":"},
+				{Type: token.Int64, Start: 13, End: 18, Literal: "int64"},
+				{Type: token.CloseParen, Start: 18, End: 19, Literal: ")"},
+				{Type: token.EOF, Start: 19, End: 19, Literal: ""},
+			},
+		},
+		{
+			input: `event UserCreated(user: User)`,
+			output: Tokens{
+				{Type: token.Event, Start: 0, End: 5, Literal: "event"},
+				{Type: token.Identifier, Start: 6, End: 17, Literal: "UserCreated"},
+				{Type: token.OpenParen, Start: 17, End: 18, Literal: "("},
+				{Type: token.Identifier, Start: 18, End: 22, Literal: "user"},
+				{Type: token.Colon, Start: 22, End: 23, Literal: ":"},
+				{Type: token.Identifier, Start: 24, End: 28, Literal: "User"},
+				{Type: token.CloseParen, Start: 28, End: 29, Literal: ")"},
+				{Type: token.EOF, Start: 29, End: 29, Literal: ""},
+			},
+		},
 		{
 			input: `service Foo {
 				rpc GetFoo() => (value: int64) {
@@ -79,7 +108,6 @@ func TestLex(t *testing.T) {
 			},
 		},
 		{
-			skip: true,
 			input: `
 			
 			# this is a comment 1
@@ -115,7 +143,6 @@ func TestLex(t *testing.T) {
 			},
 		},
 		{
-			skip: true,
 			input: `
 
 			# This is a first comment
@@ -166,7 +193,6 @@ func TestLex(t *testing.T) {
 			},
 		},
 		{
-			skip: true,
 			input: `enum a int64 {
 				one = 1 # comment
 				two = 2# comment2
@@ -312,6 +338,23 @@ func TestLex(t *testing.T) {
 				{Type: token.EOF, Start: 82, End: 82, Literal: ""},
 			},
 		},
+		{
+			input: `import "../common/types.ella" model A { ...common.Base User: common.User }`,
+			output: Tokens{
+				{Type: token.Import, Start: 0, End: 6, Literal: "import"},
+				{Type: token.ConstStringDoubleQuote, Start: 8, End: 28, Literal: "../common/types.ella"},
+				{Type: token.Model, Start: 30, End: 35, Literal: "model"},
+				{Type: token.Identifier, Start: 36, End: 37, Literal: "A"},
+				{Type: token.OpenCurly, Start: 38, End: 39, Literal: "{"},
+				{Type: token.Extend, Start: 40, End: 43, Literal: "..."},
+				{Type: token.Identifier, Start: 43, End: 54, Literal: "common.Base"},
+				{Type: token.Identifier, Start: 55, End: 59, Literal: "User"},
+				{Type: token.Colon, Start: 59, End: 60, Literal: ":"},
+				{Type: token.Identifier, Start: 61, End: 72, Literal: "common.User"},
+				{Type: token.CloseCurly, Start: 73, End: 74, Literal: "}"},
+				{Type: token.EOF, Start: 74, End: 74, Literal: ""},
+			},
+		},
 	})
 }
 
@@ -384,3 +427,24 @@ func TestNumber(t *testing.T) {
 		},
 	)
 }
+
+func TestPositions(t *testing.T) {
+	input := "model User {\n\tName: string\n\n  # comment\n}"
+
+	var positions [][2]int
+	scanner.Start(token.EmitterFunc(func(tok *token.Token) {
+		positions = append(positions, [2]int{tok.Line, tok.Column})
+	}), scanner.Lex, input)
+
+	assert.Equal(t, [][2]int{
+		{1, 1},  // model
+		{1, 7},  // User
+		{1, 12}, // {
+		{2, 2},  // Name
+		{2, 6},  // :
+		{2, 8},  // string
+		{4, 4},  // # comment
+		{5, 1},  // }
+		{5, 2},  // EOF
+	}, positions)
+}
@@ -41,7 +41,10 @@ func runTestCase(t *testing.T, target int, initState scanner.State, testCases Te
 		}
 		output := make(Tokens, 0)
 		emitter := token.EmitterFunc(func(token *token.Token) {
-			output = append(output, *token)
+			// line and column are tested separately by TestPositions
+			tok := *token
+			tok.Line, tok.Column = 0, 0
+			output = append(output, tok)
 		})
 
 		scanner.Start(emitter, initState, tc.input)
@@ -6,8 +6,10 @@ type Token struct {
 	Filename string
 	Literal  string
 	Type     Type
-	Start    int
+	Start    int // byte offset of the token in the file
 	End      int
+	Line     int // 1-based line of the token's start
+	Column   int // 1-based column of the token's start in bytes
 }
 
 type Emitter interface {
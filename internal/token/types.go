@@ -9,6 +9,7 @@ const (
 	Model                                // model
 	Http                                 // http
 	Rpc                                  // rpc
+	Event                                // event
 	Service                              // service
 	Byte                                 // byte
 	Bool                                 // bool
@@ -52,6 +53,8 @@ const (
 	RightComment                         // #
 	TopComment                           // #
 	CustomError                          // error
+	Optional                             // ?
+	Import                               // import
 )
 
 func (t Type) String() string {
@@ -72,6 +75,8 @@ func (t Type) String() string {
 		return "Http"
 	case Rpc:
 		return "Rpc"
+	case Event:
+		return "Event"
 	case Service:
 		return "Service"
 	case Byte:
@@ -158,6 +163,10 @@ func (t Type) String() string {
 		return "TopComment"
 	case CustomError:
 		return "CustomError"
+	case Optional:
+		return "Optional"
+	case Import:
+		return "Import"
 	default:
 		return "Unknown"
 	}
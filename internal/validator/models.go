@@ -2,11 +2,16 @@ package validator
 
 import (
 	"fmt"
+	"math"
+	"math/big"
+	"regexp"
 	"slices"
 	"strings"
 
 	"compiler.ella.to/internal/ast"
 	"compiler.ella.to/internal/ast/astutil"
+	"compiler.ella.to/internal/diagnostic"
+	"compiler.ella.to/pkg/strcase"
 )
 
 // Validates the messages of the program from the following aspects:
@@ -19,11 +24,18 @@ import (
 // - name of the fields has to be PascalCase
 // - name of the fields has to be unique per message
 //   - if true, type must be the same and this indicates options needs to be overwritten
+//
+// - validation options of the fields must fit the field's type
 func validateModels(prog *ast.Program) error {
+	// fields of the messages in a cycle can't be merged, so the rest is skipped
+	if err := checkModelCycles(prog); err != nil {
+		return err
+	}
+
 	return runValidators(
 		prog,
-		checkModelCycles,
 		mergeExtendFields,
+		validateFieldsOptions,
 	)
 }
 
@@ -75,7 +87,7 @@ func checkModelCycles(prog *ast.Program) error {
 	}(dependencyGraph)
 
 	if hasCycles {
-		return fmt.Errorf("message %s is part of a cycle", cycleName)
+		return diagnostic.Errorf(astutil.CreateModelTypeMap(messages)[cycleName].Name.Token, "message %s is part of a cycle", cycleName)
 	}
 
 	return nil
@@ -87,38 +99,44 @@ func mergeExtendFields(prog *ast.Program) error {
 	isValidType := astutil.CreateIsValidType(prog)
 	constantsMap := astutil.CreateConstsMap(prog)
 
+	var diags diagnostic.Diagnostics
+
 	for _, message := range messages {
 		// check if all the extends are uniques
 		extends := make(map[string]struct{})
 
 		for _, extend := range message.Extends {
 			if _, ok := extends[extend.String()]; ok {
-				return fmt.Errorf("message %s is extending %s multiple times", message.Name, extend)
+				diags = append(diags, diagnostic.Errorf(extend.Token, "message %s is extending %s multiple times", message.Name, extend))
+				continue
 			}
 			extends[extend.String()] = struct{}{}
 
 			baseModel, ok := messagesMap[extend.String()]
 			if !ok {
-				return fmt.Errorf("message %s is extending unknown message %s", message.Name, extend)
+				diags = append(diags, diagnostic.Errorf(extend.Token, "message %s is extending unknown message %s", message.Name, extend))
+				continue
 			}
 
-			if err := mergeFields(message, baseModel, isValidType, constantsMap); err != nil {
-				return err
-			}
+			diags.Add(mergeFields(message, baseModel, isValidType, constantsMap))
 		}
 	}
 
-	return nil
+	return diags.Err()
 }
 
+// mergeFields adds the fields of base into target, unknown constants in the options are
+// ignored here, as they are reported by validateFieldsOptions
 func mergeFields(target *ast.Model, base *ast.Model, isValidType func(typ ast.Type) bool, constantsMap map[string]*ast.Const) error {
+	var diags diagnostic.Diagnostics
+
 	// append all the base fields at the beginning of the target fields
 	target.Fields = append(base.Fields, target.Fields...)
 
 	// check the fields type
 	for _, field := range target.Fields {
 		if !isValidType(field.Type) {
-			return fmt.Errorf("message %s has a field %s with an invalid type %s", target.Name, field.Name, field.Type)
+			diags = append(diags, diagnostic.Errorf(field.Name.Token, "message %s has a field %s with an invalid type %s", target.Name, field.Name, field.Type))
 		}
 	}
 
@@ -126,22 +144,30 @@ func mergeFields(target *ast.Model, base *ast.Model, isValidType func(typ ast.Ty
 	for _, field := range target.Fields {
 		baseFiled, ok := fieldsMap[field.Name.String()]
 		if !ok {
-			err := prepareFieldOptions(field, constantsMap)
-			if err != nil {
-				return err
-			}
+			prepareFieldOptions(field, constantsMap)
 			fieldsMap[field.Name.String()] = field
 			continue
 		}
 
 		if baseFiled.Type.String() != field.Type.String() {
-			return fmt.Errorf("message %s has a field %s with a different type %s", target.Name, field.Name, field.Type)
+			diags = append(diags, diagnostic.Errorf(field.Name.Token, "message %s has a field %s with a different type %s", target.Name, field.Name, field.Type))
+			continue
 		}
 
-		err := mergeFieldOptions(baseFiled, field, constantsMap)
-		if err != nil {
-			return err
+		// the redefined field decides whether the field is optional, a copy is created
+		// as fields of the base message are shared with all the messages extending it
+		merged := &ast.Field{
+			Name:     baseFiled.Name,
+			Type:     baseFiled.Type,
+			Optional: field.Optional,
+			Options:  baseFiled.Options,
+		}
+
+		if err := mergeFieldOptions(merged, field, constantsMap); err != nil {
+			diags = append(diags, diagnostic.Errorf(field.Name.Token, "message %s has a field %s with an invalid option: %s", target.Name, field.Name, err))
 		}
+
+		fieldsMap[field.Name.String()] = merged
 	}
 
 	target.Fields = make([]*ast.Field, 0, len(fieldsMap))
@@ -157,22 +183,211 @@ func mergeFields(target *ast.Model, base *ast.Model, isValidType func(typ ast.Ty
 	// if not, check if the type is the same
 	// if not, return an error
 
-	return nil
+	return diags.Err()
 }
 
-func prepareFieldOptions(field *ast.Field, constantsMap map[string]*ast.Const) error {
-	for _, option := range field.Options {
-		value, err := getValue(option.Value, constantsMap)
-		if err != nil {
-			return err
+// validateFieldsOptions resolves the constants used in fields' options and makes sure
+// the validation options, Required, Min, Max, MinLen, MaxLen, Pattern and MaxSize
+// can be applied to the field's type
+func validateFieldsOptions(prog *ast.Program) error {
+	var diags diagnostic.Diagnostics
+
+	constantsMap := astutil.CreateConstsMap(prog)
+	enumsMap := createEnumsMap(prog)
+	for _, message := range astutil.GetModels(prog) {
+		for _, field := range message.Fields {
+			valid := true
+
+			for _, option := range field.Options {
+				value, err := getValue(option.Value, constantsMap)
+				if err == nil {
+					option.Value = value
+					err = validateFieldOption(field, option, enumsMap)
+				}
+
+				if err != nil {
+					valid = false
+					diags = append(diags, diagnostic.Errorf(option.Name.Token, "message %s has a field %s with an invalid option %s: %s", message.Name, field.Name, option.Name, err))
+				}
+			}
+
+			if !valid {
+				continue
+			}
+
+			validation := astutil.ParseFieldValidation(field.Options)
+			if validation.MinLen != nil && validation.MaxLen != nil && *validation.MinLen > *validation.MaxLen {
+				diags = append(diags, diagnostic.Errorf(field.Name.Token, "message %s has a field %s with MinLen greater than MaxLen", message.Name, field.Name))
+			}
 		}
+	}
 
-		option.Value = value
+	return diags.Err()
+}
+
+func validateFieldOption(field *ast.Field, option *ast.Option, enumsMap map[string]*ast.Enum) error {
+	switch strcase.ToPascal(option.Name.String()) {
+	case "Required":
+		v, ok := option.Value.(*ast.ValueBool)
+		if !ok {
+			return fmt.Errorf("expected bool value")
+		}
+		if v.Value && field.Optional {
+			return fmt.Errorf("optional field can't be required")
+		}
+		if isNumericType(field.Type) {
+			return fmt.Errorf("numeric type %s can't be required, use Min or Max instead", field.Type)
+		}
+		if _, ok := field.Type.(*ast.Bool); ok {
+			return fmt.Errorf("bool type can't be required")
+		}
+		// a required enum is checked against its zero value, so the zero value
+		// can't be one of its keys
+		if enum, ok := enumsMap[field.Type.String()]; ok {
+			for _, set := range enum.Sets {
+				if set.Name.String() != "_" && set.Value.Value == 0 {
+					return fmt.Errorf("enum %s can't be required as %s is its zero value, use _ as its first key", enum.Name, set.Name)
+				}
+			}
+		}
+	case "Min", "Max":
+		if !isNumericType(field.Type) {
+			return fmt.Errorf("expected numeric type but got %s", field.Type)
+		}
+		switch option.Value.(type) {
+		case *ast.ValueFloat:
+			if _, ok := field.Type.(*ast.Float); !ok {
+				return fmt.Errorf("float value can't be used for type %s", field.Type)
+			}
+		case *ast.ValueInt, *ast.ValueUint, *ast.ValueByteSize, *ast.ValueDuration:
+		default:
+			return fmt.Errorf("expected numeric value")
+		}
+		if !isNumberInRange(field.Type, option.Value) {
+			return fmt.Errorf("value %s is out of range of type %s", astutil.NumberLiteral(option.Value), field.Type)
+		}
+	case "MinLen", "MaxLen":
+		switch field.Type.(type) {
+		case *ast.String, *ast.Array, *ast.Map:
+		default:
+			return fmt.Errorf("expected string, array or map type but got %s", field.Type)
+		}
+		v, ok := option.Value.(*ast.ValueInt)
+		if !ok {
+			return fmt.Errorf("expected int value")
+		}
+		if v.Value < 0 {
+			return fmt.Errorf("length can't be negative")
+		}
+	case "Pattern":
+		_, isString := field.Type.(*ast.String)
+		if !isString && !isArrayOfType[*ast.String](field.Type) {
+			return fmt.Errorf("expected string or array of strings type but got %s", field.Type)
+		}
+		v, ok := option.Value.(*ast.ValueString)
+		if !ok {
+			return fmt.Errorf("expected string value")
+		}
+		if _, err := regexp.Compile(v.Value); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	case "MaxSize":
+		_, isString := field.Type.(*ast.String)
+		if !isString && !isArrayOfType[*ast.Byte](field.Type) {
+			return fmt.Errorf("expected string or array of bytes type but got %s", field.Type)
+		}
+		switch v := option.Value.(type) {
+		case *ast.ValueByteSize:
+		case *ast.ValueInt:
+			if v.Value < 0 {
+				return fmt.Errorf("size can't be negative")
+			}
+		default:
+			return fmt.Errorf("expected byte size value")
+		}
 	}
 
 	return nil
 }
 
+func isNumericType(typ ast.Type) bool {
+	switch typ.(type) {
+	case *ast.Int, *ast.Uint, *ast.Float, *ast.Byte:
+		return true
+	default:
+		return false
+	}
+}
+
+// isNumberInRange reports whether the value can be represented by the numeric type,
+// otherwise the generated comparisons don't compile
+func isNumberInRange(typ ast.Type, value ast.Value) bool {
+	var number big.Float
+	if _, ok := number.SetString(astutil.NumberLiteral(value)); !ok {
+		return false
+	}
+
+	var min, max big.Float
+
+	switch typ := typ.(type) {
+	case *ast.Byte:
+		max.SetUint64(math.MaxUint8)
+	case *ast.Uint:
+		max.SetUint64(math.MaxUint64 >> (64 - numericTypeSize(typ.Size)))
+	case *ast.Int:
+		size := numericTypeSize(typ.Size)
+		min.SetInt64(math.MinInt64 >> (64 - size))
+		max.SetInt64(math.MaxInt64 >> (64 - size))
+	case *ast.Float:
+		if typ.Size != 32 {
+			return true
+		}
+		min.SetFloat64(-math.MaxFloat32)
+		max.SetFloat64(math.MaxFloat32)
+	default:
+		return false
+	}
+
+	return number.Cmp(&min) >= 0 && number.Cmp(&max) <= 0
+}
+
+// numericTypeSize returns the bits of int and uint types, which are 64 if not specified
+func numericTypeSize(size int) int {
+	if size <= 0 || size > 64 {
+		return 64
+	}
+	return size
+}
+
+func createEnumsMap(prog *ast.Program) map[string]*ast.Enum {
+	enumsMap := make(map[string]*ast.Enum)
+	for _, enum := range astutil.GetEnums(prog) {
+		enumsMap[enum.Name.String()] = enum
+	}
+
+	return enumsMap
+}
+
+func isArrayOfType[T ast.Type](typ ast.Type) bool {
+	arr, ok := typ.(*ast.Array)
+	if !ok {
+		return false
+	}
+
+	_, ok = arr.Type.(T)
+	return ok
+}
+
+// prepareFieldOptions resolves the constants used in the field's options,
+// unknown constants are kept as they are
+func prepareFieldOptions(field *ast.Field, constantsMap map[string]*ast.Const) {
+	for _, option := range field.Options {
+		if value, err := getValue(option.Value, constantsMap); err == nil {
+			option.Value = value
+		}
+	}
+}
+
 func mergeFieldOptions(target, base *ast.Field, constantsMap map[string]*ast.Const) error {
 	target.Options = append(base.Options, target.Options...)
 
@@ -193,14 +408,16 @@ func mergeFieldOptions(target, base *ast.Field, constantsMap map[string]*ast.Con
 	return nil
 }
 
+// mergeOptionValue makes sure both options have the same type, unknown constants
+// are reported by validateFieldsOptions
 func mergeOptionValue(target, base *ast.Option, constantsMap map[string]*ast.Const) error {
 	targetValue, err := getValue(target.Value, constantsMap)
 	if err != nil {
-		return err
+		return nil
 	}
 	baseValue, err := getValue(base.Value, constantsMap)
 	if err != nil {
-		return err
+		return nil
 	}
 
 	targetType := astutil.GetValueType(targetValue)
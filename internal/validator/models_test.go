@@ -0,0 +1,107 @@
+package validator_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"compiler.ella.to/internal/diagnostic"
+	"compiler.ella.to/internal/parser"
+	"compiler.ella.to/internal/validator"
+)
+
+func TestValidateFieldsOptions(t *testing.T) {
+	testCases := []struct {
+		Name  string
+		Input string
+		Diags []string
+	}{
+		{
+			Name: "numbers in range",
+			Input: `
+model User {
+	Age: int8 {
+		Min = -128
+		Max = 127
+	}
+	Score: uint16 {
+		Max = 65535
+	}
+	Size: byte {
+		Max = 255
+	}
+	Ratio: float32 {
+		Max = 1.5
+	}
+}`,
+		},
+		{
+			Name: "numbers out of range",
+			Input: `
+model User {
+	Age: int8 {
+		Min = -129
+		Max = 1000
+	}
+	Score: uint32 {
+		Min = -1
+	}
+	Size: byte {
+		Max = 1kb
+	}
+}`,
+			Diags: []string{
+				"4:3: error: message User has a field Age with an invalid option Min: value -129 is out of range of type int8",
+				"5:3: error: message User has a field Age with an invalid option Max: value 1000 is out of range of type int8",
+				"8:3: error: message User has a field Score with an invalid option Min: value -1 is out of range of type uint32",
+				"11:3: error: message User has a field Size with an invalid option Max: value 1024 is out of range of type byte",
+			},
+		},
+		{
+			Name: "required enum with zero key",
+			Input: `
+enum Role {
+	Admin
+	User
+}
+
+model Account {
+	Role: Role {
+		Required
+	}
+}`,
+			Diags: []string{
+				"9:3: error: message Account has a field Role with an invalid option Required: enum Role can't be required as Admin is its zero value, use _ as its first key",
+			},
+		},
+		{
+			Name: "required enum without zero key",
+			Input: `
+enum Role {
+	_
+	Admin
+	User
+}
+
+model Account {
+	Role: Role {
+		Required
+	}
+}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			prog, err := parser.ParseProgram(parser.New(tc.Input))
+			assert.NoError(t, err)
+
+			var diags []string
+			for _, diag := range diagnostic.From(validator.Validate(prog)) {
+				diags = append(diags, diag.Error())
+			}
+
+			assert.Equal(t, tc.Diags, diags)
+		})
+	}
+}
@@ -0,0 +1,407 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"compiler.ella.to/internal/ast"
+	"compiler.ella.to/internal/ast/astutil"
+	"compiler.ella.to/internal/diagnostic"
+	"compiler.ella.to/pkg/strcase"
+)
+
+// Validates the services of the program from the following aspects:
+// - BasePath option of the service must be a path without placeholders
+// - Path option of http methods must be a valid path
+//   - every placeholder must be bound to an arg with a scalar type
+//   - wildcard placeholders, {name...}, must be the last segment and bound to a string arg
+//
+// - file args can't be optional
+// - stream args are only allowed in http methods, at most one per method
+// - methods with a stream arg must return a single stream and can only be GET
+// - rpc methods with a stream return must return a single stream
+// - events can't have returns, options or file args
+//
+// - http methods' routes must be unique
+// - args of GET methods must be encodable in query string
+func validateServices(prog *ast.Program) error {
+	return runValidators(
+		prog,
+		validateServicesOptions,
+		validateMethodsArgs,
+		validateRpcMethodsReturns,
+		validateEvents,
+		validateHttpRoutes,
+		validateHttpGetArgs,
+	)
+}
+
+func validateServicesOptions(prog *ast.Program) error {
+	var diags diagnostic.Diagnostics
+
+	isEnumType := astutil.CreateIsEnumTypeFunc(astutil.GetEnums(prog))
+
+	for _, service := range astutil.GetServices(prog) {
+		for _, option := range service.Options {
+			if err := validateServiceOption(option); err != nil {
+				diags = append(diags, diagnostic.Errorf(option.Name.Token, "service %s has an invalid option %s: %s", service.Name, option.Name, err))
+			}
+		}
+
+		for _, method := range service.Methods {
+			if method.Type != ast.MethodHTTP {
+				continue
+			}
+
+			for _, option := range method.Options {
+				if strcase.ToPascal(option.Name.String()) != "Path" {
+					continue
+				}
+
+				if err := validateMethodPath(method, option, isEnumType); err != nil {
+					diags = append(diags, diagnostic.Errorf(option.Name.Token, "service %s has a method %s with an invalid option %s: %s", service.Name, method.Name, option.Name, err))
+				}
+			}
+		}
+	}
+
+	return diags.Err()
+}
+
+func validateMethodsArgs(prog *ast.Program) error {
+	var diags diagnostic.Diagnostics
+
+	for _, service := range astutil.GetServices(prog) {
+		for _, method := range service.Methods {
+			streams := 0
+			for _, arg := range method.Args {
+				if _, ok := arg.Type.(*ast.File); ok && arg.Optional {
+					diags = append(diags, diagnostic.Errorf(arg.Name.Token, "service %s has a method %s with an optional file arg %s", service.Name, method.Name, arg.Name))
+				}
+
+				if arg.Stream {
+					streams++
+					if err := validateStreamArg(method, arg); err != nil {
+						diags = append(diags, diagnostic.Errorf(arg.Name.Token, "service %s has a method %s with an invalid stream arg %s: %s", service.Name, method.Name, arg.Name, err))
+					}
+				}
+			}
+
+			if streams > 1 {
+				diags = append(diags, diagnostic.Errorf(method.Name.Token, "service %s has a method %s with more than one stream arg", service.Name, method.Name))
+			}
+		}
+	}
+
+	return diags.Err()
+}
+
+func validateRpcMethodsReturns(prog *ast.Program) error {
+	var diags diagnostic.Diagnostics
+
+	for _, service := range astutil.GetServices(prog) {
+		for _, method := range service.Methods {
+			if method.Type != ast.MethodRPC {
+				continue
+			}
+
+			for _, ret := range method.Returns {
+				if ret.Stream && len(method.Returns) != 1 {
+					diags = append(diags, diagnostic.Errorf(ret.Name.Token, "service %s has a rpc method %s with a stream return %s which must be the only return", service.Name, method.Name, ret.Name))
+				}
+			}
+		}
+	}
+
+	return diags.Err()
+}
+
+func validateEvents(prog *ast.Program) error {
+	var diags diagnostic.Diagnostics
+
+	for _, service := range astutil.GetServices(prog) {
+		for _, method := range service.Methods {
+			if method.Type != ast.MethodEvent {
+				continue
+			}
+
+			if len(method.Returns) != 0 {
+				diags = append(diags, diagnostic.Errorf(method.Returns[0].Name.Token, "service %s has an event %s with returns, events don't have any reply", service.Name, method.Name))
+			}
+
+			if len(method.Options) != 0 {
+				diags = append(diags, diagnostic.Errorf(method.Options[0].Name.Token, "service %s has an event %s with options, events don't support any option", service.Name, method.Name))
+			}
+
+			for _, arg := range method.Args {
+				if _, ok := arg.Type.(*ast.File); ok {
+					diags = append(diags, diagnostic.Errorf(arg.Name.Token, "service %s has an event %s with a file arg %s", service.Name, method.Name, arg.Name))
+				}
+			}
+		}
+	}
+
+	return diags.Err()
+}
+
+func validateStreamArg(method *ast.Method, arg *ast.Arg) error {
+	if method.Type != ast.MethodHTTP {
+		return fmt.Errorf("stream args are only supported in http methods")
+	}
+
+	if arg.Optional {
+		return fmt.Errorf("stream args can't be optional")
+	}
+
+	if _, ok := arg.Type.(*ast.File); ok {
+		return fmt.Errorf("stream args can't be file")
+	}
+
+	if len(method.Returns) != 1 || !method.Returns[0].Stream {
+		return fmt.Errorf("method must return a single stream")
+	}
+
+	if array, ok := method.Returns[0].Type.(*ast.Array); ok {
+		if _, ok := array.Type.(*ast.Byte); ok {
+			return fmt.Errorf("method can't return a stream of []byte")
+		}
+	}
+
+	for _, option := range method.Options {
+		if strcase.ToPascal(option.Name.String()) != "HttpMethod" {
+			continue
+		}
+
+		if httpMethod := astutil.ParseMethodOptions(method.Options).HttpMethod; httpMethod != "GET" {
+			return fmt.Errorf("method is served over WebSocket which requires GET, but HttpMethod is %s", httpMethod)
+		}
+	}
+
+	return nil
+}
+
+func validateServiceOption(option *ast.Option) error {
+	switch strcase.ToPascal(option.Name.String()) {
+	case "BasePath":
+		v, ok := option.Value.(*ast.ValueString)
+		if !ok {
+			return fmt.Errorf("expected string value")
+		}
+		if err := validatePathSegments(v.Value); err != nil {
+			return err
+		}
+		if len(astutil.ParseHttpPathParams(v.Value)) > 0 {
+			return fmt.Errorf("base path can't have placeholders")
+		}
+	}
+
+	return nil
+}
+
+func validateMethodPath(method *ast.Method, option *ast.Option, isEnumType func(value string) bool) error {
+	v, ok := option.Value.(*ast.ValueString)
+	if !ok {
+		return fmt.Errorf("expected string value")
+	}
+
+	if err := validatePathSegments(v.Value); err != nil {
+		return err
+	}
+
+	params := astutil.ParseHttpPathParams(v.Value)
+
+	names := make(map[string]struct{})
+	for i, param := range params {
+		if _, ok := names[strcase.ToPascal(param.Name)]; ok {
+			return fmt.Errorf("placeholder {%s} is defined multiple times", param.Name)
+		}
+		names[strcase.ToPascal(param.Name)] = struct{}{}
+
+		if param.Wildcard && (i != len(params)-1 || !strings.HasSuffix(v.Value, "...}")) {
+			return fmt.Errorf("placeholder {%s...} must be the last segment of the path", param.Name)
+		}
+
+		var arg *ast.Arg
+		for _, candidate := range method.Args {
+			if strcase.ToPascal(candidate.Name.String()) == strcase.ToPascal(param.Name) {
+				arg = candidate
+				break
+			}
+		}
+
+		if arg == nil {
+			return fmt.Errorf("placeholder {%s} doesn't match any arg", param.Name)
+		}
+
+		if arg.Optional {
+			return fmt.Errorf("placeholder {%s} can't be bound to optional arg %s", param.Name, arg.Name)
+		}
+
+		if param.Wildcard {
+			if _, ok := arg.Type.(*ast.String); !ok {
+				return fmt.Errorf("placeholder {%s...} must be bound to a string arg but %s is %s", param.Name, arg.Name, arg.Type)
+			}
+			continue
+		}
+
+		if !isPathParamType(arg.Type, isEnumType) {
+			return fmt.Errorf("placeholder {%s} must be bound to a string, bool, numeric or enum arg but %s is %s", param.Name, arg.Name, arg.Type)
+		}
+	}
+
+	return nil
+}
+
+// validatePathSegments makes sure the path starts with '/' and placeholders
+// occupy the whole segment, e.g. /users/{id} is valid but /users/id-{id} is not
+func validatePathSegments(path string) error {
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("path %q must start with '/'", path)
+	}
+
+	for _, segment := range strings.Split(path, "/") {
+		if !strings.ContainsAny(segment, "{}") {
+			continue
+		}
+
+		name := strings.TrimSuffix(strings.TrimPrefix(segment, "{"), "}")
+		if len(segment) < 2 || segment[0] != '{' || segment[len(segment)-1] != '}' || strings.ContainsAny(name, "{}") {
+			return fmt.Errorf("placeholder %q must be the whole path segment", segment)
+		}
+
+		if !strcase.IsCamel(strings.TrimSuffix(name, "...")) && !strcase.IsPascal(strings.TrimSuffix(name, "...")) {
+			return fmt.Errorf("placeholder %q must be in camelCase or PascalCase format", segment)
+		}
+	}
+
+	return nil
+}
+
+func isPathParamType(typ ast.Type, isEnumType func(value string) bool) bool {
+	switch typ := typ.(type) {
+	case *ast.String, *ast.Bool, *ast.Int, *ast.Uint, *ast.Float:
+		return true
+	case *ast.CustomType:
+		return isEnumType(typ.String())
+	default:
+		return false
+	}
+}
+
+// validateHttpRoutes makes sure two http methods are not mounted on the same
+// route, placeholders' names are ignored, so /users/{id} and /users/{name} are the same.
+// Methods sharing the same path with different http methods must use the same placeholders.
+func validateHttpRoutes(prog *ast.Program) error {
+	var diags diagnostic.Diagnostics
+
+	routes := make(map[string]string)
+	paths := make(map[string]string)
+
+	for _, service := range astutil.GetServices(prog) {
+		for _, method := range service.Methods {
+			if method.Type != ast.MethodHTTP {
+				continue
+			}
+
+			path := astutil.HttpPath(service, method)
+
+			segments := strings.Split(path, "/")
+			for i, segment := range segments {
+				if strings.HasPrefix(segment, "{") {
+					segments[i] = "{}"
+				}
+			}
+
+			shape := strings.Join(segments, "/")
+			route := astutil.HttpMethod(method) + " " + shape
+			name := service.Name.String() + "." + method.Name.String()
+
+			if other, ok := routes[route]; ok {
+				diags = append(diags, diagnostic.Errorf(method.Name.Token, "service method %s has the same http route as %s", name, other))
+				continue
+			}
+
+			if other, ok := paths[shape]; ok && other != path {
+				diags = append(diags, diagnostic.Errorf(method.Name.Token, "service method %s has path %s which conflicts with %s", name, path, other))
+				continue
+			}
+
+			routes[route] = name
+			paths[shape] = path
+		}
+	}
+
+	return diags.Err()
+}
+
+// validateHttpGetArgs makes sure the args of GET methods, except the ones bound to
+// the path, can be encoded in query string, which supports scalars, enums, timestamps,
+// arrays of them and models whose fields are encodable as well
+func validateHttpGetArgs(prog *ast.Program) error {
+	var diags diagnostic.Diagnostics
+
+	isEnumType := astutil.CreateIsEnumTypeFunc(astutil.GetEnums(prog))
+	modelsMap := astutil.CreateModelTypeMap(astutil.GetModels(prog))
+
+	for _, service := range astutil.GetServices(prog) {
+		for _, method := range service.Methods {
+			if method.Type != ast.MethodHTTP || astutil.HttpMethod(method) != "GET" {
+				continue
+			}
+
+			params := astutil.ParseHttpPathParams(astutil.HttpPath(service, method))
+
+			for _, arg := range method.Args {
+				if _, ok := astutil.FindHttpPathParam(params, arg); ok || arg.Stream {
+					continue
+				}
+
+				if !isQueryType(arg.Type, isEnumType, modelsMap, map[string]bool{}) {
+					diags = append(diags, diagnostic.Errorf(arg.Name.Token, "service %s has a GET method %s with an arg %s of type %s which can't be encoded in query string", service.Name, method.Name, arg.Name, arg.Type))
+				}
+			}
+		}
+	}
+
+	return diags.Err()
+}
+
+// isQueryType returns true if the type can be encoded in query string, visiting
+// keeps track of models being checked, as recursive models can't be encoded
+func isQueryType(typ ast.Type, isEnumType func(value string) bool, modelsMap map[string]*ast.Model, visiting map[string]bool) bool {
+	switch typ := typ.(type) {
+	case *ast.String, *ast.Bool, *ast.Int, *ast.Uint, *ast.Float, *ast.Byte, *ast.Timestamp:
+		return true
+	case *ast.Array:
+		switch inner := typ.Type.(type) {
+		case *ast.Array, *ast.Map, *ast.Any, *ast.File:
+			return false
+		case *ast.CustomType:
+			return isEnumType(inner.String())
+		default:
+			return isQueryType(inner, isEnumType, modelsMap, visiting)
+		}
+	case *ast.CustomType:
+		if isEnumType(typ.String()) {
+			return true
+		}
+
+		model, ok := modelsMap[typ.String()]
+		if !ok || visiting[typ.String()] {
+			return false
+		}
+
+		visiting[typ.String()] = true
+		defer delete(visiting, typ.String())
+
+		for _, field := range model.Fields {
+			if !isQueryType(field.Type, isEnumType, modelsMap, visiting) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return false
+	}
+}
@@ -1,20 +1,23 @@
 package validator
 
 import (
-	"fmt"
-
 	"compiler.ella.to/internal/ast"
+	"compiler.ella.to/internal/diagnostic"
 )
 
+// Validate checks the program and returns all the violations as diagnostic.Diagnostics
 func Validate(prog *ast.Program) error {
 	return runValidators(
 		prog,
 		validateUniqueNames,
 		validateModels,
+		validateServices,
 	)
 }
 
 func validateUniqueNames(prog *ast.Program) error {
+	var diags diagnostic.Diagnostics
+
 	names := make(map[string]struct{})
 	for _, stmt := range prog.Statements {
 		var name string
@@ -22,38 +25,41 @@ func validateUniqueNames(prog *ast.Program) error {
 		case *ast.Const:
 			name = stmt.Name.String()
 			if _, ok := names[name]; ok {
-				return fmt.Errorf("const %s is defined multiple times", stmt.Name)
+				diags = append(diags, diagnostic.Errorf(stmt.Name.Token, "const %s is defined multiple times", stmt.Name))
 			}
 		case *ast.Enum:
 			name = stmt.Name.String()
 			if _, ok := names[name]; ok {
-				return fmt.Errorf("enum %s is defined multiple times", stmt.Name)
+				diags = append(diags, diagnostic.Errorf(stmt.Name.Token, "enum %s is defined multiple times", stmt.Name))
 			}
 		case *ast.Model:
 			name = stmt.Name.String()
 			if _, ok := names[name]; ok {
-				return fmt.Errorf("message %s is defined multiple times", stmt.Name)
+				diags = append(diags, diagnostic.Errorf(stmt.Name.Token, "message %s is defined multiple times", stmt.Name))
 			}
 		case *ast.Service:
 			name = stmt.Name.String()
 			if _, ok := names[name]; ok {
-				return fmt.Errorf("service %s is defined multiple times", stmt.Name)
+				diags = append(diags, diagnostic.Errorf(stmt.Name.Token, "service %s is defined multiple times", stmt.Name))
 			}
 		}
 		names[name] = struct{}{}
 	}
 
-	return nil
+	return diags.Err()
 }
 
 type ValidatorFunc func(prog *ast.Program) error
 
+// runValidators runs all the validators and collects their diagnostics
 func runValidators(prog *ast.Program, validatorFuncs ...ValidatorFunc) error {
+	var diags diagnostic.Diagnostics
+
 	for _, validatorFunc := range validatorFuncs {
 		if err := validatorFunc(prog); err != nil {
-			return err
+			diags.Add(err)
 		}
 	}
 
-	return nil
+	return diags.Err()
 }
@@ -1,17 +1,30 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/pmezard/go-difflib/difflib"
+
 	"compiler.ella.to/internal/ast"
 	"compiler.ella.to/internal/code"
-	"compiler.ella.to/internal/code/golang"
-	"compiler.ella.to/internal/code/typescript"
+	_ "compiler.ella.to/internal/code/golang"
+	_ "compiler.ella.to/internal/code/jsonschema"
+	_ "compiler.ella.to/internal/code/openapi"
+	_ "compiler.ella.to/internal/code/typescript"
+	"compiler.ella.to/internal/diagnostic"
+	"compiler.ella.to/internal/diff"
+	"compiler.ella.to/internal/loader"
+	"compiler.ella.to/internal/lsp"
 	"compiler.ella.to/internal/parser"
-	"compiler.ella.to/internal/validator"
 )
 
 const Version = "0.0.3"
@@ -24,19 +37,58 @@ const usage = `
 Usage: ella [command]
 
 Commands:
-  - fmt Format one or many files in place using glob pattern
-        ella fmt <glob path>
+  - fmt Format one or many files in place using glob patterns,
+        ** matches any number of folders. With --check, files are
+        not changed, the diff of unformatted files is printed and
+        it exits with non-zero status. Use - to read from stdin
+        and write to stdout
+        ella fmt [--check] [--format=text|json] <glob paths...>
+        ella fmt [--check] [--format=text|json] -
 
   - gen Generate code from a folder to a file and currently
-        supports .go and .ts extensions
-        ella gen <pkg> <output path to file> <search glob paths...>
+        supports .go and .ts extensions, imported packages are
+        generated next to the output's folder. Use .openapi.json
+        or .openapi.yaml to describe http services as OpenAPI 3.1
+        and .schema.json to export models and enums as JSON Schema.
+        The target is selected by the output's extension, or by
+        --target which is one of go, ts, openapi or jsonschema.
+        --templates loads .tmpl files from the directory which
+        replace the embedded templates with the same name or are
+        added to them
+        ella gen [--format=text|json] [--target <name>] [--templates <dir>] <pkg> <output path to file> <search glob paths...>
+
+  - diff Compare two versions of a schema and print the changes,
+        each version is either glob paths of ella files, quoted
+        to not be expanded by shell, or a snapshot. It exits with
+        non-zero status if there is any breaking change
+        ella diff [--format=text|json] <old> <new>
+
+  - snapshot Save the compiled schema as a JSON snapshot, which
+        can be compared later by diff, use - to write to stdout
+        ella snapshot <output path to file> <search glob paths...>
+
+  - lsp Start the language server which speaks the Language
+        Server Protocol over stdin and stdout
+        ella lsp
 
   - ver Print the version of ella
 
+Errors are printed as file:line:column: error: message, with
+--format=json, they are printed to stdout as a JSON array of
+diagnostics, which is empty if there is no error
+
 example:
   ella fmt ./path/to/*.ella
+  ella fmt --check ./path/**/*.ella
+  cat ./path/to/file.ella | ella fmt -
   ella gen rpc ./path/to/output.go ./path/to/*.ella
   ella gen rpc ./path/to/output.ts ./path/to/*.ella ./path/to/other/*.ella
+  ella gen rpc ./path/to/rpc.openapi.yaml ./path/to/*.ella
+  ella gen rpc ./path/to/rpc.schema.json ./path/to/*.ella
+  ella gen --templates ./path/to/templates rpc ./path/to/output.go ./path/to/*.ella
+  ella gen --format=json rpc ./path/to/output.go ./path/to/*.ella
+  ella snapshot ./api.snapshot.json ./path/to/*.ella
+  ella diff ./api.snapshot.json "./path/to/*.ella"
 `
 
 func main() {
@@ -45,21 +97,39 @@ func main() {
 		os.Exit(0)
 	}
 
-	var err error
+	opts, args, err := parseOptions(os.Args[2:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
 	switch os.Args[1] {
 	case "fmt":
-		if len(os.Args) < 3 {
+		if len(args) == 0 {
 			fmt.Print(usage)
 			os.Exit(0)
 		}
-		err = format(os.Args[2])
+		err = format(opts, args...)
 	case "gen":
-		if len(os.Args) < 5 {
+		if len(args) < 3 {
+			fmt.Print(usage)
+			os.Exit(0)
+		}
+		err = gen(opts, args[0], args[1], args[2:]...)
+	case "diff":
+		if len(args) != 2 {
 			fmt.Print(usage)
 			os.Exit(0)
 		}
-		err = gen(os.Args[2], os.Args[3], os.Args[4:]...)
+		err = compare(opts, args[0], args[1])
+	case "snapshot":
+		if len(args) < 2 {
+			fmt.Print(usage)
+			os.Exit(0)
+		}
+		err = snapshot(args[0], args[1:]...)
+	case "lsp":
+		err = lsp.New(os.Stdin, os.Stdout).Run()
 	case "ver":
 		fmt.Println(Version)
 	default:
@@ -67,36 +137,278 @@ func main() {
 		os.Exit(0)
 	}
 
+	if errors.Is(err, errBreakingChanges) {
+		// changes are already printed by diff
+		os.Exit(1)
+	}
+
+	diags := diagnostic.From(err)
+	diags.Sort()
+
+	if opts.format == "json" {
+		// formatted stdin is written to stdout, so diagnostics can't be mixed with it
+		w := os.Stdout
+		if os.Args[1] == "fmt" && !opts.check && len(args) == 1 && args[0] == "-" {
+			w = os.Stderr
+		}
+
+		if err := printJSON(w, diags); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	} else if err != nil {
+		printDiagnostics(os.Stderr, diags)
+	}
+
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
-func format(path string) error {
-	filenames, err := filepath.Glob(path)
+type options struct {
+	check     bool   // fmt only reports the unformatted files
+	format    string // format of the diagnostics, text or json
+	target    string // gen uses the registered target instead of output's extension
+	templates string // gen loads the templates from the directory in addition to embedded ones
+}
+
+// parseOptions separates the flags from the rest of args, flags can be placed anywhere,
+// and the flags which have a value accept both --name=value and --name value
+func parseOptions(args []string) (opts options, rest []string, err error) {
+	opts.format = "text"
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		// only args starting with - are flags, so files can be named like flags,
+		// and - alone is stdin
+		if arg == "-" || !strings.HasPrefix(arg, "-") {
+			rest = append(rest, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(arg, "=")
+
+		// reads the value of the flag from the next arg if it is not given by =
+		nextValue := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("missing value for %s", arg)
+			}
+			i++
+			return args[i], nil
+		}
+
+		switch name {
+		case "-check", "--check":
+			opts.check = true
+		case "-format", "--format":
+			if opts.format, err = nextValue(); err != nil {
+				return opts, nil, err
+			}
+			if opts.format != "text" && opts.format != "json" {
+				return opts, nil, fmt.Errorf("unknown format %q, expected text or json", opts.format)
+			}
+		case "-target", "--target":
+			if opts.target, err = nextValue(); err != nil {
+				return opts, nil, err
+			}
+		case "-templates", "--templates":
+			if opts.templates, err = nextValue(); err != nil {
+				return opts, nil, err
+			}
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	return opts, rest, nil
+}
+
+// printDiagnostics writes the diagnostics in compiler style followed by the
+// line of the source which they refer to, e.g.
+//
+//	api.ella:3:5: error: unknown type Foo
+//	  Name: Foo
+//	        ^^^
+func printDiagnostics(w io.Writer, diags diagnostic.Diagnostics) {
+	sources := make(map[string][]string)
+
+	for _, d := range diags {
+		fmt.Fprintln(w, d.Error())
+
+		if d.Filename == "" || d.Line == 0 {
+			continue
+		}
+
+		lines, ok := sources[d.Filename]
+		if !ok {
+			content, err := os.ReadFile(d.Filename)
+			if err == nil {
+				lines = strings.Split(string(content), "\n")
+			}
+			sources[d.Filename] = lines
+		}
+
+		if d.Line > len(lines) {
+			continue
+		}
+
+		line := lines[d.Line-1]
+		column := min(d.Column-1, len(line))
+
+		// tabs are kept, so the marker is aligned with the source line
+		indent := strings.Map(func(r rune) rune {
+			if r == '\t' {
+				return r
+			}
+			return ' '
+		}, line[:column])
+
+		width := max(min(d.Span.End-d.Span.Start, len(line)-column), 1)
+
+		fmt.Fprintf(w, "  %s\n  %s%s\n", line, indent, strings.Repeat("^", width))
+	}
+}
+
+func printJSON(w io.Writer, diags diagnostic.Diagnostics) error {
+	if diags == nil {
+		diags = diagnostic.Diagnostics{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(diags)
+}
+
+// format rewrites the files matched by the given glob paths in canonical format,
+// in check mode, files are left untouched and the diff of the unformatted ones
+// is printed instead, or they are reported as diagnostics in json format.
+// A single "-" path formats stdin to stdout. Files which can't be parsed are
+// reported and the rest of the files are formatted.
+func format(opts options, paths ...string) error {
+	for _, path := range paths {
+		if path != "-" {
+			continue
+		} else if len(paths) > 1 {
+			return fmt.Errorf("stdin can't be formatted along with other paths")
+		}
+		return formatStdin(opts)
+	}
+
+	filenames, err := mergeAllFiles(paths...)
 	if err != nil {
 		return err
 	}
 
+	var diags diagnostic.Diagnostics
+
 	for _, filename := range filenames {
-		prog, err := parse(filename)
+		content, err := os.ReadFile(filename)
 		if err != nil {
 			return err
 		}
 
-		err = os.WriteFile(filename, []byte(prog.String()), os.ModePerm)
+		formatted, err := parser.Format(string(content))
+		if err != nil {
+			diags = append(diags, withFilename(filename, err)...)
+			continue
+		}
+
+		if formatted == string(content) {
+			continue
+		}
+
+		if opts.check {
+			if err = reportUnformatted(opts, filename, string(content), formatted); err != nil {
+				return err
+			}
+			diags = append(diags, &diagnostic.Diagnostic{Filename: filename, Message: "file is not formatted"})
+			continue
+		}
+
+		err = os.WriteFile(filename, []byte(formatted), os.ModePerm)
 		if err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return diags.Err()
+}
+
+func formatStdin(opts options) error {
+	content, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	formatted, err := parser.Format(string(content))
+	if err != nil {
+		return withFilename("<stdin>", err)
+	}
+
+	if !opts.check {
+		_, err = io.WriteString(os.Stdout, formatted)
+		return err
+	}
+
+	if formatted == string(content) {
+		return nil
+	}
+
+	if err = reportUnformatted(opts, "<stdin>", string(content), formatted); err != nil {
+		return err
+	}
+
+	return &diagnostic.Diagnostic{Filename: "<stdin>", Message: "file is not formatted"}
+}
+
+// withFilename returns the diagnostics of the error, which belong to the given file,
+// as parser.Format doesn't know the name of the file
+func withFilename(filename string, err error) diagnostic.Diagnostics {
+	diags := diagnostic.From(err)
+	for _, d := range diags {
+		d.Filename = filename
+	}
+	return diags
+}
+
+// reportUnformatted prints the diff of the unformatted content, in json format
+// the diff is not printed, as stdout only contains the diagnostics
+func reportUnformatted(opts options, filename, original, formatted string) error {
+	if opts.format == "json" {
+		return nil
+	}
+
+	return printDiff(os.Stdout, filename, original, formatted)
+}
+
+// printDiff writes the unified diff between the original and formatted content
+func printDiff(w io.Writer, filename, original, formatted string) error {
+	return difflib.WriteUnifiedDiff(w, difflib.UnifiedDiff{
+		A:        splitLines(original),
+		B:        splitLines(formatted),
+		FromFile: filename + ".orig",
+		ToFile:   filename,
+		Context:  3,
+	})
 }
 
-func gen(pkg, out string, searchPaths ...string) (err error) {
-	var code code.Generator
+// splitLines splits the content into lines, the missing new line of the last line
+// is marked, so it shows up in the diff
+func splitLines(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	if last := lines[len(lines)-1]; last == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] = last + "\n\\ No newline at end of file\n"
+	}
+	return lines
+}
 
+func gen(opts options, pkg, out string, searchPaths ...string) (err error) {
 	defer func() {
 		if err != nil {
 			//os.Remove(out)
@@ -112,54 +424,212 @@ func gen(pkg, out string, searchPaths ...string) (err error) {
 		return fmt.Errorf("no ella's files found in the following paths: %s", strings.Join(searchPaths, ", "))
 	}
 
-	content, err := combine(filenames...)
+	for _, filename := range filenames {
+		if !strings.HasSuffix(filename, ".ella") {
+			return fmt.Errorf("invalid file extension %s", filename)
+		}
+	}
+
+	target, err := selectTarget(opts.target, out)
 	if err != nil {
 		return err
 	}
 
-	prog, err := parser.ParseProgram(parser.New(content))
+	pkgs, err := loader.Load(pkg, filenames)
 	if err != nil {
 		return err
 	}
 
-	err = validator.Validate(prog)
+	// the targets without package suffix write a single file for the root package,
+	// the models and enums of imported packages are already part of its program
+	if target.PackageSuffix == "" {
+		pkgs = pkgs[len(pkgs)-1:]
+	}
+
+	// imported packages are generated next to the output's directory,
+	// e.g. ./api/api.gen.go imports ./common/common.gen.go
+	absOut, err := filepath.Abs(out)
 	if err != nil {
 		return err
 	}
 
-	ext := filepath.Ext(out)
-	switch ext {
-	case ".go":
-		code = golang.New(pkg)
-	case ".ts":
-		code = typescript.New()
-	default:
-		return fmt.Errorf("unknown extension %s", out)
+	outs := make(map[*loader.Package]string)
+	for _, p := range pkgs {
+		outs[p] = filepath.Join(filepath.Dir(filepath.Dir(absOut)), p.Name, p.Name+target.PackageSuffix)
+	}
+	outs[pkgs[len(pkgs)-1]] = absOut
+
+	// package names are unique, but the root package might be generated
+	// to the output of an imported package, e.g. ./common/api.gen.go
+	pkgsByOut := make(map[string]*loader.Package)
+	for _, p := range pkgs {
+		if other, ok := pkgsByOut[outs[p]]; ok {
+			return fmt.Errorf("packages %s and %s are both generated to %s", other.Name, p.Name, outs[p])
+		}
+		pkgsByOut[outs[p]] = p
 	}
+	outs[pkgs[len(pkgs)-1]] = out
 
-	if err = code.Generate(out, prog); err != nil {
+	for _, p := range pkgs {
+		cfg := code.Config{
+			Pkg:       p.Name,
+			Out:       outs[p],
+			Templates: opts.templates,
+		}
+		for _, dep := range p.Imports {
+			cfg.Imports = append(cfg.Imports, code.Import{Name: dep.Name, Out: outs[dep]})
+		}
+
+		generator, err := target.New(cfg)
+		if err != nil {
+			return err
+		}
+
+		if err = os.MkdirAll(filepath.Dir(outs[p]), os.ModePerm); err != nil {
+			return err
+		}
+
+		if err = generator.Generate(outs[p], p.Program); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// selectTarget returns the target by its name, or the target which generates
+// the output's extension if the name is empty
+func selectTarget(name, out string) (code.Target, error) {
+	if name != "" {
+		target, ok := code.LookupTarget(name)
+		if !ok {
+			return target, fmt.Errorf("unknown target %q, expected one of %s", name, strings.Join(code.TargetNames(), ", "))
+		}
+		return target, nil
+	}
+
+	target, ok := code.MatchTarget(out)
+	if !ok {
+		return target, fmt.Errorf("unknown extension %s, use --target to select one of %s", out, strings.Join(code.TargetNames(), ", "))
+	}
+
+	return target, nil
+}
+
+var errBreakingChanges = errors.New("schema has breaking changes")
+
+// compare prints the changes between the old and new versions of the schema and
+// returns errBreakingChanges if any of them is breaking
+func compare(opts options, oldPath, newPath string) error {
+	oldProg, err := loadProgram(oldPath)
+	if err != nil {
 		return err
 	}
 
+	newProg, err := loadProgram(newPath)
+	if err != nil {
+		return err
+	}
+
+	changes := diff.Compare(oldProg, newProg)
+
+	if opts.format == "json" {
+		if changes == nil {
+			changes = diff.Changes{}
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err = encoder.Encode(changes); err != nil {
+			return err
+		}
+	} else {
+		for _, change := range changes {
+			fmt.Println(change)
+		}
+	}
+
+	if breaking := changes.Breaking(); len(breaking) > 0 {
+		if opts.format != "json" {
+			fmt.Fprintf(os.Stderr, "found %d breaking changes\n", len(breaking))
+		}
+		return errBreakingChanges
+	}
+
 	return nil
 }
 
-func parse(filename string) (*ast.Program, error) {
-	content, err := os.ReadFile(filename)
+// snapshot writes the compiled program of the schema, which is loaded by loadProgram
+func snapshot(out string, searchPaths ...string) error {
+	prog, err := loadSchema(searchPaths...)
+	if err != nil {
+		return err
+	}
+
+	text, err := prog.MarshalText()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err = json.Indent(&buf, text, "", "  "); err != nil {
+		return err
+	}
+	buf.WriteString("\n")
+
+	if out == "-" {
+		_, err = buf.WriteTo(os.Stdout)
+		return err
+	}
+
+	return os.WriteFile(out, buf.Bytes(), os.ModePerm)
+}
+
+// loadProgram returns the compiled program of a snapshot, if path has .json
+// extension, otherwise path is a glob of ella files
+func loadProgram(path string) (*ast.Program, error) {
+	if filepath.Ext(path) != ".json" {
+		return loadSchema(path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var prog ast.Program
+	if err = prog.UnmarshalText(content); err != nil {
+		return nil, fmt.Errorf("invalid snapshot %s: %w", path, err)
+	}
+
+	return &prog, nil
+}
+
+// loadSchema loads the ella files and returns the program of the root package,
+// declarations of the imported packages are included with qualified names
+func loadSchema(searchPaths ...string) (*ast.Program, error) {
+	filenames, err := mergeAllFiles(searchPaths...)
 	if err != nil {
 		return nil, err
 	}
 
-	return parser.ParseProgram(
-		parser.New(string(content)),
-	)
+	if len(filenames) == 0 {
+		return nil, fmt.Errorf("no ella's files found in the following paths: %s", strings.Join(searchPaths, ", "))
+	}
+
+	pkgs, err := loader.Load(filepath.Base(filepath.Dir(filenames[0])), filenames)
+	if err != nil {
+		return nil, err
+	}
+
+	return pkgs[len(pkgs)-1].Program, nil
 }
 
 func mergeAllFiles(paths ...string) ([]string, error) {
 	filenamesMap := make(map[string]struct{})
 
 	for _, path := range paths {
-		filenames, err := filepath.Glob(path)
+		filenames, err := glob(path)
 		if err != nil {
 			return nil, err
 		}
@@ -173,31 +643,88 @@ func mergeAllFiles(paths ...string) ([]string, error) {
 	for filename := range filenamesMap {
 		filenames = append(filenames, filename)
 	}
+	sort.Strings(filenames)
 
 	return filenames, nil
 }
 
-// combine is a helper function to concatenate multiple files into a single string
-// and returns an error if any of the files have an invalid extension or file cannot
-// be read.
-func combine(filenames ...string) (string, error) {
-	var sb strings.Builder
+// glob is similar to filepath.Glob, but it also supports ** which matches
+// any number of folders, e.g. ./schema/**/*.ella
+func glob(pattern string) ([]string, error) {
+	if !strings.Contains(pattern, "**") {
+		return filepath.Glob(pattern)
+	}
 
-	for i, filename := range filenames {
-		if !strings.HasSuffix(filename, ".ella") {
-			return "", fmt.Errorf("invalid file extension %s", filename)
+	patternSegments := strings.Split(filepath.ToSlash(filepath.Clean(pattern)), "/")
+
+	// walk from the longest folder which has no glob characters
+	i := 0
+	for i < len(patternSegments)-1 && !strings.ContainsAny(patternSegments[i], `*?[\`) {
+		i++
+	}
+
+	root := filepath.FromSlash(strings.Join(patternSegments[:i], "/"))
+	if root == "" && filepath.IsAbs(pattern) {
+		root = string(filepath.Separator)
+	} else if root == "" {
+		root = "."
+	}
+
+	var filenames []string
+
+	err := filepath.WalkDir(root, func(filename string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && filename == root {
+				return filepath.SkipDir
+			}
+			return err
 		}
 
-		content, err := os.ReadFile(filename)
+		if d.IsDir() {
+			return nil
+		}
+
+		ok, err := matchSegments(patternSegments, strings.Split(filepath.ToSlash(filename), "/"))
 		if err != nil {
-			return "", err
+			return err
+		}
+
+		if ok {
+			filenames = append(filenames, filename)
 		}
 
-		if i != 0 {
-			sb.WriteString("\n")
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return filenames, nil
+}
+
+func matchSegments(patterns, segments []string) (bool, error) {
+	if len(patterns) == 0 {
+		return len(segments) == 0, nil
+	}
+
+	if patterns[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			ok, err := matchSegments(patterns[1:], segments[i:])
+			if ok || err != nil {
+				return ok, err
+			}
 		}
-		sb.Write(content)
+		return false, nil
+	}
+
+	if len(segments) == 0 {
+		return false, nil
+	}
+
+	ok, err := filepath.Match(patterns[0], segments[0])
+	if !ok || err != nil {
+		return false, err
 	}
 
-	return sb.String(), nil
+	return matchSegments(patterns[1:], segments[1:])
 }
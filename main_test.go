@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	unformatted = "model User {\nName:   string\n}\n"
+	formatted   = "model User {\n\tName: string\n}\n"
+)
+
+// TestMain runs the command instead of the tests if ELLA_TEST_ARGS is set, so the
+// tests can check the exit code, stdout and stderr of the command
+func TestMain(m *testing.M) {
+	if args := os.Getenv("ELLA_TEST_ARGS"); args != "" {
+		os.Args = append([]string{"ella"}, strings.Split(args, "\n")...)
+		main()
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+// runElla runs the command with the args in a new process, stdin is passed to the command
+func runElla(t *testing.T, stdin string, args ...string) (stdout, stderr string, exitCode int) {
+	t.Helper()
+
+	var outBuf, errBuf bytes.Buffer
+
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), "ELLA_TEST_ARGS="+strings.Join(args, "\n"))
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+
+	return outBuf.String(), errBuf.String(), exitCode
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		filename := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filename), os.ModePerm))
+		assert.NoError(t, os.WriteFile(filename, []byte(content), os.ModePerm))
+	}
+}
+
+func TestFmtCheck(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"formatted.ella":   formatted,
+		"unformatted.ella": unformatted,
+	})
+
+	filename := filepath.Join(dir, "unformatted.ella")
+
+	stdout, stderr, exitCode := runElla(t, "", "fmt", "--check", filename)
+	assert.Equal(t, 1, exitCode)
+	assert.Contains(t, stdout, "-Name:   string\n+\tName: string\n")
+	assert.Equal(t, filename+": error: file is not formatted\n", stderr)
+
+	// the file is only checked
+	content, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, unformatted, string(content))
+
+	stdout, stderr, exitCode = runElla(t, "", "fmt", "--check", filepath.Join(dir, "formatted.ella"))
+	assert.Equal(t, 0, exitCode)
+	assert.Empty(t, stdout)
+	assert.Empty(t, stderr)
+}
+
+func TestFmtStdin(t *testing.T) {
+	stdout, stderr, exitCode := runElla(t, unformatted, "fmt", "-")
+	assert.Equal(t, 0, exitCode)
+	assert.Equal(t, formatted, stdout)
+	assert.Empty(t, stderr)
+
+	// the formatted schema is not changed anymore
+	stdout, _, exitCode = runElla(t, stdout, "fmt", "--check", "-")
+	assert.Equal(t, 0, exitCode)
+	assert.Empty(t, stdout)
+
+	_, stderr, exitCode = runElla(t, unformatted, "fmt", "--check", "-")
+	assert.Equal(t, 1, exitCode)
+	assert.Equal(t, "<stdin>: error: file is not formatted\n", stderr)
+}
+
+func TestFmtRecursiveGlob(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"schema/root.ella":       unformatted,
+		"schema/a/a.ella":        unformatted,
+		"schema/a/b/c/c.ella":    unformatted,
+		"schema/a/b/c/notes.txt": unformatted,
+		"other/other.ella":       unformatted,
+	})
+
+	_, _, exitCode := runElla(t, "", "fmt", filepath.Join(dir, "schema/**/*.ella"))
+	assert.Equal(t, 0, exitCode)
+
+	for name, expected := range map[string]string{
+		"schema/root.ella":       formatted,
+		"schema/a/a.ella":        formatted,
+		"schema/a/b/c/c.ella":    formatted,
+		"schema/a/b/c/notes.txt": unformatted,
+		"other/other.ella":       unformatted,
+	} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, string(content), name)
+	}
+}
+
+func TestGlob(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"schema/root.ella":    "",
+		"schema/a/a.ella":     "",
+		"schema/a/b/c/c.ella": "",
+		"schema/a/b/c/c.txt":  "",
+		"schema/d/x/c.ella":   "",
+	})
+
+	testCases := []struct {
+		Pattern  string
+		Expected []string
+	}{
+		{
+			Pattern:  "schema/**/*.ella",
+			Expected: []string{"schema/a/a.ella", "schema/a/b/c/c.ella", "schema/d/x/c.ella", "schema/root.ella"},
+		},
+		{
+			Pattern:  "schema/**/c/*.ella",
+			Expected: []string{"schema/a/b/c/c.ella"},
+		},
+		{
+			Pattern:  "schema/a/**/*.ella",
+			Expected: []string{"schema/a/a.ella", "schema/a/b/c/c.ella"},
+		},
+		{
+			Pattern:  "schema/**/c.*",
+			Expected: []string{"schema/a/b/c/c.ella", "schema/a/b/c/c.txt", "schema/d/x/c.ella"},
+		},
+		{
+			Pattern: "missing/**/*.ella",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Pattern, func(t *testing.T) {
+			filenames, err := mergeAllFiles(filepath.Join(dir, tc.Pattern))
+			assert.NoError(t, err)
+
+			expected := []string{}
+			for _, name := range tc.Expected {
+				expected = append(expected, filepath.Join(dir, name))
+			}
+
+			assert.Equal(t, expected, filenames)
+		})
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Args     []string
+		Expected options
+		Rest     []string
+		Err      string
+	}{
+		{
+			Name:     "check flag",
+			Args:     []string{"--check", "api.ella"},
+			Expected: options{check: true, format: "text"},
+			Rest:     []string{"api.ella"},
+		},
+		{
+			Name:     "file named check",
+			Args:     []string{"check"},
+			Expected: options{format: "text"},
+			Rest:     []string{"check"},
+		},
+		{
+			Name:     "stdin",
+			Args:     []string{"-check", "-"},
+			Expected: options{check: true, format: "text"},
+			Rest:     []string{"-"},
+		},
+		{
+			Name:     "format with =",
+			Args:     []string{"--format=json", "api.ella"},
+			Expected: options{format: "json"},
+			Rest:     []string{"api.ella"},
+		},
+		{
+			Name:     "format with separate value",
+			Args:     []string{"--format", "json", "api.ella"},
+			Expected: options{format: "json"},
+			Rest:     []string{"api.ella"},
+		},
+		{
+			Name:     "target and templates",
+			Args:     []string{"--target", "rpc.go", "rpc", "out", "-templates=./templates", "api.ella"},
+			Expected: options{format: "text", target: "rpc.go", templates: "./templates"},
+			Rest:     []string{"rpc", "out", "api.ella"},
+		},
+		{
+			Name:     "package named target",
+			Args:     []string{"target", "out.go", "api.ella"},
+			Expected: options{format: "text"},
+			Rest:     []string{"target", "out.go", "api.ella"},
+		},
+		{
+			Name:     "package named templates",
+			Args:     []string{"templates", "out.go", "api.ella"},
+			Expected: options{format: "text"},
+			Rest:     []string{"templates", "out.go", "api.ella"},
+		},
+		{
+			Name: "unknown format",
+			Args: []string{"--format", "yaml", "api.ella"},
+			Err:  `unknown format "yaml", expected text or json`,
+		},
+		{
+			Name: "missing format",
+			Args: []string{"api.ella", "--format"},
+			Err:  "missing value for --format",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			opts, rest, err := parseOptions(tc.Args)
+			if tc.Err != "" {
+				assert.EqualError(t, err, tc.Err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, opts)
+			assert.Equal(t, tc.Rest, rest)
+		})
+	}
+}
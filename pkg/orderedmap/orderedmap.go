@@ -0,0 +1,83 @@
+package orderedmap
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Map keeps the keys in the same order as they are set, and it is encoded
+// in json and yaml in the same order
+type Map[V any] struct {
+	keys   []string
+	values map[string]V
+}
+
+func New[V any]() *Map[V] {
+	return &Map[V]{values: make(map[string]V)}
+}
+
+func (m *Map[V]) Set(key string, value V) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *Map[V]) Get(key string) (V, bool) {
+	value, ok := m.values[key]
+	return value, ok
+}
+
+func (m *Map[V]) Len() int {
+	return len(m.keys)
+}
+
+func (m *Map[V]) Keys() []string {
+	return m.keys
+}
+
+func (m *Map[V]) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	// html characters are not escaped as they are common in patterns and descriptions
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	buf.WriteString("{")
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+
+		if err := enc.Encode(key); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1)
+		buf.WriteString(":")
+
+		if err := enc.Encode(m.values[key]); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.WriteString("}")
+
+	return buf.Bytes(), nil
+}
+
+func (m *Map[V]) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+
+	for _, key := range m.keys {
+		var value yaml.Node
+		if err := value.Encode(m.values[key]); err != nil {
+			return nil, err
+		}
+
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, &value)
+	}
+
+	return node, nil
+}